@@ -0,0 +1,292 @@
+//go:build js && wasm
+
+// Command ipcalc-wasm exposes the calculator to JavaScript. It registers a
+// global ipcalc object whose functions return {html} holding the HTML
+// formatter output, or {error} holding the error message.
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"syscall/js"
+
+	"github.com/neontowel/ipcalc-go/internal/version"
+	"github.com/neontowel/ipcalc-go/pkg/calculator"
+	"github.com/neontowel/ipcalc-go/pkg/formatter"
+)
+
+func main() {
+	js.Global().Set("ipcalc", js.ValueOf(map[string]any{
+		"version":     version.Version,
+		"calculate":   export(calculate),
+		"split":       export(split),
+		"subnets":     export(subnets),
+		"deaggregate": export(deaggregate),
+		"aggregate":   export(aggregate),
+		"classify":    export(classify),
+	}))
+
+	// Keep the Go runtime alive to serve calls from JavaScript
+	select {}
+}
+
+// export wraps a calculation as a JavaScript function. Errors and panics
+// are returned to JavaScript as {error} rather than stopping the runtime.
+func export(fn func(args []js.Value) (string, error)) js.Func {
+	return js.FuncOf(func(this js.Value, args []js.Value) (result any) {
+		defer func() {
+			if r := recover(); r != nil {
+				result = map[string]any{"error": fmt.Sprint(r)}
+			}
+		}()
+
+		html, err := fn(args)
+		if err != nil {
+			return map[string]any{"error": err.Error()}
+		}
+		return map[string]any{"html": html}
+	})
+}
+
+// htmlFormat returns the HTML output format, with binary output and the
+// network/host boundary marker when the options object sets binary and
+// boundary
+func htmlFormat(options js.Value) formatter.OutputFormat {
+	format := formatter.OutputFormat{UseHTML: true}
+	if options.Type() == js.TypeObject {
+		format.UseBinary = options.Get("binary").Truthy()
+		format.MarkBoundary = options.Get("boundary").Truthy()
+	}
+	return format
+}
+
+// toString converts a JavaScript value to a string the way JavaScript's
+// String() does, so numbers such as host counts are accepted
+func toString(value js.Value) string {
+	return js.Global().Get("String").Invoke(value).String()
+}
+
+// stringArg returns argument i, a string or number, as a trimmed string
+func stringArg(args []js.Value, i int, name string) (string, error) {
+	if i >= len(args) || (args[i].Type() != js.TypeString && args[i].Type() != js.TypeNumber) {
+		return "", fmt.Errorf("%s must be a string", name)
+	}
+	value := strings.TrimSpace(toString(args[i]))
+	if value == "" {
+		return "", fmt.Errorf("%s is empty", name)
+	}
+	return value, nil
+}
+
+// stringsArg returns argument i as a list of strings. It accepts an array
+// or a string of whitespace- or comma-separated values.
+func stringsArg(args []js.Value, i int, name string) ([]string, error) {
+	if i >= len(args) {
+		return nil, fmt.Errorf("%s is missing", name)
+	}
+
+	var values []string
+	switch arg := args[i]; arg.Type() {
+	case js.TypeString:
+		values = strings.FieldsFunc(arg.String(), func(r rune) bool {
+			return r == ',' || r == ' ' || r == '\t' || r == '\n' || r == '\r'
+		})
+	case js.TypeObject:
+		for j := 0; j < arg.Length(); j++ {
+			if value := strings.TrimSpace(toString(arg.Index(j))); value != "" {
+				values = append(values, value)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("%s must be an array or a string", name)
+	}
+
+	if len(values) == 0 {
+		return nil, fmt.Errorf("%s is empty", name)
+	}
+	return values, nil
+}
+
+// splitNetwork splits "address/mask" into its parts, defaulting to /24 for
+// IPv4 and /64 for IPv6 like the command line. Brackets and ports are
+// dropped and zone IDs kept.
+func splitNetwork(network string) (string, string, error) {
+	in, err := calculator.ParseInput(network)
+	if err != nil {
+		return "", "", err
+	}
+	switch {
+	case in.Prefix != "":
+		return in.Host(), in.Prefix, nil
+	case in.IPv6:
+		return in.Host(), "64", nil
+	default:
+		return in.Host(), "24", nil
+	}
+}
+
+// calculate(network, {binary, boundary}) shows the details of a network
+func calculate(args []js.Value) (string, error) {
+	network, err := stringArg(args, 0, "network")
+	if err != nil {
+		return "", err
+	}
+	var options js.Value
+	if len(args) > 1 {
+		options = args[1]
+	}
+	format := htmlFormat(options)
+
+	ipStr, maskStr, err := splitNetwork(network)
+	if err != nil {
+		return "", err
+	}
+	if strings.Contains(ipStr, ":") {
+		ipv6, err := calculator.CalculateIPv6Network(ipStr, maskStr)
+		if err != nil {
+			return "", err
+		}
+		return formatter.FormatIPv6Network(ipv6, format), nil
+	}
+
+	ipv4, err := calculator.CalculateNetwork(ipStr, maskStr)
+	if err != nil {
+		return "", err
+	}
+	return formatter.FormatIPv4Network(ipv4, format), nil
+}
+
+// split(network, sizes) splits an IPv4 network into subnets holding at
+// least the given numbers of hosts
+func split(args []js.Value) (string, error) {
+	network, err := stringArg(args, 0, "network")
+	if err != nil {
+		return "", err
+	}
+	sizeStrs, err := stringsArg(args, 1, "sizes")
+	if err != nil {
+		return "", err
+	}
+
+	var sizes []int
+	for _, sizeStr := range sizeStrs {
+		size, err := strconv.Atoi(sizeStr)
+		if err != nil {
+			return "", fmt.Errorf("invalid size: %s", sizeStr)
+		}
+		sizes = append(sizes, size)
+	}
+
+	ipStr, maskStr, err := splitNetwork(network)
+	if err != nil {
+		return "", err
+	}
+	if strings.Contains(ipStr, ":") {
+		return "", fmt.Errorf("IPv6 splitting is not supported yet")
+	}
+	networks, err := calculator.SplitNetwork(ipStr, maskStr, sizes)
+	if err != nil {
+		return "", err
+	}
+	return formatter.FormatSplitNetwork(networks, formatter.OutputFormat{UseHTML: true}), nil
+}
+
+// subnets(network, spec) splits a network into equal subnets, either a
+// number of subnets or a "/prefix" length
+func subnets(args []js.Value) (string, error) {
+	network, err := stringArg(args, 0, "network")
+	if err != nil {
+		return "", err
+	}
+	spec, err := stringArg(args, 1, "subnet count or /prefix")
+	if err != nil {
+		return "", err
+	}
+
+	ipStr, maskStr, err := splitNetwork(network)
+	if err != nil {
+		return "", err
+	}
+	var result []calculator.Subnet
+	if prefixStr, found := strings.CutPrefix(spec, "/"); found {
+		prefix, convErr := strconv.Atoi(prefixStr)
+		if convErr != nil {
+			return "", fmt.Errorf("invalid prefix length: %s", spec)
+		}
+		result, err = calculator.SplitNetworkByPrefix(ipStr, maskStr, prefix)
+	} else {
+		count, convErr := strconv.Atoi(spec)
+		if convErr != nil {
+			return "", fmt.Errorf("invalid subnet count: %s", spec)
+		}
+		result, err = calculator.SplitNetworkByCount(ipStr, maskStr, count)
+	}
+	if err != nil {
+		return "", err
+	}
+	return formatter.FormatSubnets(result, formatter.OutputFormat{UseHTML: true}), nil
+}
+
+// deaggregate(start, end) returns the networks covering an IPv4 or IPv6 range
+func deaggregate(args []js.Value) (string, error) {
+	start, err := stringArg(args, 0, "start address")
+	if err != nil {
+		return "", err
+	}
+	end, err := stringArg(args, 1, "end address")
+	if err != nil {
+		return "", err
+	}
+
+	networks, err := calculator.DeaggregateAny(start, end)
+	if err != nil {
+		return "", err
+	}
+	return formatter.FormatDeaggregation(networks, formatter.OutputFormat{UseHTML: true}), nil
+}
+
+// aggregate(networks) merges networks into the fewest covering prefixes
+func aggregate(args []js.Value) (string, error) {
+	networks, err := stringsArg(args, 0, "networks")
+	if err != nil {
+		return "", err
+	}
+	merged, err := calculator.Aggregate(networks)
+	if err != nil {
+		return "", err
+	}
+	return formatter.FormatDeaggregation(merged, formatter.OutputFormat{UseHTML: true}), nil
+}
+
+// classify(address) describes what an address is used for
+func classify(args []js.Value) (string, error) {
+	address, err := stringArg(args, 0, "address")
+	if err != nil {
+		return "", err
+	}
+	result, err := calculator.Classify(address)
+	if err != nil {
+		return "", err
+	}
+
+	format := formatter.OutputFormat{UseHTML: true}
+	lines := []string{
+		formatter.FormatValue("Address", result.Address, format),
+		formatter.FormatValue("Family", result.Family, format),
+	}
+	if result.Class != "" {
+		lines = append(lines, formatter.FormatValue("Class", result.Class, format))
+	}
+	lines = append(lines,
+		formatter.FormatValue("Category", result.Category, format),
+		formatter.FormatValue("Usage", result.Description, format))
+	if result.Range != "" {
+		lines = append(lines, formatter.FormatValue("Range", result.Range, format))
+	}
+	if result.RFC != "" {
+		lines = append(lines, formatter.FormatValue("RFC", result.RFC, format))
+	}
+	lines = append(lines, formatter.FormatValue("Global", strconv.FormatBool(result.Global), format))
+	return strings.Join(lines, "<br>\n") + "<br>\n", nil
+}
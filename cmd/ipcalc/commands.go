@@ -0,0 +1,562 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/neontowel/ipcalc-go/internal/version"
+	"github.com/neontowel/ipcalc-go/pkg/calculator"
+	"github.com/neontowel/ipcalc-go/pkg/formatter"
+	"github.com/neontowel/ipcalc-go/pkg/planner"
+	"github.com/spf13/pflag"
+)
+
+// command is an ipcalc subcommand. Its setup function defines its flags
+// and returns the function that runs it once they are parsed, so help and
+// shell completions are built from the same definitions.
+type command struct {
+	name    string
+	args    string   // Synopsis of the arguments
+	summary string   // One-line description
+	help    string   // Longer description for the command's help
+	values  []string // Values its arguments complete to, if fixed
+	setup   func(flags *pflag.FlagSet, cfg config) func(args []string)
+}
+
+// commands are the subcommands in the order of the help. They are set in
+// init because help and completion refer to them.
+var commands []command
+
+func init() {
+	commands = []command{
+		{"info", "ADDRESS[/PREFIX] [NETMASK]", "Show the network of an address",
+			"Show the address, netmask, network, host range and broadcast of a network,\nas ipcalc does without a command.", nil, setupInfo},
+		{"split", "NETWORK SIZE...", "Split a network into subnets",
+			"Split a network into subnets with room for the given numbers of hosts, or\ninto equal subnets of a /PREFIX or, with --equal, a given count.", nil, setupSplit},
+		{"range", "TARGET...", "Deaggregate address ranges into networks",
+			"Print the fewest networks covering address ranges and nmap-style targets,\nsuch as 10.0.0.5-10.0.0.50, 10.0.1-3.1-254 or two addresses.", nil, setupRange},
+		{"aggregate", "[NETWORK...]", "Merge networks into the fewest blocks",
+			"Merge overlapping and adjacent networks into the fewest networks covering\nthe same addresses. Networks are read from stdin if none are given.", nil, setupAggregate},
+		{"exclude", "NETWORK EXCLUDED...", "Remove networks from a network",
+			"Print the fewest networks covering the addresses of NETWORK that are not in\nany of the excluded networks or ranges.", nil, setupExclude},
+		{"contains", "NETWORK ADDRESS...", "Check whether a network contains addresses",
+			"Check whether a network contains each address, network or range, such as\n10.0.0.5-50. With --quiet, the exit status is 0 if it contains all of them\nand 1 otherwise.", nil, setupContains},
+		{"list", "TARGET...", "List the addresses of networks and ranges",
+			"Print every address of the networks, ranges and nmap-style targets.", nil, setupList},
+		{"plan", "vpc|k8s NETWORK", "Plan a VPC or Kubernetes network layout",
+			"Plan the subnets of a VPC across availability zones (vpc), or the pod and\nservice networks of a Kubernetes cluster whose nodes use NETWORK (k8s).", []string{"vpc", "k8s"}, setupPlan},
+		{"cidrsubnet", "PREFIX NEWBITS NETNUM", "Calculate a subnet address within a prefix",
+			"Calculate a subnet address within a prefix as Terraform's cidrsubnet does.\nGiven a subnet instead of NEWBITS and NETNUM, as in\n\"ipcalc cidrsubnet 10.0.0.0/16 10.0.2.0/24\", print the NEWBITS and NETNUM\nproducing it.", nil, setupTerraform("cidrsubnet")},
+		{"cidrhost", "PREFIX HOSTNUM", "Calculate a host address within a prefix",
+			"Calculate a host address within a prefix as Terraform's cidrhost does. A\nnegative HOSTNUM counts back from the end of the prefix.", nil, setupTerraform("cidrhost")},
+		{"cidrnetmask", "PREFIX", "Print the netmask of an IPv4 prefix",
+			"Print the netmask of an IPv4 prefix as Terraform's cidrnetmask does.", nil, setupTerraform("cidrnetmask")},
+		{"cidrsubnets", "PREFIX NEWBITS...", "Calculate consecutive subnets within a prefix",
+			"Calculate consecutive subnets within a prefix, each NEWBITS longer than it,\nas Terraform's cidrsubnets does.", nil, setupTerraform("cidrsubnets")},
+		{"serve", "", "Serve the calculator as a JSON HTTP API",
+			"Serve the calculator as a JSON HTTP API, with the OpenAPI document at\n/openapi.json.", nil, setupServe},
+		{"completion", "bash|zsh|fish", "Print a shell completion script",
+			"Print the completion script for a shell. For example:\n\n  ipcalc completion bash > /etc/bash_completion.d/ipcalc\n  ipcalc completion zsh > \"${fpath[1]}/_ipcalc\"\n  ipcalc completion fish > ~/.config/fish/completions/ipcalc.fish", []string{"bash", "zsh", "fish"}, setupCompletion},
+		{"help", "[COMMAND]", "Show the help of ipcalc or a command", "", nil, setupHelp},
+	}
+	help := findCommand("help")
+	help.values = commandNames()
+}
+
+// findCommand returns the command with the given name, or nil
+func findCommand(name string) *command {
+	for i := range commands {
+		if commands[i].name == name {
+			return &commands[i]
+		}
+	}
+	return nil
+}
+
+// firstArgument returns the index of the first argument that is neither a
+// flag nor the value of one, or -1 if there is none
+func firstArgument(args []string, flags *pflag.FlagSet) int {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--":
+			return -1
+		case strings.HasPrefix(arg, "--"):
+			name, _, hasValue := strings.Cut(arg[2:], "=")
+			if f := flags.Lookup(name); f != nil && !hasValue && f.NoOptDefVal == "" {
+				i++
+			}
+		case strings.HasPrefix(arg, "-") && len(arg) > 1:
+			// Shorthands may be combined, as in -nb, and the first that
+			// takes a value takes the rest of the argument, or the next one
+			for j := 1; j < len(arg); j++ {
+				f := flags.ShorthandLookup(arg[j : j+1])
+				if f == nil || f.NoOptDefVal != "" {
+					continue
+				}
+				if j == len(arg)-1 {
+					i++
+				}
+				break
+			}
+		default:
+			return i
+		}
+	}
+	return -1
+}
+
+// newFlagSet returns the flag set of a command with its flags defined,
+// and the function running it
+func (c *command) newFlagSet(cfg config) (*pflag.FlagSet, func(args []string)) {
+	flags := pflag.NewFlagSet(c.name, pflag.ContinueOnError)
+	flags.SortFlags = false
+	flags.SetOutput(io.Discard)
+	flags.Usage = func() {}
+	return flags, c.setup(flags, cfg)
+}
+
+// runCommand parses the flags of a command and runs it. An error loading
+// the config is reported unless help was asked for.
+func runCommand(c *command, args []string, cfg config, configErr error) {
+	flags, run := c.newFlagSet(cfg)
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, pflag.ErrHelp) {
+			c.printHelp(flags, os.Stdout)
+			return
+		}
+		fmt.Fprintf(os.Stderr, "Error: %v (run \"ipcalc help %s\" for usage)\n", err, c.name)
+		os.Exit(1)
+	}
+	if configErr != nil && c.name != "help" {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", configErr)
+		os.Exit(1)
+	}
+	run(flags.Args())
+}
+
+// printHelp prints the usage, description and flags of a command
+func (c *command) printHelp(flags *pflag.FlagSet, w *os.File) {
+	fmt.Fprintf(w, "Usage: ipcalc %s", c.name)
+	if flags.HasFlags() {
+		fmt.Fprint(w, " [options]")
+	}
+	if c.args != "" {
+		fmt.Fprintf(w, " %s", c.args)
+	}
+	fmt.Fprintln(w)
+
+	help := c.help
+	if help == "" {
+		help = c.summary + "."
+	}
+	fmt.Fprintf(w, "\n%s\n", help)
+	if flags.HasFlags() {
+		fmt.Fprintf(w, "\nOptions:\n%s", flags.FlagUsages())
+	}
+}
+
+// usageError reports wrong arguments to a command and exits
+func usageError(c *command) {
+	fmt.Fprintf(os.Stderr, "Error: Usage: ipcalc %s %s\n", c.name, c.args)
+	os.Exit(1)
+}
+
+// completionValues is the flag annotation holding the values a flag's
+// argument completes to
+const completionValues = "ipcalc_values"
+
+// setCompletionValues records the values a flag's argument completes to
+func setCompletionValues(flags *pflag.FlagSet, name string, values []string) {
+	_ = flags.SetAnnotation(name, completionValues, values)
+}
+
+// outputFlags are the flags controlling how results are shown, shared by
+// the classic flags and the commands
+type outputFlags struct {
+	noColor  *bool
+	noBinary *bool
+	boundary *bool
+	theme    *string
+	html     *bool
+}
+
+// addOutputFlags defines the output flags with the defaults of the config
+func addOutputFlags(flags *pflag.FlagSet, cfg config) *outputFlags {
+	out := &outputFlags{
+		noColor:  flags.BoolP("nocolor", "n", cfg.NoColor, "Don't display ANSI color codes"),
+		noBinary: flags.BoolP("nobinary", "b", !cfg.Binary, "Suppress the bitwise output"),
+		boundary: flags.Bool("boundary", false, "Mark the network/host boundary in the bitwise output"),
+		theme:    flags.String("theme", cfg.Theme, "Color theme for terminal output"),
+		html:     flags.BoolP("html", "H", cfg.Output == "html", "Display results as HTML"),
+	}
+	setCompletionValues(flags, "theme", cfg.themeNames())
+	return out
+}
+
+// format returns the output format the flags select
+func (out *outputFlags) format(cfg config) formatter.OutputFormat {
+	colors, err := cfg.colors(*out.theme)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	return formatter.OutputFormat{
+		UseColor:     !*out.noColor && !*out.html && isTerminal(),
+		UseHTML:      *out.html,
+		UseBinary:    !*out.noBinary,
+		MarkBoundary: *out.boundary,
+		Colors:       &colors,
+	}
+}
+
+// firewallFlags are the flags selecting firewall rule output
+type firewallFlags struct {
+	target *string
+	name   *string
+	deny   *bool
+}
+
+// addFirewallFlags defines the firewall flags
+func addFirewallFlags(flags *pflag.FlagSet) *firewallFlags {
+	fw := &firewallFlags{
+		target: flags.StringP("firewall", "f", "", "Print networks as firewall rules (cisco, iptables, ip6tables, nftables, ipset, pf)"),
+		name:   flags.String("fw-name", "", "ACL, chain, set or table name for firewall rules"),
+		deny:   flags.Bool("fw-deny", false, "Generate deny rules instead of permit rules"),
+	}
+	setCompletionValues(flags, "firewall", []string{"cisco", "iptables", "ip6tables", "nftables", "ipset", "pf"})
+	return fw
+}
+
+// options returns the firewall output options, or nil if no firewall is
+// given. Firewall rules are never printed as HTML.
+func (fw *firewallFlags) options(format *formatter.OutputFormat) *formatter.FirewallOptions {
+	if *fw.target == "" {
+		return nil
+	}
+	target, err := formatter.ParseFirewallTarget(*fw.target)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	format.UseHTML = false
+	return &formatter.FirewallOptions{
+		Target: target,
+		Name:   *fw.name,
+		Deny:   *fw.deny,
+	}
+}
+
+// addCloudFlag defines the cloud provider flag with the default of the config
+func addCloudFlag(flags *pflag.FlagSet, cfg config) *string {
+	cloud := flags.String("cloud", cfg.Cloud, "Apply the reserved addresses and subnet sizes of a cloud provider (aws, azure, gcp)")
+	setCompletionValues(flags, "cloud", []string{"aws", "azure", "gcp"})
+	return cloud
+}
+
+// lookupCloudProvider returns the cloud provider preset with the given
+// name, or nil if the name is empty
+func lookupCloudProvider(name string) *calculator.CloudProvider {
+	if name == "" {
+		return nil
+	}
+	provider, err := calculator.GetCloudProvider(name)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	return &provider
+}
+
+// printPage prints the output of print, wrapped in an HTML page if the
+// format is HTML
+func printPage(format formatter.OutputFormat, print func()) {
+	if format.UseHTML {
+		fmt.Print(formatter.FormatHTMLHeader())
+		fmt.Printf("<!-- Version %s -->\n", version.Version)
+	}
+	print()
+	if format.UseHTML {
+		fmt.Print(formatter.FormatHTMLFooter())
+	}
+}
+
+// setupInfo defines the flags of the info command
+func setupInfo(flags *pflag.FlagSet, cfg config) func(args []string) {
+	out := addOutputFlags(flags, cfg)
+	numericFormat := flags.StringSlice("format", nil, "Also show addresses as numbers (dec, hex, oct, all)")
+	strict := flags.Bool("strict", false, "Reject ambiguous legacy address forms such as 10.1 or 010.0.0.1")
+	cloud := addCloudFlag(flags, cfg)
+	fw := addFirewallFlags(flags)
+	setCompletionValues(flags, "format", []string{"dec", "hex", "oct", "all"})
+
+	return func(args []string) {
+		if len(args) == 0 || len(args) > 3 {
+			usageError(findCommand("info"))
+		}
+		format := out.format(cfg)
+		numericForms, err := formatter.ParseNumericForms(*numericFormat)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		strictInput = *strict
+		firewall := fw.options(&format)
+		provider := lookupCloudProvider(*cloud)
+
+		printPage(format, func() {
+			handleNormal(args, format, numericForms, firewall, provider)
+		})
+	}
+}
+
+// setupSplit defines the flags of the split command
+func setupSplit(flags *pflag.FlagSet, cfg config) func(args []string) {
+	out := addOutputFlags(flags, cfg)
+	equal := flags.BoolP("equal", "e", false, "Split into the given number of equal subnets")
+	fw := addFirewallFlags(flags)
+
+	return func(args []string) {
+		if len(args) < 2 {
+			usageError(findCommand("split"))
+		}
+		format := out.format(cfg)
+		firewall := fw.options(&format)
+		printPage(format, func() {
+			handleSplit(args[0], args[1:], *equal, format, firewall)
+		})
+	}
+}
+
+// setupRange defines the flags of the range command
+func setupRange(flags *pflag.FlagSet, cfg config) func(args []string) {
+	out := addOutputFlags(flags, cfg)
+	count := flags.Bool("count", false, "Count the addresses instead of listing networks")
+	fw := addFirewallFlags(flags)
+
+	return func(args []string) {
+		if len(args) == 0 {
+			usageError(findCommand("range"))
+		}
+		format := out.format(cfg)
+		firewall := fw.options(&format)
+		printPage(format, func() {
+			handleDeaggregate(args, *count, false, format, firewall)
+		})
+	}
+}
+
+// setupAggregate defines the flags of the aggregate command
+func setupAggregate(flags *pflag.FlagSet, cfg config) func(args []string) {
+	out := addOutputFlags(flags, cfg)
+	fw := addFirewallFlags(flags)
+
+	return func(args []string) {
+		if len(args) == 0 {
+			args = readWords(os.Stdin)
+		}
+		if len(args) == 0 {
+			usageError(findCommand("aggregate"))
+		}
+		networks, err := calculator.Aggregate(args)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		printNetworks(networks, out.format(cfg), fw)
+	}
+}
+
+// setupExclude defines the flags of the exclude command
+func setupExclude(flags *pflag.FlagSet, cfg config) func(args []string) {
+	out := addOutputFlags(flags, cfg)
+	fw := addFirewallFlags(flags)
+
+	return func(args []string) {
+		if len(args) < 2 {
+			usageError(findCommand("exclude"))
+		}
+		networks, err := calculator.Exclude(args[:1], args[1:])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		printNetworks(networks, out.format(cfg), fw)
+	}
+}
+
+// printNetworks prints a list of networks, or firewall rules for them
+func printNetworks(networks []string, format formatter.OutputFormat, fw *firewallFlags) {
+	if len(networks) == 0 {
+		return
+	}
+	if firewall := fw.options(&format); firewall != nil {
+		printFirewall(networks, firewall)
+		return
+	}
+	printPage(format, func() {
+		fmt.Println(formatter.FormatDeaggregation(networks, format))
+	})
+}
+
+// readWords reads whitespace-separated words, such as a list of networks,
+// ignoring comments from '#' to the end of the line
+func readWords(file *os.File) []string {
+	var words []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		words = append(words, strings.Fields(line)...)
+	}
+	if err := scanner.Err(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	return words
+}
+
+// setupContains defines the flags of the contains command
+func setupContains(flags *pflag.FlagSet, cfg config) func(args []string) {
+	quiet := flags.BoolP("quiet", "q", false, "Print nothing; exit with status 1 unless all are contained")
+
+	return func(args []string) {
+		if len(args) < 2 {
+			usageError(findCommand("contains"))
+		}
+		all := true
+		for _, arg := range args[1:] {
+			contained, err := calculator.Contains(args[0], arg)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			all = all && contained
+			if *quiet {
+				continue
+			}
+			answer := "no"
+			if contained {
+				answer = "yes"
+			}
+			fmt.Printf("%-20s %s\n", arg, answer)
+		}
+		if *quiet && !all {
+			os.Exit(1)
+		}
+	}
+}
+
+// setupList defines the flags of the list command
+func setupList(flags *pflag.FlagSet, cfg config) func(args []string) {
+	limit := flags.Int("limit", cfg.ListLimit, "Stop after this many addresses (0 lists all)")
+
+	return func(args []string) {
+		if len(args) == 0 {
+			usageError(findCommand("list"))
+		}
+		targets, err := calculator.ParseTargets(args)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		printAddresses(targets, *limit)
+	}
+}
+
+// setupPlan defines the flags of the plan command
+func setupPlan(flags *pflag.FlagSet, cfg config) func(args []string) {
+	out := addOutputFlags(flags, cfg)
+	output := flags.StringP("output", "o", "text", "Output format (text, json, terraform for vpc)")
+	cloud := addCloudFlag(flags, cfg)
+	zones := flags.Int("zones", 3, "Number of availability zones for vpc")
+	spareZones := flags.Int("spare-zones", 1, "Zone blocks to keep free for growth for vpc")
+	tiers := flags.StringSlice("tier", []string{"public:1", "private:2", "data:1"}, "Subnet tiers for vpc as name:weight or name:<hosts>h")
+	nodes := flags.Int("nodes", 100, "Maximum node count for k8s")
+	podsPerNode := flags.Int("pods-per-node", 110, "Maximum pods per node for k8s")
+	services := flags.Int("services", 1000, "Service count for k8s")
+	podCIDR := flags.StringSlice("pod-cidr", nil, "Pod CIDRs for k8s, one per address family (planned if not given)")
+	serviceCIDR := flags.StringSlice("service-cidr", nil, "Service CIDRs for k8s, one per address family (planned if not given)")
+	supernet := flags.StringSlice("supernet", nil, "Networks to plan pod and service CIDRs from for k8s (default 10.0.0.0/8)")
+	nodeMask := flags.Int("node-mask", 0, "IPv4 per-node pod mask size for k8s (sized from pods per node if not given)")
+	nodeMask6 := flags.Int("node-mask6", 0, "IPv6 per-node pod mask size for k8s (default 64)")
+	setCompletionValues(flags, "output", []string{"text", "json", "terraform"})
+
+	return func(args []string) {
+		if len(args) != 2 {
+			usageError(findCommand("plan"))
+		}
+		format := out.format(cfg)
+
+		switch args[0] {
+		case "vpc":
+			handleVPCPlan(newVPCSpec(args[1], *zones, *spareZones, *tiers, lookupCloudProvider(*cloud)), *output, format)
+		case "k8s":
+			spec := planner.KubernetesSpec{
+				NodeCIDR:       args[1],
+				PodCIDRs:       *podCIDR,
+				ServiceCIDRs:   *serviceCIDR,
+				Supernets:      *supernet,
+				MaxNodes:       *nodes,
+				MaxPodsPerNode: *podsPerNode,
+				Services:       *services,
+				NodeMaskSize:   *nodeMask,
+				NodeMaskSize6:  *nodeMask6,
+			}
+			handleKubernetesPlan(spec, *output, format)
+		default:
+			fmt.Fprintf(os.Stderr, "Error: Unknown plan: %s (must be vpc or k8s)\n", args[0])
+			os.Exit(1)
+		}
+	}
+}
+
+// newVPCSpec returns the specification of a VPC plan, parsing its tiers
+func newVPCSpec(cidr string, zones, spareZones int, tierStrs []string, provider *calculator.CloudProvider) planner.VPCSpec {
+	spec := planner.VPCSpec{
+		CIDR:       cidr,
+		Zones:      zones,
+		SpareZones: spareZones,
+		Provider:   provider,
+	}
+	for _, tierStr := range tierStrs {
+		tier, err := planner.ParseTier(tierStr)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		spec.Tiers = append(spec.Tiers, tier)
+	}
+	return spec
+}
+
+// setupTerraform returns the setup of a Terraform-compatible function,
+// whose arguments may be negative numbers and so are not parsed as flags
+func setupTerraform(name string) func(flags *pflag.FlagSet, cfg config) func(args []string) {
+	return func(flags *pflag.FlagSet, cfg config) func(args []string) {
+		flags.SetInterspersed(false)
+		return func(args []string) {
+			handleTerraformFunction(name, args)
+		}
+	}
+}
+
+// setupHelp defines the flags of the help command
+func setupHelp(flags *pflag.FlagSet, cfg config) func(args []string) {
+	return func(args []string) {
+		if len(args) == 0 {
+			printUsage()
+			return
+		}
+		c := findCommand(args[0])
+		if c == nil {
+			fmt.Fprintf(os.Stderr, "Error: Unknown command: %s\n", args[0])
+			os.Exit(1)
+		}
+		commandFlags, _ := c.newFlagSet(cfg)
+		c.printHelp(commandFlags, os.Stdout)
+	}
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// completionFlag is a flag as shell completions need it
+type completionFlag struct {
+	name      string
+	shorthand string
+	usage     string
+	takesArg  bool
+	values    []string // Values its argument completes to, if fixed
+}
+
+// completionFlags returns the flags of a flag set for completion
+func completionFlags(flags *pflag.FlagSet) []completionFlag {
+	var result []completionFlag
+	flags.VisitAll(func(f *pflag.Flag) {
+		result = append(result, completionFlag{
+			name:      f.Name,
+			shorthand: f.Shorthand,
+			usage:     f.Usage,
+			takesArg:  f.NoOptDefVal == "",
+			values:    f.Annotations[completionValues],
+		})
+	})
+	return result
+}
+
+// setupCompletion defines the flags of the completion command
+func setupCompletion(flags *pflag.FlagSet, cfg config) func(args []string) {
+	return func(args []string) {
+		if len(args) != 1 {
+			usageError(findCommand("completion"))
+		}
+
+		// The classic flags are defined on the command line before
+		// commands run
+		classic := completionFlags(pflag.CommandLine)
+		commandFlags := make(map[string][]completionFlag)
+		for _, c := range commands {
+			flags, _ := c.newFlagSet(cfg)
+			commandFlags[c.name] = completionFlags(flags)
+		}
+
+		switch args[0] {
+		case "bash":
+			fmt.Print(bashCompletion(classic, commandFlags))
+		case "zsh":
+			fmt.Print(zshCompletion(classic, commandFlags))
+		case "fish":
+			fmt.Print(fishCompletion(classic, commandFlags))
+		default:
+			fmt.Fprintf(os.Stderr, "Error: Unknown shell: %s (must be bash, zsh or fish)\n", args[0])
+			os.Exit(1)
+		}
+	}
+}
+
+// bashCompletion returns the bash completion script
+func bashCompletion(classic []completionFlag, commandFlags map[string][]completionFlag) string {
+	var b strings.Builder
+	b.WriteString("# bash completion for ipcalc, generated by \"ipcalc completion bash\"\n\n")
+	b.WriteString("_ipcalc() {\n")
+	b.WriteString("    local cur=\"${COMP_WORDS[COMP_CWORD]}\" prev=\"${COMP_WORDS[COMP_CWORD-1]}\"\n")
+	b.WriteString("    local command=\"\" words=\"\"\n")
+	b.WriteString("    if [[ $COMP_CWORD -gt 1 ]]; then\n")
+	b.WriteString("        command=\"${COMP_WORDS[1]}\"\n")
+	b.WriteString("    fi\n\n")
+
+	writeValues := func(flags []completionFlag) {
+		b.WriteString("        case \"$prev\" in\n")
+		for _, f := range flags {
+			if !f.takesArg {
+				continue
+			}
+			b.WriteString("        " + bashFlagPattern(f) + ")\n")
+			if len(f.values) > 0 {
+				fmt.Fprintf(&b, "            COMPREPLY=($(compgen -W %q -- \"$cur\"))\n", strings.Join(f.values, " "))
+			} else {
+				b.WriteString("            COMPREPLY=()\n")
+			}
+			b.WriteString("            return ;;\n")
+		}
+		b.WriteString("        esac\n")
+	}
+
+	b.WriteString("    case \"$command\" in\n")
+	for _, c := range commands {
+		flags := commandFlags[c.name]
+		fmt.Fprintf(&b, "    %s)\n", c.name)
+		writeValues(flags)
+		fmt.Fprintf(&b, "        words=%q ;;\n", strings.Join(append(flagWords(flags), c.values...), " "))
+	}
+	b.WriteString("    *)\n")
+	writeValues(classic)
+	b.WriteString("        words=" + fmt.Sprintf("%q", strings.Join(flagWords(classic), " ")) + "\n")
+	b.WriteString("        if [[ $COMP_CWORD -eq 1 ]]; then\n")
+	fmt.Fprintf(&b, "            words=\"%s $words\"\n", strings.Join(commandNames(), " "))
+	b.WriteString("        fi ;;\n")
+	b.WriteString("    esac\n")
+	b.WriteString("    COMPREPLY=($(compgen -W \"$words\" -- \"$cur\"))\n")
+	b.WriteString("}\n\n")
+	b.WriteString("complete -o default -F _ipcalc ipcalc\n")
+	return b.String()
+}
+
+// bashFlagPattern returns the case pattern matching a flag
+func bashFlagPattern(f completionFlag) string {
+	if f.shorthand != "" {
+		return "-" + f.shorthand + "|--" + f.name
+	}
+	return "--" + f.name
+}
+
+// flagWords returns the words of a list of flags
+func flagWords(flags []completionFlag) []string {
+	var words []string
+	for _, f := range flags {
+		words = append(words, "--"+f.name)
+		if f.shorthand != "" {
+			words = append(words, "-"+f.shorthand)
+		}
+	}
+	return words
+}
+
+// commandNames returns the names of the commands
+func commandNames() []string {
+	var names []string
+	for _, c := range commands {
+		names = append(names, c.name)
+	}
+	return names
+}
+
+// zshCompletion returns the zsh completion script
+func zshCompletion(classic []completionFlag, commandFlags map[string][]completionFlag) string {
+	var b strings.Builder
+	b.WriteString("#compdef ipcalc\n")
+	b.WriteString("# zsh completion for ipcalc, generated by \"ipcalc completion zsh\"\n\n")
+	b.WriteString("_ipcalc() {\n")
+	b.WriteString("    local -a commands\n")
+	b.WriteString("    commands=(\n")
+	for _, c := range commands {
+		fmt.Fprintf(&b, "        %s\n", zshQuote(c.name+":"+c.summary))
+	}
+	b.WriteString("    )\n\n")
+
+	writeArguments := func(flags []completionFlag, values []string) {
+		b.WriteString("        _arguments -s")
+		for _, f := range flags {
+			b.WriteString(" \\\n            " + zshFlagSpec(f))
+		}
+		if len(values) > 0 {
+			b.WriteString(" \\\n            " + zshQuote("1:argument:("+strings.Join(values, " ")+")"))
+		}
+		b.WriteString(" \\\n            '*:argument:_default' ;;\n")
+	}
+
+	b.WriteString("    if (( CURRENT == 2 )); then\n")
+	b.WriteString("        _describe -t commands 'ipcalc command' commands\n")
+	b.WriteString("    fi\n")
+	b.WriteString("    case $words[2] in\n")
+	for _, c := range commands {
+		fmt.Fprintf(&b, "    %s)\n", c.name)
+		b.WriteString("        shift words\n")
+		b.WriteString("        (( CURRENT-- ))\n")
+		writeArguments(commandFlags[c.name], c.values)
+	}
+	b.WriteString("    *)\n")
+	writeArguments(classic, nil)
+	b.WriteString("    esac\n")
+	b.WriteString("}\n\n")
+	b.WriteString("if [[ \"$funcstack[1]\" == \"_ipcalc\" ]]; then\n")
+	b.WriteString("    _ipcalc \"$@\"\n")
+	b.WriteString("else\n")
+	b.WriteString("    compdef _ipcalc ipcalc\n")
+	b.WriteString("fi\n")
+	return b.String()
+}
+
+// zshFlagSpec returns the _arguments specification of a flag
+func zshFlagSpec(f completionFlag) string {
+	description := strings.NewReplacer("[", "\\[", "]", "\\]", ":", "\\:").Replace(f.usage)
+	argument := ""
+	if f.takesArg {
+		argument = ":" + f.name + ":_default"
+		if len(f.values) > 0 {
+			argument = ":" + f.name + ":(" + strings.Join(f.values, " ") + ")"
+		}
+	}
+
+	if f.shorthand == "" {
+		return zshQuote("--" + f.name + "[" + description + "]" + argument)
+	}
+	exclusion := zshQuote("(-" + f.shorthand + " --" + f.name + ")")
+	return exclusion + "{-" + f.shorthand + ",--" + f.name + "}" + zshQuote("["+description+"]"+argument)
+}
+
+// zshQuote quotes a word for zsh
+func zshQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// fishCompletion returns the fish completion script
+func fishCompletion(classic []completionFlag, commandFlags map[string][]completionFlag) string {
+	var b strings.Builder
+	b.WriteString("# fish completion for ipcalc, generated by \"ipcalc completion fish\"\n\n")
+
+	names := strings.Join(commandNames(), " ")
+	for _, c := range commands {
+		fmt.Fprintf(&b, "complete -c ipcalc -n 'not __fish_seen_subcommand_from %s' -f -a %s -d %s\n",
+			names, c.name, fishQuote(c.summary))
+	}
+
+	writeFlags := func(condition string, flags []completionFlag) {
+		for _, f := range flags {
+			fmt.Fprintf(&b, "complete -c ipcalc -n %s -l %s", fishQuote(condition), f.name)
+			if f.shorthand != "" {
+				fmt.Fprintf(&b, " -s %s", f.shorthand)
+			}
+			if f.takesArg {
+				b.WriteString(" -r")
+			}
+			if len(f.values) > 0 {
+				fmt.Fprintf(&b, " -f -a %s", fishQuote(strings.Join(f.values, " ")))
+			}
+			fmt.Fprintf(&b, " -d %s\n", fishQuote(f.usage))
+		}
+	}
+
+	b.WriteString("\n")
+	writeFlags("not __fish_seen_subcommand_from "+names, classic)
+	for _, c := range commands {
+		b.WriteString("\n")
+		condition := "__fish_seen_subcommand_from " + c.name
+		writeFlags(condition, commandFlags[c.name])
+		if len(c.values) > 0 {
+			fmt.Fprintf(&b, "complete -c ipcalc -n %s -f -a %s\n", fishQuote(condition), fishQuote(strings.Join(c.values, " ")))
+		}
+	}
+	return b.String()
+}
+
+// fishQuote quotes a word for fish
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(s) + "'"
+}
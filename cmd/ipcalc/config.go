@@ -0,0 +1,260 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/neontowel/ipcalc-go/pkg/calculator"
+	"github.com/neontowel/ipcalc-go/pkg/formatter"
+)
+
+// config holds the defaults that the config file and IPCALC_* environment
+// variables set in place of the built-in ones. Flags override both.
+type config struct {
+	IPv4Prefix string // Prefix for IPv4 addresses given without one
+	IPv6Prefix string // Prefix for IPv6 addresses given without one
+	Output     string // Output format: text or html
+	Binary     bool   // Whether to show the bitwise output
+	Theme      string // Color theme name
+	NoColor    bool   // Whether to suppress colors, set by NO_COLOR
+	Cloud      string // Cloud provider preset, if any
+	ListLimit  int    // Maximum number of networks or addresses in a list
+
+	// Themes are the user-defined color themes by name
+	Themes map[string]formatter.ColorCodes
+}
+
+// configKeys are the keys of the config file. Each may also be set with
+// an environment variable named IPCALC_ and the key in upper case with
+// underscores, such as IPCALC_IPV4_PREFIX.
+var configKeys = []string{"ipv4-prefix", "ipv6-prefix", "output", "binary", "theme", "cloud", "list-limit"}
+
+// defaultConfig returns the built-in defaults
+func defaultConfig() config {
+	return config{
+		IPv4Prefix: "24",
+		IPv6Prefix: "64",
+		Output:     "text",
+		Binary:     true,
+		Theme:      "default",
+		ListLimit:  256,
+		Themes:     map[string]formatter.ColorCodes{},
+	}
+}
+
+// configPath returns the path of the config file: $IPCALC_CONFIG if set,
+// otherwise ipcalc/config in the XDG config directory
+func configPath() string {
+	if path := os.Getenv("IPCALC_CONFIG"); path != "" {
+		return path
+	}
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "ipcalc", "config")
+}
+
+// loadConfig returns the defaults after applying the config file, if there
+// is one, and then the environment
+func loadConfig() (config, error) {
+	cfg := defaultConfig()
+
+	if path := configPath(); path != "" {
+		if err := cfg.loadFile(path); err != nil {
+			return config{}, err
+		}
+	}
+
+	for _, key := range configKeys {
+		name := "IPCALC_" + strings.ToUpper(strings.ReplaceAll(key, "-", "_"))
+		if value, ok := os.LookupEnv(name); ok {
+			if err := cfg.set(key, value); err != nil {
+				return config{}, fmt.Errorf("%s: %w", name, err)
+			}
+		}
+	}
+
+	// Themes may be defined after the line choosing them
+	if _, err := cfg.colors(cfg.Theme); err != nil {
+		return config{}, err
+	}
+
+	// https://no-color.org: any non-empty value disables colors
+	if os.Getenv("NO_COLOR") != "" {
+		cfg.NoColor = true
+	}
+	return cfg, nil
+}
+
+// loadFile applies a config file with one "key = value" setting per line.
+// A "[theme NAME]" line starts the definition of a color theme, whose lines
+// map roles to colors until the next theme. Blank lines and lines starting
+// with '#' are ignored. A missing file is not an error.
+func (cfg *config) loadFile(path string) error {
+	file, err := os.Open(path) // #nosec G304 -- the path is the user's own config file
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	lineNumber := 0
+	theme := ""
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if section, found := strings.CutPrefix(line, "["); found {
+			name, found := strings.CutPrefix(strings.TrimSuffix(section, "]"), "theme ")
+			name = strings.TrimSpace(name)
+			if !found || !strings.HasSuffix(section, "]") || name == "" {
+				return fmt.Errorf("%s:%d: expected [theme NAME]", path, lineNumber)
+			}
+			theme = strings.ToLower(name)
+			cfg.Themes[theme] = formatter.DefaultColors()
+			continue
+		}
+
+		key, value, found := strings.Cut(line, "=")
+		if !found {
+			return fmt.Errorf("%s:%d: expected key = value", path, lineNumber)
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if theme != "" {
+			err = cfg.setThemeRole(theme, key, value)
+		} else {
+			err = cfg.set(key, value)
+		}
+		if err != nil {
+			return fmt.Errorf("%s:%d: %w", path, lineNumber, err)
+		}
+	}
+	return scanner.Err()
+}
+
+// setThemeRole applies one line of a theme definition: a role and its
+// color, or the built-in theme to start from as "base = NAME"
+func (cfg *config) setThemeRole(theme, role, value string) error {
+	colors := cfg.Themes[theme]
+	if role == "base" {
+		base, err := formatter.LookupTheme(value)
+		if err != nil {
+			return err
+		}
+		colors = base
+	} else if err := colors.SetRole(role, value); err != nil {
+		return err
+	}
+	cfg.Themes[theme] = colors
+	return nil
+}
+
+// colors returns the colors of a user-defined or built-in theme
+func (cfg *config) colors(theme string) (formatter.ColorCodes, error) {
+	if colors, ok := cfg.Themes[strings.ToLower(theme)]; ok {
+		return colors, nil
+	}
+	colors, err := formatter.LookupTheme(theme)
+	if err != nil {
+		return formatter.ColorCodes{}, fmt.Errorf("unknown color theme: %s (must be one of %s)", theme, strings.Join(cfg.themeNames(), ", "))
+	}
+	return colors, nil
+}
+
+// themeNames returns the names of the built-in themes followed by those of
+// the user-defined ones
+func (cfg *config) themeNames() []string {
+	names := formatter.ThemeNames()
+	var custom []string
+	for name := range cfg.Themes {
+		if _, err := formatter.LookupTheme(name); err != nil {
+			custom = append(custom, name)
+		}
+	}
+	sort.Strings(custom)
+	return append(names, custom...)
+}
+
+// handleThemes previews every color theme, or only the named one
+func handleThemes(cfg config, name string, noColor bool) {
+	names := cfg.themeNames()
+	if name != "" {
+		names = []string{name}
+	}
+	for i, name := range names {
+		colors, err := cfg.colors(name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if noColor {
+			colors = formatter.NoColors()
+		}
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Print(formatter.FormatThemePreview(name, colors))
+	}
+}
+
+// set validates and applies one setting
+func (cfg *config) set(key, value string) error {
+	switch key {
+	case "ipv4-prefix":
+		if _, _, err := calculator.ParseNetmask(value); err != nil {
+			return err
+		}
+		cfg.IPv4Prefix = strings.TrimPrefix(value, "/")
+	case "ipv6-prefix":
+		if _, err := calculator.ParseIPv6Prefix(value); err != nil {
+			return err
+		}
+		cfg.IPv6Prefix = strings.TrimPrefix(value, "/")
+	case "output":
+		if value != "text" && value != "html" {
+			return fmt.Errorf("unknown output format: %s (must be text or html)", value)
+		}
+		cfg.Output = value
+	case "binary":
+		binary, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid binary setting: %s (must be true or false)", value)
+		}
+		cfg.Binary = binary
+	case "theme":
+		cfg.Theme = value
+	case "cloud":
+		if value != "" {
+			if _, err := calculator.GetCloudProvider(value); err != nil {
+				return err
+			}
+		}
+		cfg.Cloud = value
+	case "list-limit":
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 {
+			return fmt.Errorf("invalid list limit: %s (must be a positive number)", value)
+		}
+		cfg.ListLimit = limit
+	default:
+		return fmt.Errorf("unknown setting: %s (must be one of %s)", key, strings.Join(configKeys, ", "))
+	}
+	return nil
+}
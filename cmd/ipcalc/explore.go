@@ -0,0 +1,570 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"math/big"
+	"net/netip"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/neontowel/ipcalc-go/internal/term"
+	"github.com/neontowel/ipcalc-go/pkg/calculator"
+	"github.com/neontowel/ipcalc-go/pkg/formatter"
+)
+
+// exploreNode is a network in the explorer's subnet tree
+type exploreNode struct {
+	prefix   netip.Prefix
+	mark     string
+	expanded bool
+	children []*exploreNode
+	parent   *exploreNode
+}
+
+// exploreInput is a prompt shown on the status line
+type exploreInput struct {
+	label string
+	text  []rune
+	done  func(text string)
+}
+
+// explorer is the state of the full-screen subnet explorer
+type explorer struct {
+	root    *exploreNode
+	address netip.Addr // The address entered for the root network
+	cursor  int
+	top     int
+	help    bool
+	status  string
+	input   *exploreInput
+	quit    bool
+	colors  formatter.ColorCodes
+	out     *bufio.Writer
+}
+
+// exploreAllocation is one line of an exported plan
+type exploreAllocation struct {
+	CIDR  string `json:"cidr"`
+	Label string `json:"label,omitempty"`
+	Free  bool   `json:"free"`
+}
+
+// handleExplore runs the full-screen subnet explorer
+func handleExplore(args []string, format formatter.OutputFormat) {
+	if !term.IsTerminal(os.Stdin.Fd()) || !isTerminal() {
+		fmt.Fprintln(os.Stderr, "Error: Explorer mode requires a terminal")
+		os.Exit(1)
+	}
+
+	e := &explorer{
+		colors: formatter.NoColors(),
+		out:    bufio.NewWriter(os.Stdout),
+	}
+	if format.UseColor {
+		e.colors = format.TerminalColors()
+	}
+
+	network := "192.168.0.0/24"
+	if len(args) > 0 {
+		ipStr, maskStr, err := parseNetworkArgs(args)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		network = ipStr + "/" + maskStr
+	}
+	if err := e.setNetwork(network); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	old, err := term.MakeRaw(os.Stdin.Fd())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Use the alternate screen so the shell is restored on exit
+	fmt.Fprint(e.out, "\033[?1049h\033[?25l")
+	reader := bufio.NewReader(os.Stdin)
+	for !e.quit {
+		e.render()
+		key, r, err := term.ReadKey(reader)
+		if err != nil {
+			break
+		}
+		e.handleKey(key, r)
+	}
+	fmt.Fprint(e.out, "\033[?25h\033[?1049l")
+	e.out.Flush()
+	_ = term.Restore(os.Stdin.Fd(), old)
+
+	// Leave the marked allocations on the screen
+	if e.hasMarks(e.root) {
+		fmt.Print(e.exportText())
+	}
+}
+
+// setNetwork replaces the tree with a new root network
+func (e *explorer) setNetwork(network string) error {
+	// The explorer works on whole networks, so any zone ID is dropped
+	in, err := parseNetworkInput([]string{network})
+	if err != nil {
+		return err
+	}
+	ipStr, maskStr := in.Address, in.Prefix
+	networkID, prefix, bits, err := calculator.ParseNetworkAny(ipStr, maskStr)
+	if err != nil {
+		return err
+	}
+	address, err := netip.ParseAddr(ipStr)
+	if err != nil {
+		return fmt.Errorf("invalid IP address: %s", ipStr)
+	}
+
+	e.address = address
+	e.root = &exploreNode{prefix: netip.PrefixFrom(calculator.AddrFromBig(networkID, bits), prefix), expanded: true}
+	e.cursor, e.top = 0, 0
+	return nil
+}
+
+// visible returns the nodes shown in the tree, in display order
+func (e *explorer) visible() []*exploreNode {
+	var nodes []*exploreNode
+	var walk func(node *exploreNode)
+	walk = func(node *exploreNode) {
+		nodes = append(nodes, node)
+		if node.expanded {
+			for _, child := range node.children {
+				walk(child)
+			}
+		}
+	}
+	walk(e.root)
+	return nodes
+}
+
+// selected returns the node under the cursor
+func (e *explorer) selected() *exploreNode {
+	nodes := e.visible()
+	e.cursor = max(min(e.cursor, len(nodes)-1), 0)
+	return nodes[e.cursor]
+}
+
+// handleKey applies one key press
+func (e *explorer) handleKey(key term.Key, r rune) {
+	if e.input != nil {
+		e.handleInputKey(key, r)
+		return
+	}
+	e.status = ""
+
+	node := e.selected()
+	switch {
+	case key == term.KeyUp || key == term.KeyRune && r == 'k':
+		e.cursor = max(e.cursor-1, 0)
+	case key == term.KeyDown || key == term.KeyRune && r == 'j':
+		e.cursor++
+	case key == term.KeyPageUp:
+		e.cursor = max(e.cursor-e.treeHeight(), 0)
+	case key == term.KeyPageDown:
+		e.cursor += e.treeHeight()
+	case key == term.KeyHome:
+		e.cursor = 0
+	case key == term.KeyEnd:
+		e.cursor = len(e.visible()) - 1
+	case key == term.KeyLeft || key == term.KeyRune && r == 'h':
+		e.resize(node, -1)
+	case key == term.KeyRight || key == term.KeyRune && r == 'l':
+		e.resize(node, 1)
+	case key == term.KeyEnter || key == term.KeyRune && r == ' ':
+		if len(node.children) > 0 {
+			node.expanded = !node.expanded
+		} else {
+			e.split(node, strconv.Itoa(node.prefix.Bits()+1))
+		}
+	case key == term.KeyRune && r == 's':
+		e.prompt("Split into (count or /prefix): ", func(text string) {
+			if count, ok := strings.CutPrefix(text, "/"); ok {
+				e.split(node, count)
+				return
+			}
+			e.splitCount(node, text)
+		})
+	case key == term.KeyRune && r == 'x':
+		node.children = nil
+	case key == term.KeyRune && r == 'm':
+		e.prompt("Mark as (empty to unmark): ", func(text string) {
+			e.markNode(node, text)
+		})
+	case key == term.KeyRune && r == 'n':
+		e.prompt("Network: ", func(text string) {
+			if err := e.setNetwork(text); err != nil {
+				e.status = err.Error()
+			}
+		})
+	case key == term.KeyRune && r == 'e':
+		e.prompt("Export to (.txt or .json): ", e.export)
+	case key == term.KeyRune && r == '?':
+		e.help = !e.help
+	case key == term.KeyRune && r == 'q', key == term.KeyEscape, key == term.Ctrl('c'):
+		e.quit = true
+	}
+}
+
+// handleInputKey edits the status line prompt
+func (e *explorer) handleInputKey(key term.Key, r rune) {
+	input := e.input
+	switch key {
+	case term.KeyEnter:
+		e.input = nil
+		input.done(strings.TrimSpace(string(input.text)))
+	case term.KeyEscape, term.Ctrl('c'):
+		e.input = nil
+	case term.KeyBackspace:
+		if len(input.text) > 0 {
+			input.text = input.text[:len(input.text)-1]
+		}
+	case term.KeyRune:
+		if unicode.IsPrint(r) {
+			input.text = append(input.text, r)
+		}
+	}
+}
+
+// prompt asks for a line of text on the status line
+func (e *explorer) prompt(label string, done func(text string)) {
+	e.input = &exploreInput{label: label, done: done}
+}
+
+// resize widens or narrows the root network; subnets must be removed first
+func (e *explorer) resize(node *exploreNode, delta int) {
+	if node != e.root {
+		e.status = "Only the top network can be widened or narrowed"
+		return
+	}
+	if len(node.children) > 0 {
+		e.status = "Remove the subnets (x) before changing the prefix"
+		return
+	}
+
+	bits := node.prefix.Bits() + delta
+	if bits < 0 || bits > node.prefix.Addr().BitLen() {
+		return
+	}
+	prefix, _ := e.address.Prefix(bits)
+	node.prefix = prefix
+}
+
+// split divides a node into equal subnets of the given prefix length
+func (e *explorer) split(node *exploreNode, prefixStr string) {
+	prefix, err := strconv.Atoi(prefixStr)
+	if err != nil {
+		e.status = "Invalid prefix length: " + prefixStr
+		return
+	}
+	subnets, err := calculator.SplitNetworkByPrefix(node.prefix.Addr().String(), strconv.Itoa(node.prefix.Bits()), prefix)
+	if err != nil {
+		e.status = err.Error()
+		return
+	}
+	e.setChildren(node, subnets)
+}
+
+// splitCount divides a node into at least the given number of equal subnets
+func (e *explorer) splitCount(node *exploreNode, countStr string) {
+	count, err := strconv.Atoi(countStr)
+	if err != nil {
+		e.status = "Invalid subnet count: " + countStr
+		return
+	}
+	subnets, err := calculator.SplitNetworkByCount(node.prefix.Addr().String(), strconv.Itoa(node.prefix.Bits()), count)
+	if err != nil {
+		e.status = err.Error()
+		return
+	}
+	e.setChildren(node, subnets)
+}
+
+// setChildren replaces the subnets of a node
+func (e *explorer) setChildren(node *exploreNode, subnets []calculator.Subnet) {
+	if len(subnets) > listLimit {
+		e.status = fmt.Sprintf("Cannot show %d subnets (limit is %d)", len(subnets), listLimit)
+		return
+	}
+	if node.mark != "" {
+		e.status = "Unmark the network before splitting it"
+		return
+	}
+
+	node.children = nil
+	for _, subnet := range subnets {
+		prefix, err := netip.ParsePrefix(subnet.Network)
+		if err != nil {
+			e.status = err.Error()
+			return
+		}
+		node.children = append(node.children, &exploreNode{prefix: prefix, parent: node})
+	}
+	node.expanded = true
+}
+
+// markNode marks a network as allocated, or unmarks it for an empty label.
+// Marked networks may not overlap, so a network whose subnets or parents
+// are marked cannot be marked itself.
+func (e *explorer) markNode(node *exploreNode, label string) {
+	if label != "" {
+		for parent := node.parent; parent != nil; parent = parent.parent {
+			if parent.mark != "" {
+				e.status = fmt.Sprintf("%s is already marked as %s", parent.prefix, parent.mark)
+				return
+			}
+		}
+		for _, child := range node.children {
+			if e.hasMarks(child) {
+				e.status = "A subnet of this network is already marked"
+				return
+			}
+		}
+	}
+	node.mark = label
+}
+
+// hasMarks reports whether a node or any of its subnets is marked
+func (e *explorer) hasMarks(node *exploreNode) bool {
+	if node.mark != "" {
+		return true
+	}
+	for _, child := range node.children {
+		if e.hasMarks(child) {
+			return true
+		}
+	}
+	return false
+}
+
+// allocations lists the marked networks and the free leaves in address order
+func (e *explorer) allocations() []exploreAllocation {
+	var result []exploreAllocation
+	var walk func(node *exploreNode)
+	walk = func(node *exploreNode) {
+		switch {
+		case node.mark != "":
+			result = append(result, exploreAllocation{CIDR: node.prefix.String(), Label: node.mark})
+		case len(node.children) > 0:
+			for _, child := range node.children {
+				walk(child)
+			}
+		default:
+			result = append(result, exploreAllocation{CIDR: node.prefix.String(), Free: true})
+		}
+	}
+	walk(e.root)
+	return result
+}
+
+// exportText formats the allocations as an aligned plain text list
+func (e *explorer) exportText() string {
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("# Allocations in %s\n", e.root.prefix))
+	for _, allocation := range e.allocations() {
+		label := allocation.Label
+		if allocation.Free {
+			label = "(free)"
+		}
+		result.WriteString(fmt.Sprintf("%-24s%s\n", allocation.CIDR, label))
+	}
+	return result.String()
+}
+
+// export writes the allocations to a file, as JSON for a .json name
+func (e *explorer) export(path string) {
+	if path == "" {
+		return
+	}
+
+	content := e.exportText()
+	if strings.HasSuffix(path, ".json") {
+		var err error
+		content, err = formatter.FormatJSON(e.allocations())
+		if err != nil {
+			e.status = err.Error()
+			return
+		}
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		e.status = err.Error()
+		return
+	}
+	e.status = "Exported to " + path
+}
+
+// screenSize returns the terminal size, with a fallback for odd terminals
+func (e *explorer) screenSize() (int, int) {
+	width, height, err := term.Size(os.Stdout.Fd())
+	if err != nil || width < 20 || height < 10 {
+		return 80, 24
+	}
+	return width, height
+}
+
+// treeHeight returns the number of tree lines that fit on the screen
+func (e *explorer) treeHeight() int {
+	_, height := e.screenSize()
+	return max(height-len(e.detailLines())-4, 1)
+}
+
+// render draws the whole screen
+func (e *explorer) render() {
+	width, height := e.screenSize()
+	var lines []string
+
+	title := fmt.Sprintf(" ipcalc-go explorer: %s ", e.root.prefix)
+	lines = append(lines, "\033[7m"+padRight(title, width)+"\033[0m")
+	lines = append(lines, e.detailLines()...)
+	lines = append(lines, strings.Repeat("-", min(width, 80)))
+
+	// Tree lines, scrolled to keep the cursor visible
+	nodes := e.visible()
+	e.selected()
+	treeHeight := e.treeHeight()
+	if e.cursor < e.top {
+		e.top = e.cursor
+	}
+	if e.cursor >= e.top+treeHeight {
+		e.top = e.cursor - treeHeight + 1
+	}
+	for i := e.top; i < min(len(nodes), e.top+treeHeight); i++ {
+		lines = append(lines, e.treeLine(nodes[i], i == e.cursor, width))
+	}
+	for len(lines) < height-1 {
+		lines = append(lines, "")
+	}
+
+	// Status line: a prompt, a message or the key help
+	switch {
+	case e.input != nil:
+		lines = append(lines, e.input.label+string(e.input.text)+"\033[?25h")
+	case e.status != "":
+		lines = append(lines, e.colors.Error+truncate(e.status, width)+e.colors.Reset+"\033[?25l")
+	default:
+		lines = append(lines, truncate("arrows: move/resize  enter: split/fold  s: split  m: mark  x: remove  n: new  e: export  ?: help  q: quit", width)+"\033[?25l")
+	}
+
+	fmt.Fprint(e.out, "\033[H")
+	fmt.Fprint(e.out, strings.Join(lines[:min(len(lines), height)], "\033[K\r\n"))
+	fmt.Fprint(e.out, "\033[K")
+	e.out.Flush()
+}
+
+// detailLines describes the selected network, showing the network and host
+// bits of the address in different colors
+func (e *explorer) detailLines() []string {
+	if e.help {
+		return []string{
+			"Up/Down      Select a network (also k/j, PgUp/PgDn, Home/End)",
+			"Left/Right   Widen or narrow the top network (also h/l)",
+			"Enter/Space  Split a network in two, or fold and unfold its subnets",
+			"s            Split into a number of subnets or subnets of a /prefix",
+			"x            Remove the subnets of a network",
+			"m            Mark a network as allocated, with a label",
+			"n            Explore another network",
+			"e            Export the marked and free networks to a file",
+			"?            Show or hide this help",
+			"q/Esc        Quit, printing the marked networks",
+		}
+	}
+
+	node := e.selected()
+	c := e.colors
+	prefix := node.prefix
+	bits := prefix.Addr().BitLen()
+
+	address := prefix.Addr()
+	if node == e.root {
+		address = e.address
+	}
+	var lines []string
+	lines = append(lines, fmt.Sprintf("Address:   %s%s%s", c.Address, address, c.Reset))
+
+	if bits == 32 {
+		network, _ := calculator.CalculateNetwork(address.String(), strconv.Itoa(prefix.Bits()))
+		lines = append(lines,
+			fmt.Sprintf("Netmask:   %s%s = %d%s", c.Netmask, calculator.IPToString(network.Netmask), network.BitCount, c.Reset),
+			fmt.Sprintf("Binary:    %s", formatter.FormatBits(calculator.FormatBinary(network.Address), prefix.Bits(), 0, c, false)),
+			fmt.Sprintf("Mask:      %s", formatter.FormatBits(calculator.FormatBinary(network.Netmask), prefix.Bits(), 0, c, false)),
+			fmt.Sprintf("Network:   %s%s%s", c.Subnet, prefix, c.Reset),
+			fmt.Sprintf("HostMin:   %s%s%s", c.Address, calculator.IPToString(network.HostMin), c.Reset),
+			fmt.Sprintf("HostMax:   %s%s%s", c.Address, calculator.IPToString(network.HostMax), c.Reset),
+			fmt.Sprintf("Broadcast: %s%s%s", c.Address, calculator.IPToString(network.Broadcast), c.Reset),
+			fmt.Sprintf("Hosts/Net: %s%d%s", c.Subnet, network.HostsCount, c.Reset))
+	} else {
+		network, _ := calculator.CalculateIPv6Network(address.String(), strconv.Itoa(prefix.Bits()))
+		binary := strings.Split(calculator.FormatIPv6Binary(network.Address), ":")
+		hostMin, hostMax := calculator.IPv6HostRange(network.NetworkID, network.PrefixLen)
+		lines = append(lines,
+			fmt.Sprintf("Netmask:   %s%d%s", c.Netmask, prefix.Bits(), c.Reset),
+			fmt.Sprintf("Binary:    %s", formatter.FormatBits(strings.Join(binary[:4], ":"), prefix.Bits(), 0, c, false)),
+			fmt.Sprintf("           %s", formatter.FormatBits(strings.Join(binary[4:], ":"), prefix.Bits()-64, 0, c, false)),
+			fmt.Sprintf("Network:   %s%s%s", c.Subnet, prefix, c.Reset),
+			fmt.Sprintf("HostMin:   %s%s%s", c.Address, calculator.IPv6ToString(hostMin), c.Reset),
+			fmt.Sprintf("HostMax:   %s%s%s", c.Address, calculator.IPv6ToString(hostMax), c.Reset),
+			fmt.Sprintf("Addresses: %s%s%s", c.Subnet, new(big.Int).Lsh(big.NewInt(1), uint(128-prefix.Bits())), c.Reset))
+	}
+
+	lines = append(lines, fmt.Sprintf("Bits:      %s%d network%s, %s%d host%s",
+		c.NetworkBits, prefix.Bits(), c.Reset, c.HostBits, bits-prefix.Bits(), c.Reset))
+	if node.mark != "" {
+		lines = append(lines, fmt.Sprintf("Mark:      %s%s%s", c.Class, node.mark, c.Reset))
+	} else {
+		lines = append(lines, "")
+	}
+	return lines
+}
+
+// treeLine formats one network of the tree
+func (e *explorer) treeLine(node *exploreNode, selected bool, width int) string {
+	depth := 0
+	for parent := node.parent; parent != nil; parent = parent.parent {
+		depth++
+	}
+
+	fold := "  "
+	if len(node.children) > 0 {
+		fold = "+ "
+		if node.expanded {
+			fold = "- "
+		}
+	}
+	text := strings.Repeat("  ", depth) + fold + node.prefix.String()
+	if node.mark != "" {
+		text = fmt.Sprintf("%-40s [%s]", text, node.mark)
+	}
+	text = padRight(text, min(width, 80))
+
+	if selected {
+		return "\033[7m" + text + "\033[0m"
+	}
+	if node.mark != "" {
+		return e.colors.Class + text + e.colors.Reset
+	}
+	return text
+}
+
+// padRight pads or truncates text to the given width
+func padRight(text string, width int) string {
+	text = truncate(text, width)
+	return text + strings.Repeat(" ", width-len([]rune(text)))
+}
+
+// truncate shortens text to at most width characters
+func truncate(text string, width int) string {
+	runes := []rune(text)
+	if len(runes) > width {
+		return string(runes[:width])
+	}
+	return text
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/neontowel/ipcalc-go/pkg/calculator"
+	"github.com/neontowel/ipcalc-go/pkg/formatter"
+)
+
+// handleLocal shows the networks of the addresses configured on this
+// machine, or only on the named interface
+func handleLocal(name string, format formatter.OutputFormat) {
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	found := false
+	first := true
+	for _, iface := range interfaces {
+		if name != "" && iface.Name != name {
+			continue
+		}
+		found = true
+
+		addrs, err := iface.Addrs()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s: %v\n", iface.Name, err)
+			os.Exit(1)
+		}
+		for _, addr := range addrs {
+			ipNet, ok := addr.(*net.IPNet)
+			if !ok {
+				continue
+			}
+			if !first {
+				if format.UseHTML {
+					fmt.Println("<br>")
+				} else {
+					fmt.Println()
+				}
+			}
+			first = false
+			printLocalAddress(iface, ipNet, format)
+		}
+	}
+
+	if name != "" && !found {
+		fmt.Fprintf(os.Stderr, "Error: No such interface: %s\n", name)
+		os.Exit(1)
+	}
+}
+
+// printLocalAddress prints the interface details and the network report
+// of one configured address
+func printLocalAddress(iface net.Interface, ipNet *net.IPNet, format formatter.OutputFormat) {
+	ip, ok := netip.AddrFromSlice(ipNet.IP)
+	if !ok {
+		return
+	}
+	ip = ip.Unmap()
+	ones, _ := ipNet.Mask.Size()
+
+	fmt.Println(formatter.FormatValue("Interface", fmt.Sprintf("%s (%s)", iface.Name, interfaceFlags(iface.Flags)), format))
+	if len(iface.HardwareAddr) > 0 {
+		fmt.Println(formatter.FormatValue("MAC", iface.HardwareAddr.String(), format))
+	}
+
+	if ip.Is4() {
+		network, err := calculator.CalculateNetwork(ip.String(), strconv.Itoa(ones))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(formatter.FormatIPv4Network(network, format))
+		return
+	}
+
+	fmt.Println(formatter.FormatValue("Host ID", calculator.InterfaceIDHint(ip, iface.HardwareAddr), format))
+
+	// Link-local addresses are only meaningful with their zone
+	ipStr := ip.String()
+	if ip.IsLinkLocalUnicast() {
+		ipStr += "%" + iface.Name
+	}
+	network, err := calculator.CalculateIPv6Network(ipStr, strconv.Itoa(ones))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(formatter.FormatIPv6Network(network, format))
+}
+
+// interfaceFlags returns the flags of an interface as a readable list
+func interfaceFlags(flags net.Flags) string {
+	if flags == 0 {
+		return "down"
+	}
+	return strings.ReplaceAll(flags.String(), "|", ", ")
+}
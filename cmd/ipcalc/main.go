@@ -1,28 +1,99 @@
 package main
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
+	"math/big"
+	"net/netip"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
 
+	"github.com/neontowel/ipcalc-go/internal/version"
 	"github.com/neontowel/ipcalc-go/pkg/calculator"
 	"github.com/neontowel/ipcalc-go/pkg/formatter"
+	"github.com/neontowel/ipcalc-go/pkg/planner"
+	"github.com/neontowel/ipcalc-go/pkg/routing"
 	"github.com/spf13/pflag"
 )
 
-const version = "0.1.0"
+// listLimit is the maximum number of networks or addresses printed in a
+// list, set by the list-limit setting
+var listLimit = 256
+
+// defaultIPv4Prefix and defaultIPv6Prefix are used for addresses given
+// without a prefix, set by the ipv4-prefix and ipv6-prefix settings
+var (
+	defaultIPv4Prefix = "24"
+	defaultIPv6Prefix = "64"
+)
 
 func main() {
+	// The config file and environment set the defaults of the flags. An
+	// error in them is reported once --help and --version have had a
+	// chance to run, so both work while the config is being fixed.
+	cfg, configErr := loadConfig()
+	if configErr != nil {
+		cfg = defaultConfig()
+	}
+	listLimit = cfg.ListLimit
+	defaultIPv4Prefix = cfg.IPv4Prefix
+	defaultIPv6Prefix = cfg.IPv6Prefix
+
 	// Define command-line flags
 	help := pflag.BoolP("help", "h", false, "Display help usage")
-	noColor := pflag.BoolP("nocolor", "n", false, "Don't display ANSI color codes")
-	noBinary := pflag.BoolP("nobinary", "b", false, "Suppress the bitwise output")
+	out := addOutputFlags(pflag.CommandLine, cfg)
+	showThemes := pflag.Bool("themes", false, "Preview the color themes, or only the one given by --theme")
+	numericFormat := pflag.StringSlice("format", nil, "Also show addresses as numbers (dec, hex, oct, all)")
+	setCompletionValues(pflag.CommandLine, "format", []string{"dec", "hex", "oct", "all"})
+	strict := pflag.Bool("strict", false, "Reject ambiguous legacy address forms such as 10.1 or 010.0.0.1")
 	classOnly := pflag.BoolP("class", "c", false, "Just print bit-count-mask of given address")
-	html := pflag.BoolP("html", "H", false, "Display results as HTML")
 	showVersion := pflag.BoolP("version", "v", false, "Print Version")
+	interactive := pflag.BoolP("interactive", "i", false, "Start an interactive session")
+	explore := pflag.BoolP("explore", "x", false, "Explore a network and its subnets in a full-screen view")
 	split := pflag.BoolP("split", "s", false, "Split into networks of specified sizes")
-	deaggregate := pflag.BoolP("range", "r", false, "Deaggregate address range")
+	equal := pflag.BoolP("equal", "e", false, "Split into the given number of equal subnets")
+	deaggregate := pflag.BoolP("range", "r", false, "Deaggregate address ranges and nmap-style targets")
+	next := pflag.Bool("next", false, "Show the next network of the same size")
+	prev := pflag.Bool("prev", false, "Show the previous network of the same size")
+	host := pflag.String("host", "", "Show the Nth host of the network (negative counts from the end)")
+	add := pflag.String("add", "", "Add an offset to the address (negative subtracts)")
+	distance := pflag.Bool("distance", false, "Count the addresses from the first to the second address")
+	wildcard := pflag.BoolP("wildcard", "w", false, "Evaluate an address with a (discontiguous) wildcard mask")
+	list := pflag.Bool("list", false, "List matching addresses instead of networks")
+	count := pflag.Bool("count", false, "Count the addresses of a range instead of listing networks")
+	fw := addFirewallFlags(pflag.CommandLine)
+	routes := pflag.String("routes", "", "Look up addresses in a routing table file")
+	local := pflag.Bool("local", false, "Show the networks of the addresses configured on this machine")
+	iface := pflag.String("iface", "", "Show the networks of one interface only (implies --local)")
+	paste := pflag.Bool("paste", false, "Calculate the addresses found in ifconfig, ip addr or router config text on stdin")
+	cloud := addCloudFlag(pflag.CommandLine, cfg)
+	vpc := pflag.Bool("vpc", false, "Plan a VPC layout across availability zones")
+	zones := pflag.Int("zones", 3, "Number of availability zones for --vpc")
+	spareZones := pflag.Int("spare-zones", 1, "Zone blocks to keep free for growth for --vpc")
+	tiers := pflag.StringSlice("tier", []string{"public:1", "private:2", "data:1"}, "Subnet tiers for --vpc as name:weight or name:<hosts>h")
+	k8s := pflag.Bool("k8s", false, "Plan the pod and service networks of a Kubernetes cluster")
+	nodes := pflag.Int("nodes", 100, "Maximum node count for --k8s")
+	podsPerNode := pflag.Int("pods-per-node", 110, "Maximum pods per node for --k8s")
+	services := pflag.Int("services", 1000, "Service count for --k8s")
+	podCIDR := pflag.StringSlice("pod-cidr", nil, "Pod CIDRs for --k8s, one per address family (planned if not given)")
+	serviceCIDR := pflag.StringSlice("service-cidr", nil, "Service CIDRs for --k8s, one per address family (planned if not given)")
+	supernet := pflag.StringSlice("supernet", nil, "Networks to plan pod and service CIDRs from for --k8s (default 10.0.0.0/8)")
+	nodeMask := pflag.Int("node-mask", 0, "IPv4 per-node pod mask size for --k8s (sized from pods per node if not given)")
+	nodeMask6 := pflag.Int("node-mask6", 0, "IPv6 per-node pod mask size for --k8s (default 64)")
+	output := pflag.StringP("output", "o", "text", "Output format for plans (text, json, terraform)")
+	setCompletionValues(pflag.CommandLine, "output", []string{"text", "json", "terraform"})
+
+	// Commands take their own flags; any given before the command, as in
+	// "ipcalc -nb range", are passed on to it
+	if i := firstArgument(os.Args[1:], pflag.CommandLine); i >= 0 {
+		if c := findCommand(os.Args[1+i]); c != nil {
+			runCommand(c, append(slices.Clone(os.Args[1:1+i]), os.Args[2+i:]...), cfg, configErr)
+			os.Exit(0)
+		}
+	}
 
 	// Parse flags
 	pflag.Parse()
@@ -30,29 +101,72 @@ func main() {
 	// Get remaining arguments
 	args := pflag.Args()
 
+	// Check for version flag
+	if *showVersion {
+		fmt.Printf("ipcalc-go version %s\n", version.Version)
+		os.Exit(0)
+	}
+
 	// Check for help flag
-	if *help || len(args) == 0 {
+	if *help || (len(args) == 0 && !*interactive && !*explore && !*local && *iface == "" && !*paste && !*showThemes) {
 		printUsage()
 		os.Exit(0)
 	}
 
-	// Check for version flag
-	if *showVersion {
-		fmt.Printf("ipcalc-go version %s\n", version)
+	if configErr != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", configErr)
+		os.Exit(1)
+	}
+
+	// Preview color themes if requested
+	if *showThemes {
+		name := ""
+		if pflag.CommandLine.Changed("theme") {
+			name = *out.theme
+		}
+		handleThemes(cfg, name, *out.noColor)
 		os.Exit(0)
 	}
 
+	// An equal split is a kind of split, so -e alone is an error
+	if *equal && !*split {
+		fmt.Fprintln(os.Stderr, "Error: --equal requires split mode (-s)")
+		os.Exit(1)
+	}
+
 	// Set up output format
-	format := formatter.OutputFormat{
-		UseColor:  !*noColor && !*html && isTerminal(),
-		UseHTML:   *html,
-		UseBinary: !*noBinary,
+	format := out.format(cfg)
+
+	// Set up numeric forms if requested
+	numericForms, err := formatter.ParseNumericForms(*numericFormat)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	strictInput = *strict
+
+	// Set up firewall output if requested
+	firewall := fw.options(&format)
+
+	// Look up the cloud provider preset if requested
+	cloudProvider := lookupCloudProvider(*cloud)
+
+	// Handle interactive mode
+	if *interactive {
+		handleInteractive(format)
+		os.Exit(0)
+	}
+
+	// Handle explorer mode
+	if *explore {
+		handleExplore(args, format)
+		os.Exit(0)
 	}
 
 	// Print HTML header if needed
 	if format.UseHTML {
 		fmt.Print(formatter.FormatHTMLHeader())
-		fmt.Printf("<!-- Version %s -->\n", version)
+		fmt.Printf("<!-- Version %s -->\n", version.Version)
 	}
 
 	// Handle class-only mode
@@ -63,11 +177,11 @@ func main() {
 
 	// Handle deaggregate mode
 	if *deaggregate {
-		if len(args) < 2 {
-			fmt.Fprintln(os.Stderr, "Error: Deaggregate mode requires two IP addresses")
+		if len(args) < 1 {
+			fmt.Fprintln(os.Stderr, "Error: Deaggregate mode requires two IP addresses or a range")
 			os.Exit(1)
 		}
-		handleDeaggregate(args[0], args[1], format)
+		handleDeaggregate(args, *count, *list, format, firewall)
 		os.Exit(0)
 	}
 
@@ -77,13 +191,84 @@ func main() {
 			fmt.Fprintln(os.Stderr, "Error: Split mode requires an IP/netmask and at least one size")
 			os.Exit(1)
 		}
-		handleSplit(args[0], args[1:], format)
+		handleSplit(args[0], args[1:], *equal, format, firewall)
+		os.Exit(0)
+	}
+
+	// Handle VPC planning mode
+	if *vpc {
+		handleVPCPlan(newVPCSpec(args[0], *zones, *spareZones, *tiers, cloudProvider), *output, format)
+		os.Exit(0)
+	}
+
+	// Handle Kubernetes planning mode
+	if *k8s {
+		spec := planner.KubernetesSpec{
+			NodeCIDR:       args[0],
+			PodCIDRs:       *podCIDR,
+			ServiceCIDRs:   *serviceCIDR,
+			Supernets:      *supernet,
+			MaxNodes:       *nodes,
+			MaxPodsPerNode: *podsPerNode,
+			Services:       *services,
+			NodeMaskSize:   *nodeMask,
+			NodeMaskSize6:  *nodeMask6,
+		}
+		handleKubernetesPlan(spec, *output, format)
+		os.Exit(0)
+	}
+
+	// Handle local interface mode
+	if *local || *iface != "" {
+		handleLocal(*iface, format)
+		os.Exit(0)
+	}
+
+	// Handle paste mode
+	if *paste {
+		handlePaste(format)
+		os.Exit(0)
+	}
+
+	// Handle route lookup mode
+	if *routes != "" {
+		handleRouteLookup(*routes, args, format)
+		os.Exit(0)
+	}
+
+	// Handle wildcard mode
+	if *wildcard {
+		if len(args) < 2 {
+			fmt.Fprintln(os.Stderr, "Error: Wildcard mode requires an IP address and a wildcard mask")
+			os.Exit(1)
+		}
+		handleWildcard(args[0], args[1], args[2:], *list, format, firewall)
+		os.Exit(0)
+	}
+
+	// Handle address arithmetic modes
+	if *distance {
+		if len(args) < 2 {
+			fmt.Fprintln(os.Stderr, "Error: Distance mode requires two IP addresses")
+			os.Exit(1)
+		}
+		handleDistance(args[0], args[1], format)
+		os.Exit(0)
+	}
+
+	if *add != "" {
+		handleAdd(args[0], *add, format)
+		os.Exit(0)
+	}
+
+	if *next || *prev || *host != "" {
+		handleNetworkArithmetic(args, *next, *prev, *host, format)
 		os.Exit(0)
 	}
 
 	// Handle normal mode
 	if len(args) > 0 {
-		handleNormal(args, format)
+		handleNormal(args, format, numericForms, firewall, cloudProvider)
 	}
 
 	// Print HTML footer if needed
@@ -104,22 +289,87 @@ func isTerminal() bool {
 // printUsage prints the usage information
 func printUsage() {
 	fmt.Println(`Usage: ipcalc [options] <ADDRESS>[[/]<NETMASK>] [NETMASK]
+       ipcalc COMMAND [options] <ARGS>
 
 ipcalc takes an IP address and netmask and calculates the resulting
 broadcast, network, Cisco wildcard mask, and host range. By giving a
 second netmask, you can design sub- and supernetworks. It is also
 intended to be a teaching tool and presents the results as easy-to-
-understand binary values.
+understand binary values.`)
+
+	fmt.Println("\nCommands:")
+	for _, c := range commands {
+		fmt.Printf("  %-34s %s\n", strings.TrimSpace(c.name+" "+c.args), c.summary)
+	}
+	fmt.Println(`
+Run "ipcalc help COMMAND" for the options of a command. The options below
+select the same operations for compatibility with earlier versions.
 
 Options:
   -h, --help        Display help usage
   -n, --nocolor     Don't display ANSI color codes
   -b, --nobinary    Suppress the bitwise output
+      --boundary    Mark the network/host boundary in the bitwise output
+      --theme NAME  Color theme for terminal output (default, none, bright,
+                    solarized-dark, solarized-light or one from the config)
+      --themes      Preview the color themes, or only the one given by --theme
+      --format LIST Also show addresses as numbers: dec, hex, oct or all
+      --strict      Reject ambiguous legacy addresses such as 10.1 or 010.0.0.1
   -c, --class       Just print bit-count-mask of given address
   -H, --html        Display results as HTML
   -v, --version     Print Version
+  -i, --interactive Start an interactive session
+  -x, --explore     Explore a network and its subnets in a full-screen view
   -s, --split       Split into networks of specified sizes
-  -r, --range       Deaggregate address range
+  -e, --equal       Split into the given number of equal subnets
+  -r, --range       Deaggregate address ranges and nmap-style targets
+      --next        Show the next network of the same size
+      --prev        Show the previous network of the same size
+      --host N      Show the Nth host of the network (negative counts from the end)
+      --add N       Add an offset to the address (negative subtracts)
+      --distance    Count the addresses from the first to the second address
+  -w, --wildcard    Evaluate an address with a (discontiguous) wildcard mask
+      --list        List matching addresses instead of networks
+      --count       Count the addresses of a range instead of listing networks
+  -f, --firewall T  Print networks as firewall rules (cisco, iptables,
+                    ip6tables, nftables, ipset, pf)
+      --fw-name N   ACL, chain, set or table name for firewall rules
+      --fw-deny     Generate deny rules instead of permit rules
+      --routes FILE Look up addresses in a routing table file
+      --local       Show the networks of the addresses on this machine
+      --iface NAME  Show the networks of one interface only
+      --paste       Calculate the addresses found in pasted ifconfig, ip addr
+                    or router config text read from stdin
+      --cloud P     Apply the reserved addresses and subnet sizes of a
+                    cloud provider (aws, azure, gcp)
+      --vpc         Plan a VPC layout across availability zones
+      --zones N     Number of availability zones for --vpc (default 3)
+      --spare-zones N
+                    Zone blocks to keep free for growth (default 1)
+      --tier T      Subnet tiers for --vpc as name:weight or name:<hosts>h
+                    (default public:1,private:2,data:1)
+      --k8s         Plan the pod and service networks of a Kubernetes
+                    cluster whose nodes use the given network
+      --nodes N     Maximum node count for --k8s (default 100)
+      --pods-per-node N
+                    Maximum pods per node for --k8s (default 110)
+      --services N  Service count for --k8s (default 1000)
+      --pod-cidr C  Pod CIDRs for --k8s, one per address family
+      --service-cidr C
+                    Service CIDRs for --k8s, one per address family
+      --supernet C  Networks to plan pod and service CIDRs from for --k8s
+                    (default 10.0.0.0/8; add an IPv6 one for dual-stack)
+      --node-mask N IPv4 per-node pod mask size for --k8s
+      --node-mask6 N
+                    IPv6 per-node pod mask size for --k8s (default 64)
+  -o, --output F    Output format for plans (text, json, terraform)
+
+Configuration:
+  Defaults are read from $XDG_CONFIG_HOME/ipcalc/config (or $IPCALC_CONFIG),
+  one "key = value" per line, and then from IPCALC_* environment variables
+  such as IPCALC_IPV4_PREFIX. Flags override both. Keys: ipv4-prefix,
+  ipv6-prefix, output (text, html), binary, theme, cloud, list-limit.
+  NO_COLOR disables colors.
 
 Examples:
   ipcalc 192.168.0.1/24
@@ -127,11 +377,40 @@ Examples:
   ipcalc 192.168.0.1 255.255.128.0 255.255.192.0
   ipcalc 192.168.0.1 0.0.63.255
   ipcalc -r 192.168.0.1 192.168.0.10
-  ipcalc -s 192.168.0.0/24 10 20 30`)
+  ipcalc -s 192.168.0.0/24 10 20 30
+  ipcalc -s 10.0.0.0/16 /22
+  ipcalc -s -e 10.0.0.0/16 8
+  ipcalc -s 2001:db8::/48 /52
+  ipcalc --next 10.0.0.0/26
+  ipcalc --host -10 10.0.0.0/24
+  ipcalc --add 300 10.0.0.1
+  ipcalc --distance 10.0.0.1 10.0.1.1
+  ipcalc -w 10.0.0.0 0.255.0.255 10.7.0.9
+  ipcalc -r -f cisco --fw-name MGMT 192.168.0.1 192.168.0.10
+  ipcalc --routes table.txt 10.1.2.3 2001:db8::1
+  ipcalc --cloud aws 10.0.1.0/24
+  ipcalc --vpc --cloud aws --zones 3 --tier public:1,private:4,data:250h 10.0.0.0/16
+  ipcalc --k8s --nodes 250 --pods-per-node 60 10.10.0.0/22
+  ipcalc --k8s --supernet 10.0.0.0/8,fd00:10::/48 10.10.0.0/22
+  ipcalc -i
+  ipcalc -x 10.0.0.0/16
+  ipcalc exclude 10.0.0.0/16 10.0.4.0/24
+  ipcalc contains 10.0.0.0/8 10.1.2.3 192.168.0.1
+  ipcalc aggregate < networks.txt
+  ipcalc plan vpc --cloud aws 10.0.0.0/16
+  ipcalc serve --listen :8080
+  ipcalc cidrsubnet 10.0.0.0/16 8 2
+  ipcalc cidrhost 10.0.0.0/24 -1`)
 }
 
 // handleClassOnly handles the class-only mode
 func handleClassOnly(ipStr string) {
+	ipStr, err := normalizeAddress(ipStr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
 	// Check if it's an IPv6 address
 	if strings.Contains(ipStr, ":") {
 		fmt.Println("IPv6 addresses don't have classes")
@@ -154,42 +433,112 @@ func handleClassOnly(ipStr string) {
 }
 
 // handleDeaggregate handles the deaggregate mode
-func handleDeaggregate(startStr, endStr string, format formatter.OutputFormat) {
-	// Check if these are IPv6 addresses
-	if strings.Contains(startStr, ":") || strings.Contains(endStr, ":") {
-		fmt.Fprintln(os.Stderr, "Error: IPv6 deaggregation is not supported yet")
+func handleDeaggregate(args []string, count, list bool, format formatter.OutputFormat, firewall *formatter.FirewallOptions) {
+	specs, err := targetSpecs(args)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
-
-	// Deaggregate the range
-	networks, err := calculator.Deaggregate(startStr, endStr)
+	targets, err := calculator.ParseTargets(specs)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 
 	// Print the result
-	fmt.Printf("Deaggregating %s - %s\n", startStr, endStr)
-	fmt.Println(formatter.FormatDeaggregation(networks, format))
+	switch {
+	case count:
+		fmt.Println(formatter.FormatValue("Addresses", targets.Count().String(), format))
+	case list:
+		printAddresses(targets, listLimit)
+	case firewall != nil:
+		printFirewall(targets.CIDRs(), firewall)
+	default:
+		fmt.Printf("Deaggregating %s\n", strings.Join(specs, " "))
+		fmt.Println(formatter.FormatDeaggregation(targets.CIDRs(), format))
+	}
+}
+
+// printAddresses prints the addresses of targets one per line, stopping
+// after limit addresses unless it is 0
+func printAddresses(targets *calculator.Targets, limit int) {
+	writer := bufio.NewWriter(os.Stdout)
+	defer writer.Flush()
+	listed := 0
+	for addr := range targets.Addresses() {
+		if limit > 0 && listed == limit {
+			writer.Flush()
+			fmt.Fprintf(os.Stderr, "Note: stopped after %d of %s addresses (see list-limit and \"ipcalc list --limit\")\n",
+				limit, targets.Count())
+			return
+		}
+		fmt.Fprintln(writer, addr)
+		listed++
+	}
+}
+
+// targetSpecs returns the target specifications of range arguments. Two
+// plain addresses are the start and end of a range, as -r has always
+// taken them; anything else is a list of specifications for
+// calculator.ParseTargets.
+func targetSpecs(args []string) ([]string, error) {
+	if len(args) == 2 && !strings.ContainsAny(args[0]+args[1], "-,/*") {
+		start, err := normalizeAddress(args[0])
+		if err != nil {
+			return nil, err
+		}
+		end, err := normalizeAddress(args[1])
+		if err != nil {
+			return nil, err
+		}
+		return []string{start + " - " + end}, nil
+	}
+	return args, nil
+}
+
+// printSection prints further output after a blank line
+func printSection(text string, format formatter.OutputFormat) {
+	if text == "" {
+		return
+	}
+	if format.UseHTML {
+		fmt.Println("<br>")
+	} else {
+		fmt.Println()
+	}
+	fmt.Println(text)
 }
 
 // handleSplit handles the split mode
-func handleSplit(networkStr string, sizeStrs []string, format formatter.OutputFormat) {
-	// Parse the network
-	var ipStr, maskStr string
-	if strings.Contains(networkStr, "/") {
-		parts := strings.SplitN(networkStr, "/", 2)
-		ipStr = parts[0]
-		maskStr = parts[1]
-	} else if len(sizeStrs) > 0 {
-		ipStr = networkStr
+func handleSplit(networkStr string, sizeStrs []string, equal bool, format formatter.OutputFormat, firewall *formatter.FirewallOptions) {
+	// Parse the network, which takes its netmask from the first size if it
+	// has no prefix
+	in, err := calculator.ParseInput(networkStr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	maskStr := in.Prefix
+	if maskStr == "" {
+		if len(sizeStrs) == 0 {
+			fmt.Fprintln(os.Stderr, "Error: No netmask specified")
+			os.Exit(1)
+		}
 		maskStr = sizeStrs[0]
 		sizeStrs = sizeStrs[1:]
-	} else {
-		fmt.Fprintln(os.Stderr, "Error: No netmask specified")
+	}
+	ipStr, err := normalizeAddress(in.Address)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 
+	// Handle equal splits by subnet count or prefix length
+	if equal || (len(sizeStrs) == 1 && strings.HasPrefix(sizeStrs[0], "/")) {
+		handleEqualSplit(ipStr, maskStr, sizeStrs, equal, format, firewall)
+		return
+	}
+
 	// Parse the sizes
 	var sizes []int
 	for _, sizeStr := range sizeStrs {
@@ -215,36 +564,527 @@ func handleSplit(networkStr string, sizeStrs []string, format formatter.OutputFo
 	}
 
 	// Print the result
+	if firewall != nil {
+		printFirewall(networks, firewall)
+		return
+	}
 	fmt.Printf("Splitting %s/%s into subnets\n", ipStr, maskStr)
 	fmt.Println(formatter.FormatSplitNetwork(networks, format))
 }
 
-// handleNormal handles the normal mode
-func handleNormal(args []string, format formatter.OutputFormat) {
-	// Parse the IP address and netmask
-	var ipStr, maskStr string
-	if len(args) == 1 {
-		// Check if the argument contains a slash
-		if strings.Contains(args[0], "/") {
-			parts := strings.SplitN(args[0], "/", 2)
-			ipStr = parts[0]
-			maskStr = parts[1]
+// handleEqualSplit handles splitting a network into equal subnets
+func handleEqualSplit(ipStr, maskStr string, sizeStrs []string, byCount bool, format formatter.OutputFormat, firewall *formatter.FirewallOptions) {
+	if len(sizeStrs) != 1 {
+		fmt.Fprintln(os.Stderr, "Error: Equal split requires exactly one subnet count or /prefix")
+		os.Exit(1)
+	}
+
+	var subnets []calculator.Subnet
+	var err error
+	if byCount {
+		count, convErr := strconv.Atoi(sizeStrs[0])
+		if convErr != nil {
+			fmt.Fprintf(os.Stderr, "Error: Invalid subnet count: %s\n", sizeStrs[0])
+			os.Exit(1)
+		}
+		subnets, err = calculator.SplitNetworkByCount(ipStr, maskStr, count)
+	} else {
+		prefix, convErr := strconv.Atoi(strings.TrimPrefix(sizeStrs[0], "/"))
+		if convErr != nil {
+			fmt.Fprintf(os.Stderr, "Error: Invalid prefix length: %s\n", sizeStrs[0])
+			os.Exit(1)
+		}
+		subnets, err = calculator.SplitNetworkByPrefix(ipStr, maskStr, prefix)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Print the result
+	if firewall != nil {
+		var networks []string
+		for _, subnet := range subnets {
+			networks = append(networks, subnet.Network)
+		}
+		printFirewall(networks, firewall)
+		return
+	}
+	fmt.Printf("Splitting %s/%s into %d subnets\n", ipStr, maskStr, len(subnets))
+	fmt.Println(formatter.FormatSubnets(subnets, format))
+}
+
+// handleWildcard handles the wildcard mode, testing any extra addresses against the match
+func handleWildcard(ipStr, wildcardStr string, testStrs []string, list bool, format formatter.OutputFormat, firewall *formatter.FirewallOptions) {
+	match, err := calculator.ParseWildcardMatch(ipStr, wildcardStr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if firewall != nil {
+		if match.CIDRCount() > calculator.MaxSubnets {
+			fmt.Fprintf(os.Stderr, "Error: wildcard matches %d networks (limit is %d)\n", match.CIDRCount(), calculator.MaxSubnets)
+			os.Exit(1)
+		}
+		printFirewall(match.CIDRs(calculator.MaxSubnets), firewall)
+		return
+	}
+
+	// Print the result
+	fmt.Print(formatter.FormatWildcardMatch(match, listLimit, list, format))
+
+	for _, testStr := range testStrs {
+		ip, err := calculator.ParseIPv4(testStr)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		result := "no match"
+		if match.Matches(ip) {
+			result = "match"
+		}
+		fmt.Println(formatter.FormatValue(testStr, result, format))
+	}
+}
+
+// handleTerraformFunction evaluates a Terraform-compatible CIDR function
+func handleTerraformFunction(name string, args []string) {
+	var results []string
+	var err error
+
+	switch {
+	case name == "cidrsubnet" && len(args) == 2:
+		// Reverse mode: find the arguments that produce a given subnet
+		newBits, netNum, reverseErr := calculator.CIDRSubnetReverse(args[0], args[1])
+		if reverseErr != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", reverseErr)
+			os.Exit(1)
+		}
+		fmt.Printf("newbits = %d\nnetnum  = %d\n", newBits, netNum)
+		fmt.Printf("cidrsubnet(%q, %d, %d)\n", args[0], newBits, netNum)
+		return
+	case name == "cidrsubnet" && len(args) == 3:
+		newBits, netNum := parseTerraformInt(args[1]), parseTerraformInt(args[2])
+		var result string
+		result, err = calculator.CIDRSubnet(args[0], int(newBits), netNum)
+		results = []string{result}
+	case name == "cidrhost" && len(args) == 2:
+		hostNum, ok := new(big.Int).SetString(args[1], 10)
+		if !ok {
+			fmt.Fprintf(os.Stderr, "Error: Invalid number: %s\n", args[1])
+			os.Exit(1)
+		}
+		var result string
+		result, err = calculator.CIDRHost(args[0], hostNum)
+		results = []string{result}
+	case name == "cidrnetmask" && len(args) == 1:
+		var result string
+		result, err = calculator.CIDRNetmask(args[0])
+		results = []string{result}
+	case name == "cidrsubnets" && len(args) >= 1:
+		var newBits []int
+		for _, arg := range args[1:] {
+			newBits = append(newBits, int(parseTerraformInt(arg)))
+		}
+		results, err = calculator.CIDRSubnets(args[0], newBits...)
+	default:
+		fmt.Fprintf(os.Stderr, "Error: Usage: ipcalc %s\n", terraformUsage(name))
+		os.Exit(1)
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	for _, result := range results {
+		fmt.Println(result)
+	}
+}
+
+// parseTerraformInt parses an integer argument of a Terraform-compatible function
+func parseTerraformInt(arg string) int64 {
+	value, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Invalid number: %s\n", arg)
+		os.Exit(1)
+	}
+	return value
+}
+
+// terraformUsage returns the argument synopsis of a Terraform-compatible function
+func terraformUsage(name string) string {
+	switch name {
+	case "cidrsubnet":
+		return "cidrsubnet PREFIX NEWBITS NETNUM | cidrsubnet PREFIX SUBNET"
+	case "cidrhost":
+		return "cidrhost PREFIX HOSTNUM"
+	case "cidrnetmask":
+		return "cidrnetmask PREFIX"
+	default:
+		return "cidrsubnets PREFIX NEWBITS..."
+	}
+}
+
+// handleVPCPlan handles the VPC planning mode
+func handleVPCPlan(spec planner.VPCSpec, output string, format formatter.OutputFormat) {
+	plan, err := planner.PlanVPC(spec)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Print the result
+	switch output {
+	case "text":
+		fmt.Print(formatter.FormatVPCPlan(plan, format))
+	case "json":
+		printJSON(plan)
+	case "terraform":
+		fmt.Print(formatter.FormatVPCPlanTerraform(plan))
+	default:
+		fmt.Fprintf(os.Stderr, "Error: Unknown output format: %s (must be one of text, json, terraform)\n", output)
+		os.Exit(1)
+	}
+}
+
+// handleKubernetesPlan handles the Kubernetes planning mode
+func handleKubernetesPlan(spec planner.KubernetesSpec, output string, format formatter.OutputFormat) {
+	plan, err := planner.PlanKubernetes(spec)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Print the result
+	switch output {
+	case "text":
+		fmt.Print(formatter.FormatKubernetesPlan(plan, format))
+	case "json":
+		printJSON(plan)
+	default:
+		fmt.Fprintf(os.Stderr, "Error: Unknown output format: %s (must be one of text, json)\n", output)
+		os.Exit(1)
+	}
+}
+
+// printJSON prints a result as JSON
+func printJSON(value any) {
+	result, err := formatter.FormatJSON(value)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Print(result)
+}
+
+// handleRouteLookup handles looking up addresses in a routing table file
+func handleRouteLookup(path string, addrStrs []string, format formatter.OutputFormat) {
+	table, err := routing.LoadFile(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	var addrs []netip.Addr
+	for _, addrStr := range addrStrs {
+		addr, err := netip.ParseAddr(addrStr)
+		if err == nil {
+			addrs = append(addrs, addr)
+			continue
+		}
+
+		// Ranges look up every address in them
+		if !strings.ContainsAny(addrStr, "-,*") {
+			fmt.Fprintf(os.Stderr, "Error: invalid IP address: %s\n", addrStr)
+			os.Exit(1)
+		}
+		targets, err := calculator.ParseTargets([]string{addrStr})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		for addr := range targets.Addresses() {
+			if len(addrs) == listLimit {
+				fmt.Fprintf(os.Stderr, "Error: Too many addresses to look up (limit is %d)\n", listLimit)
+				os.Exit(1)
+			}
+			addrs = append(addrs, addr)
+		}
+	}
+
+	for i, addr := range addrs {
+		// Print the result
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Print(formatter.FormatRouteLookup(table.Lookup(addr), format))
+	}
+}
+
+// printFirewall prints a list of networks as firewall rules
+func printFirewall(networks []string, firewall *formatter.FirewallOptions) {
+	rules, err := formatter.FormatFirewall(networks, *firewall)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Print(rules)
+}
+
+// parseNetworkInput splits the arguments into an address and a netmask,
+// using the default netmask for the address family when none is given.
+// Brackets, zone IDs and ports are split off the address.
+func parseNetworkInput(args []string) (calculator.Input, error) {
+	if len(args) == 0 {
+		return calculator.Input{}, nil
+	}
+	in, err := calculator.ParseInput(args[0])
+	if err != nil {
+		return calculator.Input{}, err
+	}
+	if len(args) >= 2 && in.Prefix == "" {
+		in.Prefix = args[1]
+	}
+
+	// A number may turn out to be an IPv6 address
+	in.Address, err = normalizeAddress(in.Address)
+	if err != nil {
+		return calculator.Input{}, err
+	}
+	in.IPv6 = strings.Contains(in.Address, ":")
+
+	// Use default netmask based on IP version
+	if in.Prefix == "" {
+		if in.IPv6 {
+			in.Prefix = defaultIPv6Prefix
 		} else {
-			ipStr = args[0]
-			// Use default netmask based on IP version
-			if strings.Contains(ipStr, ":") {
-				maskStr = "64" // Default for IPv6
-			} else {
-				maskStr = "24" // Default for IPv4
+			in.Prefix = defaultIPv4Prefix
+		}
+	}
+	return in, nil
+}
+
+// parseNetworkArgs splits the arguments into an address, with its zone ID
+// if any, and a netmask, as parseNetworkInput does
+func parseNetworkArgs(args []string) (string, string, error) {
+	in, err := parseNetworkInput(args)
+	if err != nil {
+		return "", "", err
+	}
+	return in.Host(), in.Prefix, nil
+}
+
+// strictInput rejects addresses that inet_aton and dotted-decimal parsers
+// read differently instead of interpreting them
+var strictInput bool
+
+// normalizeAddress rewrites an address given as a number, such as
+// 3232235777 or 0xC0A80101, or in a legacy inet_aton form, such as 10.1 or
+// 010.0.0.1, as a plain address. How a legacy form was interpreted is
+// printed as a note, and with strictInput ambiguous forms are an error.
+func normalizeAddress(ipStr string) (string, error) {
+	if _, err := netip.ParseAddr(ipStr); err == nil {
+		return ipStr, nil
+	}
+	if addr, err := calculator.ParseNumericAddress(ipStr); err == nil {
+		return addr.String(), nil
+	}
+
+	if strictInput {
+		if _, err := calculator.ParseIPv4Strict(ipStr); errors.Is(err, calculator.ErrAmbiguousAddress) {
+			return "", err
+		}
+	}
+	ip, notes, err := calculator.ParseInetAton(ipStr)
+	if err != nil {
+		// Leave the error to the calculator
+		return ipStr, nil
+	}
+	normalized := calculator.IPToString(ip)
+	fmt.Fprintf(os.Stderr, "Note: %s is %s: %s\n", ipStr, normalized, strings.Join(notes, " "))
+	return normalized, nil
+}
+
+// handleNetworkArithmetic handles the next, previous and Nth host modes
+func handleNetworkArithmetic(args []string, next, prev bool, hostStr string, format formatter.OutputFormat) {
+	ipStr, maskStr, err := parseNetworkArgs(args)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if strings.Contains(ipStr, ":") {
+		network, err := calculator.CalculateIPv6Network(ipStr, maskStr)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		if hostStr != "" {
+			index, ok := new(big.Int).SetString(hostStr, 10)
+			if !ok {
+				fmt.Fprintf(os.Stderr, "Error: Invalid host index: %s\n", hostStr)
+				os.Exit(1)
+			}
+			host, err := network.Host(index)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
 			}
+			fmt.Println(formatter.FormatValue("Host "+hostStr, calculator.IPv6ToString(host), format))
+			return
+		}
+
+		if next {
+			network, err = network.Next()
+		} else if prev {
+			network, err = network.Previous()
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
 		}
-	} else if len(args) >= 2 {
-		ipStr = args[0]
-		maskStr = args[1]
+		fmt.Println(formatter.FormatIPv6Network(network, format))
+		return
 	}
 
-	// Check if it's an IPv6 address
+	network, err := calculator.CalculateNetwork(ipStr, maskStr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if hostStr != "" {
+		index, err := strconv.ParseInt(hostStr, 10, 64)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Invalid host index: %s\n", hostStr)
+			os.Exit(1)
+		}
+		host, err := network.Host(index)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(formatter.FormatValue("Host "+hostStr, calculator.IPToString(host), format))
+		return
+	}
+
+	if next {
+		network, err = network.Next()
+	} else if prev {
+		network, err = network.Previous()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(formatter.FormatIPv4Network(network, format))
+}
+
+// handleAdd handles adding an offset to an address
+func handleAdd(ipStr, offsetStr string, format formatter.OutputFormat) {
+	ipStr, err := normalizeAddress(ipStr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
 	if strings.Contains(ipStr, ":") {
+		ip, err := calculator.ParseIPv6(ipStr)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		offset, ok := new(big.Int).SetString(offsetStr, 10)
+		if !ok {
+			fmt.Fprintf(os.Stderr, "Error: Invalid offset: %s\n", offsetStr)
+			os.Exit(1)
+		}
+		result, err := calculator.AddIPv6(ip, offset)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(formatter.FormatValue("Address", calculator.IPv6ToString(result), format))
+		return
+	}
+
+	ip, err := calculator.ParseIPv4(ipStr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	offset, err := strconv.ParseInt(offsetStr, 10, 64)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Invalid offset: %s\n", offsetStr)
+		os.Exit(1)
+	}
+	result, err := calculator.AddIPv4(ip, offset)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(formatter.FormatValue("Address", calculator.IPToString(result), format))
+}
+
+// handleDistance handles counting the addresses between two addresses
+func handleDistance(startStr, endStr string, format formatter.OutputFormat) {
+	startStr, err := normalizeAddress(startStr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	endStr, err = normalizeAddress(endStr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if strings.Contains(startStr, ":") || strings.Contains(endStr, ":") {
+		start, err := calculator.ParseIPv6(startStr)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		end, err := calculator.ParseIPv6(endStr)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(formatter.FormatValue("Distance", calculator.IPv6Distance(start, end).String(), format))
+		return
+	}
+
+	start, err := calculator.ParseIPv4(startStr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	end, err := calculator.ParseIPv4(endStr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(formatter.FormatValue("Distance", strconv.FormatInt(calculator.IPv4Distance(start, end), 10), format))
+}
+
+// handleNormal handles the normal mode
+func handleNormal(args []string, format formatter.OutputFormat, numericForms []formatter.NumericForm, firewall *formatter.FirewallOptions, cloudProvider *calculator.CloudProvider) {
+	// Parse the IP address and netmask
+	in, err := parseNetworkInput(args)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	ipStr, maskStr := in.Host(), in.Prefix
+
+	// Check if it's an IPv6 address
+	if in.IPv6 {
+		if cloudProvider != nil {
+			fmt.Fprintln(os.Stderr, "Error: Cloud provider presets only support IPv4 subnets")
+			os.Exit(1)
+		}
+
 		// Calculate IPv6 network
 		network, err := calculator.CalculateIPv6Network(ipStr, maskStr)
 		if err != nil {
@@ -253,7 +1093,14 @@ func handleNormal(args []string, format formatter.OutputFormat) {
 		}
 
 		// Print the result
+		if firewall != nil {
+			printFirewall([]string{fmt.Sprintf("%s/%d", calculator.IPv6ToString(network.NetworkID), network.PrefixLen)}, firewall)
+			return
+		}
 		fmt.Println(formatter.FormatIPv6Network(network, format))
+		if len(numericForms) > 0 {
+			printSection(formatter.FormatIPv6Numeric(network, numericForms, format), format)
+		}
 	} else {
 		// Calculate IPv4 network
 		network, err := calculator.CalculateNetwork(ipStr, maskStr)
@@ -262,7 +1109,27 @@ func handleNormal(args []string, format formatter.OutputFormat) {
 			os.Exit(1)
 		}
 
+		// Apply the cloud provider's reserved addresses
+		if cloudProvider != nil {
+			if err := calculator.ApplyCloudProvider(network, *cloudProvider); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		}
+
 		// Print the result
+		if firewall != nil {
+			printFirewall([]string{fmt.Sprintf("%s/%d", calculator.IPToString(network.NetworkID), network.BitCount)}, firewall)
+			return
+		}
 		fmt.Println(formatter.FormatIPv4Network(network, format))
+		if len(numericForms) > 0 {
+			printSection(formatter.FormatIPv4Numeric(network, numericForms, format), format)
+		}
+	}
+
+	// A port is not part of the network, so show it on its own
+	if in.Port != "" {
+		printSection(formatter.FormatValue("Port", in.Port, format), format)
 	}
 } 
\ No newline at end of file
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/neontowel/ipcalc-go/pkg/extract"
+	"github.com/neontowel/ipcalc-go/pkg/formatter"
+)
+
+// handlePaste reads pasted ifconfig, ip addr or router configuration text
+// from stdin and shows the network of every address/mask found in it,
+// with warnings for inconsistencies such as a mismatched broadcast
+func handlePaste(format formatter.OutputFormat) {
+	entries, err := extract.Load(os.Stdin)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if len(entries) == 0 {
+		fmt.Fprintln(os.Stderr, "Error: No addresses with a netmask or prefix length found in the input")
+		os.Exit(1)
+	}
+
+	failed := false
+	for i, entry := range entries {
+		if i > 0 {
+			if format.UseHTML {
+				fmt.Println("<br>")
+			} else {
+				fmt.Println()
+			}
+		}
+		fmt.Println(formatter.FormatValue("Found", fmt.Sprintf("line %d (%s): %s", entry.Line, entry.Source, entry.Text), format))
+
+		var report string
+		var problems []string
+		if entry.IPv6 {
+			network, ipv6Problems, err := entry.CheckIPv6()
+			if err != nil {
+				failed = true
+				fmt.Println(formatter.FormatValue("Error", err.Error(), format))
+				continue
+			}
+			report, problems = formatter.FormatIPv6Network(network, format), ipv6Problems
+		} else {
+			network, ipv4Problems, err := entry.Check()
+			if err != nil {
+				failed = true
+				fmt.Println(formatter.FormatValue("Error", err.Error(), format))
+				continue
+			}
+			report, problems = formatter.FormatIPv4Network(network, format), ipv4Problems
+		}
+
+		for _, problem := range problems {
+			fmt.Println(formatter.FormatValue("Warning", problem, format))
+		}
+		fmt.Println(report)
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
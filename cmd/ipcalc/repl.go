@@ -0,0 +1,439 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/neontowel/ipcalc-go/internal/term"
+	"github.com/neontowel/ipcalc-go/internal/version"
+	"github.com/neontowel/ipcalc-go/pkg/calculator"
+	"github.com/neontowel/ipcalc-go/pkg/formatter"
+)
+
+// replSession is the state of an interactive session. The current network
+// is the context for commands such as next and split, and the networks
+// listed by the last command can be picked with use.
+type replSession struct {
+	format  formatter.OutputFormat
+	editor  *term.Editor
+	current string
+	results []string
+}
+
+// replCommand is a command of the interactive session
+type replCommand struct {
+	name string
+	args string
+	help string
+	run  func(s *replSession, args []string) error
+}
+
+// replCommands lists the session commands; they are looked up by name or unique prefix
+var replCommands []replCommand
+
+func init() {
+	replCommands = []replCommand{
+		{"show", "[NETWORK]", "Show the current or given network", (*replSession).cmdShow},
+		{"next", "", "Move to the next network of the same size", (*replSession).cmdNext},
+		{"prev", "", "Move to the previous network of the same size", (*replSession).cmdPrev},
+		{"host", "N", "Show the Nth host (negative counts from the end)", (*replSession).cmdHost},
+		{"split", "COUNT|/PREFIX", "Split the current network into equal subnets", (*replSession).cmdSplit},
+		{"range", "START END|SPEC...", "Deaggregate an address range or targets such as 10.0.1-3.1-254", (*replSession).cmdRange},
+		{"contains", "ADDRESS...", "Check whether the current network contains addresses or networks", (*replSession).cmdContains},
+		{"aggregate", "[NETWORK...]", "Merge networks, or the last listed networks, into the fewest blocks", (*replSession).cmdAggregate},
+		{"use", "N", "Make the Nth listed network the current network", (*replSession).cmdUse},
+		{"history", "", "Show the lines entered so far", (*replSession).cmdHistory},
+		{"help", "", "Show this help", (*replSession).cmdHelp},
+		{"quit", "", "Leave the session (also exit or Ctrl-D)", nil},
+	}
+}
+
+// errQuit ends the session
+var errQuit = errors.New("quit")
+
+// handleInteractive runs an interactive session on standard input
+func handleInteractive(format formatter.OutputFormat) {
+	s := &replSession{format: format}
+	s.editor = term.NewEditor(os.Stdin, os.Stdout, s.complete)
+
+	if s.editor.IsInteractive() {
+		fmt.Printf("ipcalc-go %s interactive mode. Type a network or \"help\".\n", version.Version)
+	}
+
+	for {
+		line, err := s.editor.ReadLine(s.prompt())
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		s.editor.AddHistory(line)
+		if err := s.execute(line); err == errQuit {
+			return
+		} else if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		}
+	}
+}
+
+// prompt returns the prompt, showing the current network
+func (s *replSession) prompt() string {
+	if s.current == "" {
+		return "ipcalc> "
+	}
+	return fmt.Sprintf("ipcalc %s> ", s.current)
+}
+
+// execute runs one line: a command, or a network to make current
+func (s *replSession) execute(line string) error {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return nil
+	}
+
+	name := strings.ToLower(fields[0])
+	if name == "exit" {
+		return errQuit
+	}
+	if command := findReplCommand(name); command != nil {
+		if command.run == nil {
+			return errQuit
+		}
+		return command.run(s, fields[1:])
+	}
+	if !strings.ContainsAny(name, ".:/") && strings.Trim(name, "0123456789") != "" {
+		return fmt.Errorf("unknown command: %s (type \"help\" for a list)", fields[0])
+	}
+	return s.cmdShow(fields)
+}
+
+// findReplCommand returns the command with the given name or unique name prefix
+func findReplCommand(name string) *replCommand {
+	var found *replCommand
+	for i := range replCommands {
+		if replCommands[i].name == name {
+			return &replCommands[i]
+		}
+		if strings.HasPrefix(replCommands[i].name, name) {
+			if found != nil {
+				return nil
+			}
+			found = &replCommands[i]
+		}
+	}
+	return found
+}
+
+// complete completes command names in the first word, list indexes after
+// use, and known networks elsewhere
+func (s *replSession) complete(line string) []string {
+	fields := strings.Fields(line)
+	word := ""
+	if len(fields) > 0 && !strings.HasSuffix(line, " ") {
+		word = fields[len(fields)-1]
+		fields = fields[:len(fields)-1]
+	}
+
+	var options []string
+	switch {
+	case len(fields) == 0:
+		for _, command := range replCommands {
+			options = append(options, command.name)
+		}
+	case fields[0] == "use":
+		for i := range s.results {
+			options = append(options, strconv.Itoa(i+1))
+		}
+	default:
+		seen := make(map[string]bool)
+		for _, network := range append([]string{s.current}, s.results...) {
+			if network != "" && !seen[network] {
+				seen[network] = true
+				options = append(options, network)
+			}
+		}
+	}
+
+	var matches []string
+	for _, option := range options {
+		if strings.HasPrefix(option, word) {
+			matches = append(matches, option)
+		}
+	}
+	return matches
+}
+
+// requireCurrent returns the current network split into address and netmask
+func (s *replSession) requireCurrent() (string, string, error) {
+	if s.current == "" {
+		return "", "", fmt.Errorf("no current network; enter a network first")
+	}
+	ipStr, maskStr, _ := strings.Cut(s.current, "/")
+	return ipStr, maskStr, nil
+}
+
+// cmdShow shows a network and makes it current
+func (s *replSession) cmdShow(args []string) error {
+	if len(args) == 0 {
+		ipStr, maskStr, err := s.requireCurrent()
+		if err != nil {
+			return err
+		}
+		args = []string{ipStr, maskStr}
+	}
+	if len(args) > 2 {
+		return fmt.Errorf("usage: show [ADDRESS[/MASK] [MASK]]")
+	}
+
+	ipStr, maskStr, err := parseNetworkArgs(args)
+	if err != nil {
+		return err
+	}
+	if strings.Contains(ipStr, ":") {
+		network, err := calculator.CalculateIPv6Network(ipStr, maskStr)
+		if err != nil {
+			return err
+		}
+		s.current = fmt.Sprintf("%s/%d", ipStr, network.PrefixLen)
+		fmt.Println(formatter.FormatIPv6Network(network, s.format))
+		return nil
+	}
+
+	network, err := calculator.CalculateNetwork(ipStr, maskStr)
+	if err != nil {
+		return err
+	}
+	s.current = fmt.Sprintf("%s/%d", ipStr, network.BitCount)
+	fmt.Println(formatter.FormatIPv4Network(network, s.format))
+	return nil
+}
+
+// cmdNext moves to the next network of the same size
+func (s *replSession) cmdNext(args []string) error {
+	return s.step(true)
+}
+
+// cmdPrev moves to the previous network of the same size
+func (s *replSession) cmdPrev(args []string) error {
+	return s.step(false)
+}
+
+// step moves the current network forward or back by its own size
+func (s *replSession) step(forward bool) error {
+	ipStr, maskStr, err := s.requireCurrent()
+	if err != nil {
+		return err
+	}
+
+	if strings.Contains(ipStr, ":") {
+		network, err := calculator.CalculateIPv6Network(ipStr, maskStr)
+		if err != nil {
+			return err
+		}
+		if forward {
+			network, err = network.Next()
+		} else {
+			network, err = network.Previous()
+		}
+		if err != nil {
+			return err
+		}
+		s.current = fmt.Sprintf("%s/%d", calculator.IPv6ToString(network.NetworkID), network.PrefixLen)
+		fmt.Println(formatter.FormatIPv6Network(network, s.format))
+		return nil
+	}
+
+	network, err := calculator.CalculateNetwork(ipStr, maskStr)
+	if err != nil {
+		return err
+	}
+	if forward {
+		network, err = network.Next()
+	} else {
+		network, err = network.Previous()
+	}
+	if err != nil {
+		return err
+	}
+	s.current = fmt.Sprintf("%s/%d", calculator.IPToString(network.NetworkID), network.BitCount)
+	fmt.Println(formatter.FormatIPv4Network(network, s.format))
+	return nil
+}
+
+// cmdHost shows the Nth host of the current network
+func (s *replSession) cmdHost(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: host N")
+	}
+	ipStr, maskStr, err := s.requireCurrent()
+	if err != nil {
+		return err
+	}
+
+	if strings.Contains(ipStr, ":") {
+		network, err := calculator.CalculateIPv6Network(ipStr, maskStr)
+		if err != nil {
+			return err
+		}
+		index, ok := new(big.Int).SetString(args[0], 10)
+		if !ok {
+			return fmt.Errorf("invalid host index: %s", args[0])
+		}
+		host, err := network.Host(index)
+		if err != nil {
+			return err
+		}
+		fmt.Println(formatter.FormatValue("Host "+args[0], calculator.IPv6ToString(host), s.format))
+		return nil
+	}
+
+	network, err := calculator.CalculateNetwork(ipStr, maskStr)
+	if err != nil {
+		return err
+	}
+	index, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid host index: %s", args[0])
+	}
+	host, err := network.Host(index)
+	if err != nil {
+		return err
+	}
+	fmt.Println(formatter.FormatValue("Host "+args[0], calculator.IPToString(host), s.format))
+	return nil
+}
+
+// cmdSplit splits the current network into equal subnets
+func (s *replSession) cmdSplit(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: split COUNT|/PREFIX")
+	}
+	ipStr, maskStr, err := s.requireCurrent()
+	if err != nil {
+		return err
+	}
+
+	var subnets []calculator.Subnet
+	if prefixStr, ok := strings.CutPrefix(args[0], "/"); ok {
+		prefix, convErr := strconv.Atoi(prefixStr)
+		if convErr != nil {
+			return fmt.Errorf("invalid prefix length: %s", args[0])
+		}
+		subnets, err = calculator.SplitNetworkByPrefix(ipStr, maskStr, prefix)
+	} else {
+		count, convErr := strconv.Atoi(args[0])
+		if convErr != nil {
+			return fmt.Errorf("invalid subnet count: %s", args[0])
+		}
+		subnets, err = calculator.SplitNetworkByCount(ipStr, maskStr, count)
+	}
+	if err != nil {
+		return err
+	}
+
+	s.results = nil
+	for _, subnet := range subnets {
+		s.results = append(s.results, subnet.Network)
+	}
+	fmt.Println(formatter.FormatSubnets(subnets, s.format))
+	return nil
+}
+
+// cmdRange deaggregates an address range or target specifications
+func (s *replSession) cmdRange(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: range START END | range SPEC...")
+	}
+
+	specs, err := targetSpecs(args)
+	if err != nil {
+		return err
+	}
+	targets, err := calculator.ParseTargets(specs)
+	if err != nil {
+		return err
+	}
+	networks := targets.CIDRs()
+	s.results = networks
+	fmt.Println(formatter.FormatDeaggregation(networks, s.format))
+	return nil
+}
+
+// cmdContains checks addresses or networks against the current network
+func (s *replSession) cmdContains(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: contains ADDRESS...")
+	}
+	if _, _, err := s.requireCurrent(); err != nil {
+		return err
+	}
+
+	for _, arg := range args {
+		contained, err := calculator.Contains(s.current, arg)
+		if err != nil {
+			return err
+		}
+		answer := "no"
+		if contained {
+			answer = "yes"
+		}
+		fmt.Printf("%-20s %s\n", arg, answer)
+	}
+	return nil
+}
+
+// cmdAggregate merges networks into the fewest CIDR blocks
+func (s *replSession) cmdAggregate(args []string) error {
+	if len(args) == 0 {
+		args = s.results
+	}
+	if len(args) == 0 {
+		return fmt.Errorf("usage: aggregate NETWORK...")
+	}
+
+	networks, err := calculator.Aggregate(args)
+	if err != nil {
+		return err
+	}
+	s.results = networks
+	fmt.Println(formatter.FormatDeaggregation(networks, s.format))
+	return nil
+}
+
+// cmdUse makes a listed network the current network
+func (s *replSession) cmdUse(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: use N")
+	}
+	index, err := strconv.Atoi(args[0])
+	if err != nil || index < 1 || index > len(s.results) {
+		return fmt.Errorf("no listed network %s (the last command listed %d)", args[0], len(s.results))
+	}
+	return s.cmdShow([]string{s.results[index-1]})
+}
+
+// cmdHistory prints the lines entered so far
+func (s *replSession) cmdHistory(args []string) error {
+	for i, line := range s.editor.History() {
+		fmt.Printf("%4d  %s\n", i+1, line)
+	}
+	return nil
+}
+
+// cmdHelp prints the session commands
+func (s *replSession) cmdHelp(args []string) error {
+	fmt.Println("Enter a network (such as 10.0.0.0/24) to make it current, or a command:")
+	for _, command := range replCommands {
+		fmt.Printf("  %-28s %s\n", strings.TrimSpace(command.name+" "+command.args), command.help)
+	}
+	fmt.Println("Commands may be shortened to a unique prefix.")
+	return nil
+}
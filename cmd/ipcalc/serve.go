@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/neontowel/ipcalc-go/internal/version"
+	"github.com/neontowel/ipcalc-go/pkg/api"
+	"github.com/spf13/pflag"
+)
+
+// setupServe defines the flags of the serve command
+func setupServe(flags *pflag.FlagSet, cfg config) func(args []string) {
+	listen := flags.String("listen", ":8080", "Address to listen on")
+	maxBody := flags.Int64("max-body", api.DefaultMaxBodyBytes, "Largest accepted request body in bytes")
+	maxItems := flags.Int("max-items", api.DefaultMaxItems, "Most networks or addresses accepted or returned by one request")
+
+	return func(args []string) {
+		handleServe(*listen, *maxBody, *maxItems)
+	}
+}
+
+// handleServe runs the JSON HTTP API until interrupted
+func handleServe(listen string, maxBody int64, maxItems int) {
+	server := api.NewServer()
+	server.MaxBodyBytes = maxBody
+	server.MaxItems = maxItems
+
+	httpServer := &http.Server{
+		Addr:              listen,
+		Handler:           server.Handler(),
+		ReadHeaderTimeout: 5 * time.Second,
+		ReadTimeout:       10 * time.Second,
+		WriteTimeout:      30 * time.Second,
+		IdleTimeout:       60 * time.Second,
+	}
+
+	// Shut down gracefully on Ctrl-C or SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_ = httpServer.Shutdown(shutdownCtx)
+	}()
+
+	fmt.Fprintf(os.Stderr, "ipcalc-go %s API listening on %s (OpenAPI document at /openapi.json)\n", version.Version, listen)
+	if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
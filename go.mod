@@ -2,4 +2,4 @@ module github.com/neontowel/ipcalc-go
 
 go 1.24.0
 
-require github.com/spf13/pflag v1.0.6 // indirect
+require github.com/spf13/pflag v1.0.6
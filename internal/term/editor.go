@@ -0,0 +1,248 @@
+// Package term provides raw terminal access for interactive modes: key
+// decoding, and a minimal line editor with history and tab completion
+package term
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// CompleteFunc returns the completions of the last word of line, which is
+// the text before the cursor
+type CompleteFunc func(line string) []string
+
+// Editor reads lines from a terminal with cursor movement, history and
+// completion. When the input is not a terminal it reads plain lines and
+// prints no prompt, so sessions can be scripted.
+type Editor struct {
+	in       *os.File
+	out      io.Writer
+	reader   *bufio.Reader
+	history  []string
+	complete CompleteFunc
+	terminal bool
+}
+
+// NewEditor returns a line editor reading from in and echoing to out
+func NewEditor(in *os.File, out io.Writer, complete CompleteFunc) *Editor {
+	return &Editor{
+		in:       in,
+		out:      out,
+		reader:   bufio.NewReader(in),
+		complete: complete,
+		terminal: IsTerminal(in.Fd()),
+	}
+}
+
+// IsInteractive reports whether the editor reads from a terminal
+func (e *Editor) IsInteractive() bool {
+	return e.terminal
+}
+
+// History returns the lines entered so far, oldest first
+func (e *Editor) History() []string {
+	return e.history
+}
+
+// AddHistory appends a line to the history, skipping blanks and repeats
+func (e *Editor) AddHistory(line string) {
+	if strings.TrimSpace(line) == "" {
+		return
+	}
+	if len(e.history) > 0 && e.history[len(e.history)-1] == line {
+		return
+	}
+	e.history = append(e.history, line)
+}
+
+// ReadLine reads one line, returning io.EOF at the end of input
+func (e *Editor) ReadLine(prompt string) (string, error) {
+	if !e.terminal {
+		return e.readPlain()
+	}
+
+	old, err := MakeRaw(e.in.Fd())
+	if err != nil {
+		e.terminal = false
+		fmt.Fprint(e.out, prompt)
+		return e.readPlain()
+	}
+	defer func() { _ = Restore(e.in.Fd(), old) }()
+
+	return e.readEdited(prompt)
+}
+
+// readPlain reads a line without editing
+func (e *Editor) readPlain() (string, error) {
+	line, err := e.reader.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// lineState is the line being edited
+type lineState struct {
+	prompt  string
+	buf     []rune
+	pos     int
+	history int    // Index into the history, len(history) for the new line
+	saved   []rune // The new line, kept while browsing the history
+}
+
+// readEdited reads a line from a terminal in raw mode
+func (e *Editor) readEdited(prompt string) (string, error) {
+	s := &lineState{prompt: prompt, history: len(e.history)}
+	e.refresh(s)
+
+	lastTab := false
+	for {
+		key, r, err := ReadKey(e.reader)
+		if err != nil {
+			return "", err
+		}
+
+		switch key {
+		case KeyEnter:
+			fmt.Fprint(e.out, "\n")
+			return string(s.buf), nil
+		case Ctrl('c'):
+			fmt.Fprint(e.out, "^C\n")
+			s.buf, s.pos = nil, 0
+		case Ctrl('d'):
+			if len(s.buf) == 0 {
+				fmt.Fprint(e.out, "\n")
+				return "", io.EOF
+			}
+			e.deleteRune(s)
+		case KeyTab:
+			e.completeWord(s, lastTab)
+		case KeyBackspace:
+			if s.pos > 0 {
+				s.pos--
+				e.deleteRune(s)
+			}
+		case KeyDelete:
+			e.deleteRune(s)
+		case KeyHome, Ctrl('a'):
+			s.pos = 0
+		case KeyEnd, Ctrl('e'):
+			s.pos = len(s.buf)
+		case KeyLeft, Ctrl('b'):
+			s.pos = max(s.pos-1, 0)
+		case KeyRight, Ctrl('f'):
+			s.pos = min(s.pos+1, len(s.buf))
+		case Ctrl('k'):
+			s.buf = s.buf[:s.pos]
+		case Ctrl('u'):
+			s.buf = append([]rune{}, s.buf[s.pos:]...)
+			s.pos = 0
+		case Ctrl('w'):
+			start := s.pos
+			for start > 0 && unicode.IsSpace(s.buf[start-1]) {
+				start--
+			}
+			for start > 0 && !unicode.IsSpace(s.buf[start-1]) {
+				start--
+			}
+			s.buf = append(s.buf[:start], s.buf[s.pos:]...)
+			s.pos = start
+		case Ctrl('l'):
+			fmt.Fprint(e.out, "\033[H\033[2J")
+		case KeyUp, Ctrl('p'):
+			e.browseHistory(s, -1)
+		case KeyDown, Ctrl('n'):
+			e.browseHistory(s, 1)
+		case KeyRune:
+			if unicode.IsPrint(r) {
+				s.buf = append(s.buf[:s.pos], append([]rune{r}, s.buf[s.pos:]...)...)
+				s.pos++
+			}
+		}
+
+		lastTab = key == KeyTab
+		e.refresh(s)
+	}
+}
+
+// deleteRune deletes the rune under the cursor
+func (e *Editor) deleteRune(s *lineState) {
+	if s.pos < len(s.buf) {
+		s.buf = append(s.buf[:s.pos], s.buf[s.pos+1:]...)
+	}
+}
+
+// browseHistory moves through the history by delta entries
+func (e *Editor) browseHistory(s *lineState, delta int) {
+	index := s.history + delta
+	if index < 0 || index > len(e.history) {
+		return
+	}
+	if s.history == len(e.history) {
+		s.saved = s.buf
+	}
+	s.history = index
+	if index == len(e.history) {
+		s.buf = s.saved
+	} else {
+		s.buf = []rune(e.history[index])
+	}
+	s.pos = len(s.buf)
+}
+
+// completeWord completes the word before the cursor. A unique completion
+// is inserted; otherwise the common prefix is, and a second tab lists the
+// candidates.
+func (e *Editor) completeWord(s *lineState, listAll bool) {
+	if e.complete == nil {
+		return
+	}
+	head := string(s.buf[:s.pos])
+	candidates := e.complete(head)
+	if len(candidates) == 0 {
+		return
+	}
+
+	wordStart := strings.LastIndexFunc(head, unicode.IsSpace) + 1
+	word := head[wordStart:]
+
+	completion := candidates[0]
+	for _, candidate := range candidates[1:] {
+		completion = commonPrefix(completion, candidate)
+	}
+	if len(candidates) == 1 {
+		completion += " "
+	}
+
+	if len(completion) > len(word) {
+		newHead := []rune(head[:wordStart] + completion)
+		s.buf = append(newHead, s.buf[s.pos:]...)
+		s.pos = len(newHead)
+		return
+	}
+
+	if listAll && len(candidates) > 1 {
+		fmt.Fprintf(e.out, "\n%s\n", strings.Join(candidates, "  "))
+	}
+}
+
+// refresh redraws the prompt and line and places the cursor
+func (e *Editor) refresh(s *lineState) {
+	fmt.Fprintf(e.out, "\r%s%s\033[K", s.prompt, string(s.buf))
+	if back := len(s.buf) - s.pos; back > 0 {
+		fmt.Fprintf(e.out, "\033[%dD", back)
+	}
+}
+
+// commonPrefix returns the longest common prefix of two strings
+func commonPrefix(a, b string) string {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return a[:i]
+}
@@ -0,0 +1,122 @@
+package term
+
+import "bufio"
+
+// Key identifies a special key. Printable characters are reported as
+// KeyRune with the character alongside.
+type Key int
+
+// Keys decoded by ReadKey. Control characters without a name of their own
+// are reported as KeyCtrlA through KeyCtrlZ; see Ctrl.
+const (
+	KeyRune Key = iota
+	KeyEnter
+	KeyTab
+	KeyBackspace
+	KeyDelete
+	KeyEscape
+	KeyUp
+	KeyDown
+	KeyLeft
+	KeyRight
+	KeyHome
+	KeyEnd
+	KeyPageUp
+	KeyPageDown
+	KeyCtrlA
+	KeyCtrlZ = KeyCtrlA + 25
+	KeyUnknown
+)
+
+// Ctrl returns the key for a control character, such as Ctrl('c') for Ctrl-C
+func Ctrl(letter byte) Key {
+	return KeyCtrlA + Key(letter-'a')
+}
+
+// ReadKey reads one key press from a terminal in raw mode, decoding the
+// escape sequences of cursor and editing keys
+func ReadKey(reader *bufio.Reader) (Key, rune, error) {
+	r, _, err := reader.ReadRune()
+	if err != nil {
+		return KeyUnknown, 0, err
+	}
+
+	switch r {
+	case '\r', '\n':
+		return KeyEnter, r, nil
+	case '\t':
+		return KeyTab, r, nil
+	case 127, 8:
+		return KeyBackspace, r, nil
+	case 27:
+		return readEscape(reader), r, nil
+	}
+	if r < 32 {
+		return KeyCtrlA + Key(r-1), r, nil
+	}
+	return KeyRune, r, nil
+}
+
+// readEscape decodes the rest of an escape sequence. A lone escape is only
+// recognized when no sequence follows in the same read.
+func readEscape(reader *bufio.Reader) Key {
+	if reader.Buffered() == 0 {
+		return KeyEscape
+	}
+	prefix, err := reader.ReadByte()
+	if err != nil || (prefix != '[' && prefix != 'O') {
+		return KeyEscape
+	}
+	final, err := reader.ReadByte()
+	if err != nil {
+		return KeyUnknown
+	}
+
+	// Sequences such as ESC [ 3 ~ carry a number before the final byte
+	code := 0
+	if final >= '0' && final <= '9' {
+		for final >= '0' && final <= '9' {
+			code = code*10 + int(final-'0')
+			if final, err = reader.ReadByte(); err != nil {
+				return KeyUnknown
+			}
+		}
+		// Skip modifier parameters such as ESC [ 1 ; 5 C
+		for final == ';' || final >= '0' && final <= '9' {
+			if final, err = reader.ReadByte(); err != nil {
+				return KeyUnknown
+			}
+		}
+		if final == '~' {
+			switch code {
+			case 1, 7:
+				return KeyHome
+			case 3:
+				return KeyDelete
+			case 4, 8:
+				return KeyEnd
+			case 5:
+				return KeyPageUp
+			case 6:
+				return KeyPageDown
+			}
+			return KeyUnknown
+		}
+	}
+
+	switch final {
+	case 'A':
+		return KeyUp
+	case 'B':
+		return KeyDown
+	case 'C':
+		return KeyRight
+	case 'D':
+		return KeyLeft
+	case 'H':
+		return KeyHome
+	case 'F':
+		return KeyEnd
+	}
+	return KeyUnknown
+}
@@ -0,0 +1,31 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+
+package term
+
+import "errors"
+
+// State is unused on platforms without termios support
+type State struct{}
+
+var errUnsupported = errors.New("raw terminal mode is not supported on this platform")
+
+// IsTerminal reports whether a file descriptor refers to a terminal. Without
+// termios support line editing is unavailable, so it always reports false.
+func IsTerminal(fd uintptr) bool {
+	return false
+}
+
+// MakeRaw is not supported on this platform
+func MakeRaw(fd uintptr) (*State, error) {
+	return nil, errUnsupported
+}
+
+// Restore is not supported on this platform
+func Restore(fd uintptr, old *State) error {
+	return errUnsupported
+}
+
+// Size is not supported on this platform
+func Size(fd uintptr) (int, int, error) {
+	return 0, 0, errUnsupported
+}
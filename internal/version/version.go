@@ -0,0 +1,7 @@
+// Package version holds the release version shared by the ipcalc command
+// and the WebAssembly build
+package version
+
+// Version is the release version. Release builds may set it with
+// -ldflags "-X github.com/neontowel/ipcalc-go/internal/version.Version=1.2.3".
+var Version = "0.1.0"
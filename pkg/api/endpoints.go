@@ -0,0 +1,391 @@
+package api
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/neontowel/ipcalc-go/pkg/calculator"
+)
+
+// CalculateRequest asks for the details of a network. The netmask is taken
+// from the network's /prefix, or from Mask; without either it defaults to
+// /24 for IPv4 and /64 for IPv6.
+type CalculateRequest struct {
+	Network string `json:"network"`
+	Mask    string `json:"mask,omitempty"`
+	Cloud   string `json:"cloud,omitempty"` // Cloud provider preset for IPv4 reserved addresses
+}
+
+// Network describes a network. Counts are strings because IPv6 counts
+// exceed the integers JSON clients can represent.
+type Network struct {
+	Family    string            `json:"family"`
+	Address   string            `json:"address"`
+	Prefix    int               `json:"prefix"`
+	Netmask   string            `json:"netmask,omitempty"`
+	Wildcard  string            `json:"wildcard,omitempty"`
+	Network   string            `json:"network"`
+	Broadcast string            `json:"broadcast,omitempty"`
+	HostMin   string            `json:"host_min"`
+	HostMax   string            `json:"host_max"`
+	Hosts     string            `json:"hosts"`
+	Class     string            `json:"class,omitempty"`
+	Private   bool              `json:"private"`
+	Reserved  []ReservedAddress `json:"reserved,omitempty"`
+}
+
+// ReservedAddress is an address a cloud provider reserves in a subnet
+type ReservedAddress struct {
+	Address string `json:"address"`
+	Purpose string `json:"purpose"`
+}
+
+// SplitRequest asks to split a network by exactly one of Count (equal
+// subnets), Prefix (subnets of a prefix length) or Sizes (IPv4 subnets for
+// host counts)
+type SplitRequest struct {
+	Network string `json:"network"`
+	Count   int    `json:"count,omitempty"`
+	Prefix  int    `json:"prefix,omitempty"`
+	Sizes   []int  `json:"sizes,omitempty"`
+}
+
+// SplitResponse lists the subnets of a split
+type SplitResponse struct {
+	Network string   `json:"network"`
+	Subnets []Subnet `json:"subnets"`
+}
+
+// Subnet is one subnet of a split
+type Subnet struct {
+	Network   string `json:"network"`
+	Broadcast string `json:"broadcast,omitempty"`
+	HostMin   string `json:"host_min"`
+	HostMax   string `json:"host_max"`
+	Hosts     string `json:"hosts"`
+}
+
+// DeaggregateRequest asks for the networks covering an IPv4 or IPv6 address range
+type DeaggregateRequest struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+// AggregateRequest asks to merge networks into the fewest CIDR blocks.
+// Ranges and nmap target specifications are accepted too.
+type AggregateRequest struct {
+	Networks []string `json:"networks"`
+}
+
+// NetworksResponse lists networks in CIDR notation
+type NetworksResponse struct {
+	Networks []string `json:"networks"`
+}
+
+// ContainsRequest asks whether a network contains addresses or networks
+type ContainsRequest struct {
+	Network   string   `json:"network"`
+	Addresses []string `json:"addresses"`
+}
+
+// ContainsResponse answers a ContainsRequest, in the order asked
+type ContainsResponse struct {
+	Network string           `json:"network"`
+	Results []ContainsResult `json:"results"`
+}
+
+// ContainsResult is the answer for one address or network
+type ContainsResult struct {
+	Address  string `json:"address"`
+	Contains bool   `json:"contains"`
+}
+
+// ClassifyRequest asks for the special-purpose category of addresses
+type ClassifyRequest struct {
+	Addresses []string `json:"addresses"`
+}
+
+// ClassifyResponse lists the classifications, in the order asked
+type ClassifyResponse struct {
+	Results []calculator.Classification `json:"results"`
+}
+
+// splitNetwork splits a network string into address and netmask, using the
+// default netmask for the address family when none is given. Brackets and
+// ports are dropped and zone IDs kept, as calculator.ParseInput reads them.
+func splitNetwork(network, mask string) (string, string, error) {
+	if network == "" {
+		return "", "", invalidRequest("network is required")
+	}
+	in, err := calculator.ParseInput(network)
+	if err != nil {
+		return "", "", err
+	}
+	switch {
+	case in.Prefix != "" && mask != "":
+		return "", "", invalidRequest("give the netmask in either network or mask, not both")
+	case in.Prefix != "":
+		return in.Host(), in.Prefix, nil
+	case mask != "":
+		return in.Host(), mask, nil
+	case in.IPv6:
+		return in.Host(), "64", nil
+	default:
+		return in.Host(), "24", nil
+	}
+}
+
+// checkItems returns an error if a list is empty or over the item limit
+func (s *Server) checkItems(field string, count int) error {
+	if count == 0 {
+		return invalidRequest("%s is required", field)
+	}
+	if count > s.MaxItems {
+		return limitExceeded("%s has %d entries (limit is %d)", field, count, s.MaxItems)
+	}
+	return nil
+}
+
+// checkTargets is checkItems for target specifications, which may hold
+// several comma-separated targets that each expand to many ranges. The
+// ranges are counted without expanding them.
+func (s *Server) checkTargets(field string, specs []string) error {
+	if err := s.checkItems(field, len(specs)); err != nil {
+		return err
+	}
+	count, err := calculator.CountTargets(specs)
+	if err != nil {
+		return err
+	}
+	if count > s.MaxItems {
+		return limitExceeded("%s expand to %d ranges (limit is %d)", field, count, s.MaxItems)
+	}
+	return nil
+}
+
+// calculate handles POST /v1/calculate
+func (s *Server) calculate(request *CalculateRequest) (*Network, error) {
+	ipStr, maskStr, err := splitNetwork(request.Network, request.Mask)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.Contains(ipStr, ":") {
+		if request.Cloud != "" {
+			return nil, invalidRequest("cloud provider presets only support IPv4 subnets")
+		}
+		network, err := calculator.CalculateIPv6Network(ipStr, maskStr)
+		if err != nil {
+			return nil, err
+		}
+		hostMin, hostMax := calculator.IPv6HostRange(network.NetworkID, network.PrefixLen)
+		hosts := new(big.Int).Sub(hostMax, hostMin)
+		hosts.Add(hosts, big.NewInt(1))
+
+		classification, _ := calculator.Classify(calculator.IPv6ToString(network.Address))
+		return &Network{
+			Family:  "IPv6",
+			Address: calculator.IPv6ToString(network.Address),
+			Prefix:  network.PrefixLen,
+			Network: fmt.Sprintf("%s/%d", calculator.IPv6ToString(network.NetworkID), network.PrefixLen),
+			HostMin: calculator.IPv6ToString(hostMin),
+			HostMax: calculator.IPv6ToString(hostMax),
+			Hosts:   hosts.String(),
+			Private: classification != nil && classification.Category == "unique-local",
+		}, nil
+	}
+
+	network, err := calculator.CalculateNetwork(ipStr, maskStr)
+	if err != nil {
+		return nil, err
+	}
+	if request.Cloud != "" {
+		provider, err := calculator.GetCloudProvider(request.Cloud)
+		if err != nil {
+			return nil, invalidRequest("%v", err)
+		}
+		if err := calculator.ApplyCloudProvider(network, provider); err != nil {
+			return nil, err
+		}
+	}
+
+	result := &Network{
+		Family:    "IPv4",
+		Address:   calculator.IPToString(network.Address),
+		Prefix:    network.BitCount,
+		Netmask:   calculator.IPToString(network.Netmask),
+		Wildcard:  calculator.IPToString(calculator.GetWildcardMask(network.Netmask)),
+		Network:   fmt.Sprintf("%s/%d", calculator.IPToString(network.NetworkID), network.BitCount),
+		Broadcast: calculator.IPToString(network.Broadcast),
+		HostMin:   calculator.IPToString(network.HostMin),
+		HostMax:   calculator.IPToString(network.HostMax),
+		Hosts:     strconv.FormatUint(uint64(network.HostsCount), 10),
+		Class:     network.Class,
+		Private:   calculator.IsPrivate(network.Address),
+	}
+	for _, reserved := range network.Reserved {
+		result.Reserved = append(result.Reserved, ReservedAddress{
+			Address: calculator.IPToString(reserved.Address),
+			Purpose: reserved.Purpose,
+		})
+	}
+	return result, nil
+}
+
+// split handles POST /v1/split
+func (s *Server) split(request *SplitRequest) (*SplitResponse, error) {
+	ipStr, maskStr, err := splitNetwork(request.Network, "")
+	if err != nil {
+		return nil, err
+	}
+
+	modes := 0
+	for _, set := range []bool{request.Count != 0, request.Prefix != 0, len(request.Sizes) > 0} {
+		if set {
+			modes++
+		}
+	}
+	if modes != 1 {
+		return nil, invalidRequest("give exactly one of count, prefix or sizes")
+	}
+
+	var subnets []calculator.Subnet
+	switch {
+	case request.Count != 0:
+		if request.Count > s.MaxItems {
+			return nil, limitExceeded("cannot list %d subnets (limit is %d)", request.Count, s.MaxItems)
+		}
+		subnets, err = calculator.SplitNetworkByCount(ipStr, maskStr, request.Count)
+	case request.Prefix != 0:
+		_, prefix, _, parseErr := calculator.ParseNetworkAny(ipStr, maskStr)
+		if parseErr != nil {
+			return nil, parseErr
+		}
+		if extra := request.Prefix - prefix; extra >= 63 || (extra > 0 && 1<<extra > s.MaxItems) {
+			return nil, limitExceeded("splitting /%d into /%d subnets would list more than %d subnets", prefix, request.Prefix, s.MaxItems)
+		}
+		subnets, err = calculator.SplitNetworkByPrefix(ipStr, maskStr, request.Prefix)
+	default:
+		if err := s.checkItems("sizes", len(request.Sizes)); err != nil {
+			return nil, err
+		}
+		if strings.Contains(ipStr, ":") {
+			return nil, invalidRequest("splitting by sizes only supports IPv4 networks")
+		}
+		subnets, err = splitBySizes(ipStr, maskStr, request.Sizes)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(subnets) > s.MaxItems {
+		return nil, limitExceeded("cannot list %d subnets (limit is %d)", len(subnets), s.MaxItems)
+	}
+
+	response := &SplitResponse{Network: ipStr + "/" + maskStr, Subnets: []Subnet{}}
+	for _, subnet := range subnets {
+		response.Subnets = append(response.Subnets, Subnet{
+			Network:   subnet.Network,
+			Broadcast: subnet.Broadcast,
+			HostMin:   subnet.HostMin,
+			HostMax:   subnet.HostMax,
+			Hosts:     subnet.Hosts.String(),
+		})
+	}
+	return response, nil
+}
+
+// splitBySizes splits an IPv4 network into subnets for the given host counts
+func splitBySizes(ipStr, maskStr string, sizes []int) ([]calculator.Subnet, error) {
+	networks, err := calculator.SplitNetwork(ipStr, maskStr, sizes)
+	if err != nil {
+		return nil, err
+	}
+
+	var subnets []calculator.Subnet
+	for _, cidr := range networks {
+		networkStr, prefixStr, _ := strings.Cut(cidr, "/")
+		network, err := calculator.CalculateNetwork(networkStr, prefixStr)
+		if err != nil {
+			return nil, err
+		}
+		subnets = append(subnets, calculator.Subnet{
+			Network:   cidr,
+			Broadcast: calculator.IPToString(network.Broadcast),
+			HostMin:   calculator.IPToString(network.HostMin),
+			HostMax:   calculator.IPToString(network.HostMax),
+			Hosts:     new(big.Int).SetUint64(uint64(network.HostsCount)),
+		})
+	}
+	return subnets, nil
+}
+
+// deaggregate handles POST /v1/deaggregate
+func (s *Server) deaggregate(request *DeaggregateRequest) (*NetworksResponse, error) {
+	if request.Start == "" || request.End == "" {
+		return nil, invalidRequest("start and end are required")
+	}
+
+	networks, err := calculator.DeaggregateAny(request.Start, request.End)
+	if err != nil {
+		return nil, err
+	}
+	if len(networks) > s.MaxItems {
+		return nil, limitExceeded("range covers %d networks (limit is %d)", len(networks), s.MaxItems)
+	}
+	return &NetworksResponse{Networks: networks}, nil
+}
+
+// aggregate handles POST /v1/aggregate
+func (s *Server) aggregate(request *AggregateRequest) (*NetworksResponse, error) {
+	if err := s.checkTargets("networks", request.Networks); err != nil {
+		return nil, err
+	}
+
+	networks, err := calculator.Aggregate(request.Networks)
+	if err != nil {
+		return nil, err
+	}
+	if len(networks) > s.MaxItems {
+		return nil, limitExceeded("result has %d networks (limit is %d)", len(networks), s.MaxItems)
+	}
+	return &NetworksResponse{Networks: networks}, nil
+}
+
+// contains handles POST /v1/contains
+func (s *Server) contains(request *ContainsRequest) (*ContainsResponse, error) {
+	if request.Network == "" {
+		return nil, invalidRequest("network is required")
+	}
+	if err := s.checkTargets("addresses", request.Addresses); err != nil {
+		return nil, err
+	}
+
+	response := &ContainsResponse{Network: request.Network}
+	for _, address := range request.Addresses {
+		contained, err := calculator.Contains(request.Network, address)
+		if err != nil {
+			return nil, err
+		}
+		response.Results = append(response.Results, ContainsResult{Address: address, Contains: contained})
+	}
+	return response, nil
+}
+
+// classify handles POST /v1/classify
+func (s *Server) classify(request *ClassifyRequest) (*ClassifyResponse, error) {
+	if err := s.checkItems("addresses", len(request.Addresses)); err != nil {
+		return nil, err
+	}
+
+	response := &ClassifyResponse{}
+	for _, address := range request.Addresses {
+		classification, err := calculator.Classify(address)
+		if err != nil {
+			return nil, err
+		}
+		response.Results = append(response.Results, *classification)
+	}
+	return response, nil
+}
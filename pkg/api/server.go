@@ -0,0 +1,169 @@
+// Package api serves the calculator as a JSON HTTP API
+package api
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/neontowel/ipcalc-go/pkg/calculator"
+)
+
+// Default limits of a Server
+const (
+	DefaultMaxBodyBytes = 64 << 10
+	DefaultMaxItems     = 1024
+)
+
+//go:embed openapi.json
+var openAPIDocument []byte
+
+// Server holds the API configuration. The zero value is not usable; create
+// servers with NewServer.
+type Server struct {
+	MaxBodyBytes int64 // Largest accepted request body
+	MaxItems     int   // Most networks or addresses accepted or returned by one request
+}
+
+// NewServer returns a server with the default limits
+func NewServer() *Server {
+	return &Server{
+		MaxBodyBytes: DefaultMaxBodyBytes,
+		MaxItems:     DefaultMaxItems,
+	}
+}
+
+// Error is the body of every error response
+type Error struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// errorResponse wraps an Error in the response body
+type errorResponse struct {
+	Error Error `json:"error"`
+}
+
+// apiError is an error with its HTTP status and error code
+type apiError struct {
+	status int
+	code   string
+	err    error
+}
+
+func (e *apiError) Error() string {
+	return e.err.Error()
+}
+
+// invalidRequest returns an error for a request missing or misusing fields
+func invalidRequest(format string, args ...any) error {
+	return &apiError{http.StatusBadRequest, "invalid_request", fmt.Errorf(format, args...)}
+}
+
+// limitExceeded returns an error for a request over the enumeration limit
+func limitExceeded(format string, args ...any) error {
+	return &apiError{http.StatusUnprocessableEntity, "limit_exceeded", fmt.Errorf(format, args...)}
+}
+
+// Handler returns the HTTP handler serving the API
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/v1/calculate", handle(s, s.calculate))
+	mux.Handle("/v1/split", handle(s, s.split))
+	mux.Handle("/v1/deaggregate", handle(s, s.deaggregate))
+	mux.Handle("/v1/aggregate", handle(s, s.aggregate))
+	mux.Handle("/v1/contains", handle(s, s.contains))
+	mux.Handle("/v1/classify", handle(s, s.classify))
+	mux.HandleFunc("/openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		if !allowMethod(w, r, http.MethodGet) {
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(openAPIDocument)
+	})
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		if !allowMethod(w, r, http.MethodGet) {
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, &apiError{http.StatusNotFound, "not_found", fmt.Errorf("no endpoint at %s", r.URL.Path)})
+	})
+	return mux
+}
+
+// handle adapts an endpoint function to an HTTP handler that decodes the
+// JSON request body, enforces the body size limit and encodes the result
+func handle[Request, Response any](s *Server, endpoint func(*Request) (*Response, error)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !allowMethod(w, r, http.MethodPost) {
+			return
+		}
+
+		var request Request
+		decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, s.MaxBodyBytes))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&request); err != nil {
+			var tooLarge *http.MaxBytesError
+			switch {
+			case errors.As(err, &tooLarge):
+				writeError(w, &apiError{http.StatusRequestEntityTooLarge, "request_too_large",
+					fmt.Errorf("request body is larger than %d bytes", tooLarge.Limit)})
+			case errors.Is(err, io.EOF):
+				writeError(w, invalidRequest("request body is empty"))
+			default:
+				writeError(w, &apiError{http.StatusBadRequest, "invalid_json", err})
+			}
+			return
+		}
+		if decoder.More() {
+			writeError(w, &apiError{http.StatusBadRequest, "invalid_json", errors.New("request body holds more than one JSON value")})
+			return
+		}
+
+		response, err := endpoint(&request)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, response)
+	})
+}
+
+// allowMethod writes an error response unless the request uses the given method
+func allowMethod(w http.ResponseWriter, r *http.Request, method string) bool {
+	if r.Method == method {
+		return true
+	}
+	w.Header().Set("Allow", method)
+	writeError(w, &apiError{http.StatusMethodNotAllowed, "method_not_allowed",
+		fmt.Errorf("%s is not allowed; use %s", r.Method, method)})
+	return false
+}
+
+// writeError writes an error response. Errors without a status are
+// calculator errors about the input, except for those about its limits.
+func writeError(w http.ResponseWriter, err error) {
+	var apiErr *apiError
+	switch {
+	case errors.As(err, &apiErr):
+	case errors.Is(err, calculator.ErrTooManySubnets):
+		apiErr = &apiError{http.StatusUnprocessableEntity, "limit_exceeded", err}
+	default:
+		apiErr = &apiError{http.StatusBadRequest, "invalid_input", err}
+	}
+	writeJSON(w, apiErr.status, errorResponse{Error{Code: apiErr.code, Message: apiErr.err.Error()}})
+}
+
+// writeJSON writes a JSON response
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	_ = encoder.Encode(value)
+}
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandler(t *testing.T) {
+	server := NewServer()
+	server.MaxItems = 16
+	handler := server.Handler()
+
+	tests := []struct {
+		name   string
+		path   string
+		body   string
+		status int
+		code   string // error code, or "" for a success
+		want   string // part of the compacted success body
+	}{
+		{"calculate", "/v1/calculate", `{"network": "10.0.0.1/24"}`, 200, "",
+			`"network":"10.0.0.0/24","broadcast":"10.0.0.255","host_min":"10.0.0.1","host_max":"10.0.0.254","hosts":"254"`},
+		{"calculate IPv6", "/v1/calculate", `{"network": "2001:db8::1/64"}`, 200, "",
+			`"host_min":"2001:db8::1","host_max":"2001:db8::ffff:ffff:ffff:ffff","hosts":"18446744073709551615"`},
+		{"split by count", "/v1/split", `{"network": "10.0.0.0/24", "count": 2}`, 200, "",
+			`"subnets":[{"network":"10.0.0.0/25",`},
+		{"split by prefix", "/v1/split", `{"network": "10.0.0.0/24", "prefix": 28}`, 200, "",
+			`"network":"10.0.0.240/28"`},
+		{"aggregate", "/v1/aggregate", `{"networks": ["10.0.0.0/25", "10.0.0.128/25"]}`, 200, "",
+			`{"networks":["10.0.0.0/24"]}`},
+		{"contains", "/v1/contains", `{"network": "10.0.0.0/24", "addresses": ["10.0.0.5-50", "10.0.1.1"]}`, 200, "",
+			`"results":[{"address":"10.0.0.5-50","contains":true},{"address":"10.0.1.1","contains":false}]`},
+
+		// Limits are checked before the work is done
+		{"split count over limit", "/v1/split", `{"network": "10.0.0.0/8", "count": 17}`, 422, "limit_exceeded", ""},
+		{"split prefix over limit", "/v1/split", `{"network": "10.0.0.0/8", "prefix": 13}`, 422, "limit_exceeded", ""},
+		{"split huge prefix", "/v1/split", `{"network": "::/0", "prefix": 128}`, 422, "limit_exceeded", ""},
+		{"aggregate octet ranges", "/v1/aggregate", `{"networks": ["10.0-255.0-255.1"]}`, 422, "limit_exceeded", ""},
+		{"aggregate comma list", "/v1/aggregate", `{"networks": ["10.0.0.1,10.0.0.2,10.0.0.3,10.0.0.4,10.0.0.5,10.0.0.6,10.0.0.7,10.0.0.8,10.0.0.9,10.0.0.10,10.0.0.11,10.0.0.12,10.0.0.13,10.0.0.14,10.0.0.15,10.0.0.16,10.0.0.17"]}`, 422, "limit_exceeded", ""},
+		{"contains octet ranges", "/v1/contains", `{"network": "10.0.0.0/8", "addresses": ["10.0.0-16.1"]}`, 422, "limit_exceeded", ""},
+		{"deaggregate over limit", "/v1/deaggregate", `{"start": "10.0.0.1", "end": "10.255.255.254"}`, 422, "limit_exceeded", ""},
+
+		{"split without mode", "/v1/split", `{"network": "10.0.0.0/24"}`, 400, "invalid_request", ""},
+		{"split with two modes", "/v1/split", `{"network": "10.0.0.0/24", "count": 2, "prefix": 26}`, 400, "invalid_request", ""},
+		{"aggregate without networks", "/v1/aggregate", `{"networks": []}`, 400, "invalid_request", ""},
+		{"contains without network", "/v1/contains", `{"addresses": ["10.0.0.1"]}`, 400, "invalid_request", ""},
+		{"empty body", "/v1/calculate", ``, 400, "invalid_request", ""},
+		{"invalid address", "/v1/calculate", `{"network": "10.0.0.300/24"}`, 400, "invalid_input", ""},
+		{"unknown field", "/v1/calculate", `{"network": "10.0.0.1/24", "netmask": "24"}`, 400, "invalid_json", ""},
+		{"unknown endpoint", "/v1/nothing", `{}`, 404, "not_found", ""},
+	}
+
+	for _, tt := range tests {
+		request := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body))
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+
+		if recorder.Code != tt.status {
+			t.Errorf("%s: status %d, want %d: %s", tt.name, recorder.Code, tt.status, recorder.Body)
+			continue
+		}
+		if tt.code != "" {
+			var response errorResponse
+			if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil || response.Error.Code != tt.code {
+				t.Errorf("%s: error %+v, %v, want code %s", tt.name, response.Error, err, tt.code)
+			}
+			continue
+		}
+
+		var body bytes.Buffer
+		if err := json.Compact(&body, recorder.Body.Bytes()); err != nil || !strings.Contains(body.String(), tt.want) {
+			t.Errorf("%s: body %s, %v, want it to contain %s", tt.name, body.String(), err, tt.want)
+		}
+	}
+}
+
+func TestMethodNotAllowed(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	NewServer().Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/v1/calculate", nil))
+	if recorder.Code != http.StatusMethodNotAllowed || recorder.Header().Get("Allow") != http.MethodPost {
+		t.Errorf("GET /v1/calculate: status %d, Allow %q, want 405, POST", recorder.Code, recorder.Header().Get("Allow"))
+	}
+}
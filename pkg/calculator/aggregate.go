@@ -0,0 +1,86 @@
+package calculator
+
+import (
+	"math/big"
+	"strings"
+)
+
+// addressRange is an inclusive range of addresses of one width
+type addressRange struct {
+	start, end *big.Int
+	bits       int
+}
+
+// parseCIDRAny parses an IPv4 or IPv6 network in CIDR notation. An address
+// without a prefix length is a single-address network.
+func parseCIDRAny(cidr string) (*big.Int, int, int, error) {
+	ipStr, maskStr, found := strings.Cut(cidr, "/")
+	if !found {
+		maskStr = "128"
+		if _, err := ParseIPv4(ipStr); err == nil {
+			maskStr = "32"
+		}
+	}
+	return ParseNetworkAny(ipStr, maskStr)
+}
+
+// Aggregate merges networks into the smallest list of CIDR blocks covering
+// exactly the same addresses. Overlapping and adjacent networks are merged;
+// IPv4 networks are listed before IPv6 networks. Ranges and the other
+// target specifications of ParseTargets are accepted too.
+func Aggregate(networks []string) ([]string, error) {
+	if len(networks) == 0 {
+		return nil, nil
+	}
+	targets, err := ParseTargets(networks)
+	if err != nil {
+		return nil, err
+	}
+	return targets.CIDRs(), nil
+}
+
+// rangeToCIDRs returns the minimal list of CIDR blocks covering an inclusive address range
+func rangeToCIDRs(start, end *big.Int, bits int) []string {
+	var result []string
+	current := new(big.Int).Set(start)
+	for current.Cmp(end) <= 0 {
+		// Find the largest block aligned at current that ends within the range
+		prefix := bits - int(current.TrailingZeroBits())
+		if current.Sign() == 0 {
+			prefix = 0
+		}
+		for lastAddress(current, prefix, bits).Cmp(end) > 0 {
+			prefix++
+		}
+
+		result = append(result, bigToCIDR(current, prefix, bits))
+		current.Add(current, blockSize(prefix, bits))
+	}
+	return result
+}
+
+// Contains reports whether a network contains every address of other,
+// which may be an address, a network, a range or any other target
+// specification ParseTargets accepts
+func Contains(networkStr, otherStr string) (bool, error) {
+	networkID, prefix, bits, err := parseCIDRAny(networkStr)
+	if err != nil {
+		return false, err
+	}
+	other, err := ParseTargets([]string{otherStr})
+	if err != nil {
+		return false, err
+	}
+
+	last := new(big.Int).Or(networkID, hostMaskFor(prefix, bits))
+	contained := true
+	for _, r := range other.ranges {
+		if r.bits != bits {
+			return false, newError(ErrAddressFamily, "%s and %s are different address families", networkStr, otherStr)
+		}
+		if r.start.Cmp(networkID) < 0 || r.end.Cmp(last) > 0 {
+			contained = false
+		}
+	}
+	return contained, nil
+}
@@ -0,0 +1,122 @@
+package calculator
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// Next returns the network of the same size that follows this one
+func (n *IPv4Network) Next() (*IPv4Network, error) {
+	size := uint64(1) << (32 - n.BitCount)
+	next := uint64(n.NetworkID) + size
+	if next > 0xFFFFFFFF {
+		return nil, newError(ErrOutOfRange, "no network after %s/%d: end of the address space", IPToString(n.NetworkID), n.BitCount)
+	}
+	return CalculateNetwork(IPToString(uint32(next)), fmt.Sprintf("%d", n.BitCount))
+}
+
+// Previous returns the network of the same size that precedes this one
+func (n *IPv4Network) Previous() (*IPv4Network, error) {
+	size := uint64(1) << (32 - n.BitCount)
+	if uint64(n.NetworkID) < size {
+		return nil, newError(ErrOutOfRange, "no network before %s/%d: start of the address space", IPToString(n.NetworkID), n.BitCount)
+	}
+	return CalculateNetwork(IPToString(uint32(uint64(n.NetworkID)-size)), fmt.Sprintf("%d", n.BitCount))
+}
+
+// Host returns the Nth host of the network, counting from 1 at HostMin.
+// Negative indexes count back from HostMax, so -1 is the last host.
+func (n *IPv4Network) Host(index int64) (uint32, error) {
+	if index == 0 {
+		return 0, newError(ErrInvalidSize, "host index 0 is invalid: hosts are numbered from 1, or from -1 at the end")
+	}
+
+	// Count the hosts from the range, as HostsCount overflows for a /0
+	hosts := int64(n.HostMax) - int64(n.HostMin) + 1
+	if index > hosts || index < -hosts {
+		return 0, newError(ErrOutOfRange, "host index %d out of range: %s/%d has %d hosts", index, IPToString(n.NetworkID), n.BitCount, hosts)
+	}
+
+	if index > 0 {
+		return n.HostMin + uint32(index-1), nil
+	}
+	return n.HostMax - uint32(-index-1), nil
+}
+
+// AddIPv4 adds a signed offset to an IPv4 address
+func AddIPv4(ip uint32, offset int64) (uint32, error) {
+	result := int64(ip) + offset
+	if result < 0 || result > 0xFFFFFFFF {
+		return 0, newError(ErrOutOfRange, "%s %+d is outside the IPv4 address space", IPToString(ip), offset)
+	}
+	return uint32(result), nil
+}
+
+// IPv4Distance returns the number of addresses from a to b (negative if b is before a)
+func IPv4Distance(a, b uint32) int64 {
+	return int64(b) - int64(a)
+}
+
+// Next returns the network of the same size that follows this one
+func (n *IPv6Network) Next() (*IPv6Network, error) {
+	next := new(big.Int).Add(n.NetworkID, blockSize(n.PrefixLen, 128))
+	if next.Cmp(maxAddress(128)) > 0 {
+		return nil, newError(ErrOutOfRange, "no network after %s/%d: end of the address space", IPv6ToString(n.NetworkID), n.PrefixLen)
+	}
+	network, err := CalculateIPv6Network(IPv6ToString(next), fmt.Sprintf("%d", n.PrefixLen))
+	if err != nil {
+		return nil, err
+	}
+	network.Zone = n.Zone
+	return network, nil
+}
+
+// Previous returns the network of the same size that precedes this one
+func (n *IPv6Network) Previous() (*IPv6Network, error) {
+	previous := new(big.Int).Sub(n.NetworkID, blockSize(n.PrefixLen, 128))
+	if previous.Sign() < 0 {
+		return nil, newError(ErrOutOfRange, "no network before %s/%d: start of the address space", IPv6ToString(n.NetworkID), n.PrefixLen)
+	}
+	network, err := CalculateIPv6Network(IPv6ToString(previous), fmt.Sprintf("%d", n.PrefixLen))
+	if err != nil {
+		return nil, err
+	}
+	network.Zone = n.Zone
+	return network, nil
+}
+
+// Host returns the Nth host of the network, counting from 1 at the first host.
+// Negative indexes count back from the last address, so -1 is the last host.
+func (n *IPv6Network) Host(index *big.Int) (*big.Int, error) {
+	if index.Sign() == 0 {
+		return nil, newError(ErrInvalidSize, "host index 0 is invalid: hosts are numbered from 1, or from -1 at the end")
+	}
+
+	hostMin, hostMax := IPv6HostRange(n.NetworkID, n.PrefixLen)
+	hosts := new(big.Int).Sub(hostMax, hostMin)
+	hosts.Add(hosts, big.NewInt(1))
+	if new(big.Int).Abs(index).Cmp(hosts) > 0 {
+		return nil, newError(ErrOutOfRange, "host index %s out of range: %s/%d has %s hosts", index, IPv6ToString(n.NetworkID), n.PrefixLen, hosts)
+	}
+
+	if index.Sign() > 0 {
+		host := new(big.Int).Add(hostMin, index)
+		return host.Sub(host, big.NewInt(1)), nil
+	}
+	host := new(big.Int).Add(hostMax, index)
+	return host.Add(host, big.NewInt(1)), nil
+}
+
+// AddIPv6 adds a signed offset to an IPv6 address
+func AddIPv6(ip, offset *big.Int) (*big.Int, error) {
+	result := new(big.Int).Add(ip, offset)
+	if result.Sign() < 0 || result.Cmp(maxAddress(128)) > 0 {
+		return nil, newError(ErrOutOfRange, "%s %+d is outside the IPv6 address space", IPv6ToString(ip), offset)
+	}
+	return result, nil
+}
+
+// IPv6Distance returns the number of addresses from a to b (negative if b is before a)
+func IPv6Distance(a, b *big.Int) *big.Int {
+	return new(big.Int).Sub(b, a)
+}
@@ -0,0 +1,202 @@
+package calculator
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"testing"
+)
+
+func TestIPv4NextPrevious(t *testing.T) {
+	tests := []struct {
+		network, mask string
+		next, prev    string
+		nextErr       error
+		prevErr       error
+	}{
+		{"10.0.1.0", "24", "10.0.2.0", "10.0.0.0", nil, nil},
+		{"10.0.0.77", "26", "10.0.0.128", "10.0.0.0", nil, nil},
+		{"0.0.0.0", "8", "1.0.0.0", "", nil, ErrOutOfRange},
+		{"255.255.255.0", "24", "", "255.255.254.0", ErrOutOfRange, nil},
+		{"0.0.0.0", "0", "", "", ErrOutOfRange, ErrOutOfRange},
+		{"10.0.0.1", "32", "10.0.0.2", "10.0.0.0", nil, nil},
+	}
+
+	for _, tt := range tests {
+		network, err := CalculateNetwork(tt.network, tt.mask)
+		if err != nil {
+			t.Fatalf("CalculateNetwork(%q, %q) failed: %v", tt.network, tt.mask, err)
+		}
+
+		next, err := network.Next()
+		if !errors.Is(err, tt.nextErr) || (tt.nextErr != nil && err == nil) || (err == nil && IPToString(next.NetworkID) != tt.next) {
+			t.Errorf("%s/%s Next() = %v, %v, want %s, %v", tt.network, tt.mask, next, err, tt.next, tt.nextErr)
+		}
+		prev, err := network.Previous()
+		if !errors.Is(err, tt.prevErr) || (tt.prevErr != nil && err == nil) || (err == nil && IPToString(prev.NetworkID) != tt.prev) {
+			t.Errorf("%s/%s Previous() = %v, %v, want %s, %v", tt.network, tt.mask, prev, err, tt.prev, tt.prevErr)
+		}
+	}
+}
+
+func TestIPv4Host(t *testing.T) {
+	tests := []struct {
+		network, mask string
+		index         int64
+		want          string
+		err           error
+	}{
+		{"192.168.0.0", "24", 1, "192.168.0.1", nil},
+		{"192.168.0.0", "24", 10, "192.168.0.10", nil},
+		{"192.168.0.0", "24", 254, "192.168.0.254", nil},
+		{"192.168.0.0", "24", -1, "192.168.0.254", nil},
+		{"192.168.0.0", "24", -254, "192.168.0.1", nil},
+		{"10.0.0.0", "31", 2, "10.0.0.1", nil},
+		{"0.0.0.0", "0", -1, "255.255.255.254", nil},
+		{"192.168.0.0", "24", 255, "", ErrOutOfRange},
+		{"192.168.0.0", "24", -255, "", ErrOutOfRange},
+		// -index would overflow, so the range check must not negate it
+		{"192.168.0.0", "24", math.MinInt64, "", ErrOutOfRange},
+		{"192.168.0.0", "24", math.MaxInt64, "", ErrOutOfRange},
+		{"192.168.0.0", "24", 0, "", ErrInvalidSize},
+	}
+
+	for _, tt := range tests {
+		network, err := CalculateNetwork(tt.network, tt.mask)
+		if err != nil {
+			t.Fatalf("CalculateNetwork(%q, %q) failed: %v", tt.network, tt.mask, err)
+		}
+		host, err := network.Host(tt.index)
+		if !errors.Is(err, tt.err) || (tt.err != nil && err == nil) || (err == nil && IPToString(host) != tt.want) {
+			t.Errorf("%s/%s Host(%d) = %s, %v, want %s, %v", tt.network, tt.mask, tt.index, IPToString(host), err, tt.want, tt.err)
+		}
+	}
+}
+
+func TestAddIPv4(t *testing.T) {
+	tests := []struct {
+		ip     string
+		offset int64
+		want   string
+		err    error
+	}{
+		{"10.0.0.1", 255, "10.0.1.0", nil},
+		{"10.0.1.0", -1, "10.0.0.255", nil},
+		{"255.255.255.254", 1, "255.255.255.255", nil},
+		{"255.255.255.255", 1, "", ErrOutOfRange},
+		{"0.0.0.0", -1, "", ErrOutOfRange},
+	}
+
+	for _, tt := range tests {
+		ip, _ := ParseIPv4(tt.ip)
+		got, err := AddIPv4(ip, tt.offset)
+		if !errors.Is(err, tt.err) || (tt.err != nil && err == nil) || (err == nil && IPToString(got) != tt.want) {
+			t.Errorf("AddIPv4(%s, %d) = %s, %v, want %s, %v", tt.ip, tt.offset, IPToString(got), err, tt.want, tt.err)
+		}
+	}
+
+	a, _ := ParseIPv4("10.0.0.0")
+	b, _ := ParseIPv4("10.0.1.0")
+	if got := IPv4Distance(a, b); got != 256 {
+		t.Errorf("IPv4Distance(10.0.0.0, 10.0.1.0) = %d, want 256", got)
+	}
+	if got := IPv4Distance(b, a); got != -256 {
+		t.Errorf("IPv4Distance(10.0.1.0, 10.0.0.0) = %d, want -256", got)
+	}
+}
+
+func TestIPv6NextPrevious(t *testing.T) {
+	tests := []struct {
+		network, prefix string
+		next, prev      string
+		nextErr         error
+		prevErr         error
+	}{
+		{"2001:db8:0:1::", "64", "2001:db8:0:2::", "2001:db8::", nil, nil},
+		{"::", "64", "0:0:0:1::", "", nil, ErrOutOfRange},
+		{"ffff:ffff:ffff:ffff::", "64", "", "ffff:ffff:ffff:fffe::", ErrOutOfRange, nil},
+		{"::", "0", "", "", ErrOutOfRange, ErrOutOfRange},
+	}
+
+	for _, tt := range tests {
+		network, err := CalculateIPv6Network(tt.network, tt.prefix)
+		if err != nil {
+			t.Fatalf("CalculateIPv6Network(%q, %q) failed: %v", tt.network, tt.prefix, err)
+		}
+
+		next, err := network.Next()
+		if !errors.Is(err, tt.nextErr) || (tt.nextErr != nil && err == nil) || (err == nil && !sameIPv6(next.NetworkID, tt.next)) {
+			t.Errorf("%s/%s Next() = %v, %v, want %s, %v", tt.network, tt.prefix, next, err, tt.next, tt.nextErr)
+		}
+		prev, err := network.Previous()
+		if !errors.Is(err, tt.prevErr) || (tt.prevErr != nil && err == nil) || (err == nil && !sameIPv6(prev.NetworkID, tt.prev)) {
+			t.Errorf("%s/%s Previous() = %v, %v, want %s, %v", tt.network, tt.prefix, prev, err, tt.prev, tt.prevErr)
+		}
+	}
+}
+
+func TestIPv6Host(t *testing.T) {
+	tests := []struct {
+		network, prefix string
+		index           string
+		want            string
+		err             error
+	}{
+		// Hosts start above the subnet-router anycast address
+		{"2001:db8::", "64", "1", "2001:db8::1", nil},
+		{"2001:db8::", "64", "-1", "2001:db8::ffff:ffff:ffff:ffff", nil},
+		{"2001:db8::", "126", "3", "2001:db8::3", nil},
+		{"2001:db8::", "126", "-3", "2001:db8::1", nil},
+		{"2001:db8::", "127", "1", "2001:db8::", nil},
+		{"2001:db8::", "127", "2", "2001:db8::1", nil},
+		{"2001:db8::", "126", "4", "", ErrOutOfRange},
+		{"2001:db8::", "126", "-4", "", ErrOutOfRange},
+		{"2001:db8::", "64", "0", "", ErrInvalidSize},
+	}
+
+	for _, tt := range tests {
+		network, err := CalculateIPv6Network(tt.network, tt.prefix)
+		if err != nil {
+			t.Fatalf("CalculateIPv6Network(%q, %q) failed: %v", tt.network, tt.prefix, err)
+		}
+		index, _ := new(big.Int).SetString(tt.index, 10)
+		host, err := network.Host(index)
+		if !errors.Is(err, tt.err) || (tt.err != nil && err == nil) || (err == nil && !sameIPv6(host, tt.want)) {
+			t.Errorf("%s/%s Host(%s) = %v, %v, want %s, %v", tt.network, tt.prefix, tt.index, host, err, tt.want, tt.err)
+		}
+	}
+}
+
+func TestAddIPv6(t *testing.T) {
+	tests := []struct {
+		ip     string
+		offset int64
+		want   string
+		err    error
+	}{
+		{"2001:db8::ffff", 1, "2001:db8::1:0", nil},
+		{"2001:db8::1:0", -1, "2001:db8::ffff", nil},
+		{"ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff", 1, "", ErrOutOfRange},
+		{"::", -1, "", ErrOutOfRange},
+	}
+
+	for _, tt := range tests {
+		ip, _ := ParseIPv6(tt.ip)
+		got, err := AddIPv6(ip, big.NewInt(tt.offset))
+		if !errors.Is(err, tt.err) || (tt.err != nil && err == nil) || (err == nil && !sameIPv6(got, tt.want)) {
+			t.Errorf("AddIPv6(%s, %d) = %v, %v, want %s, %v", tt.ip, tt.offset, got, err, tt.want, tt.err)
+		}
+	}
+
+	a, _ := ParseIPv6("2001:db8::")
+	b, _ := ParseIPv6("2001:db9::")
+	if got := IPv6Distance(b, a); got.Cmp(new(big.Int).Lsh(big.NewInt(-1), 96)) != 0 {
+		t.Errorf("IPv6Distance(2001:db9::, 2001:db8::) = %s, want -2^96", got)
+	}
+}
+
+// sameIPv6 reports whether an address equals the parsed want
+func sameIPv6(ip *big.Int, want string) bool {
+	parsed, err := ParseIPv6(want)
+	return err == nil && ip.Cmp(parsed) == 0
+}
@@ -0,0 +1,84 @@
+package calculator
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// ParseNetworkAny parses an IPv4 or IPv6 address and netmask into a network
+// address, its prefix length and the address width in bits (32 or 128)
+func ParseNetworkAny(ipStr, maskStr string) (*big.Int, int, int, error) {
+	if ip, err := ParseIPv4(ipStr); err == nil {
+		mask, bitCount, err := ParseNetmask(maskStr)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		return new(big.Int).SetUint64(uint64(ip & mask)), bitCount, 32, nil
+	}
+
+	ip, err := ParseIPv6(ipStr)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	prefix, err := ParseIPv6Prefix(maskStr)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	networkID := new(big.Int).And(ip, prefixMask(prefix, 128))
+	return networkID, prefix, 128, nil
+}
+
+// prefixMask returns a mask with prefix leading 1 bits in an address of the given width
+func prefixMask(prefix, bits int) *big.Int {
+	mask := new(big.Int).Lsh(big.NewInt(1), uint(bits))
+	mask.Sub(mask, big.NewInt(1))
+	hostMask := hostMaskFor(prefix, bits)
+	return mask.Xor(mask, hostMask)
+}
+
+// hostMaskFor returns a mask with the (bits-prefix) trailing host bits set
+func hostMaskFor(prefix, bits int) *big.Int {
+	hostMask := new(big.Int).Lsh(big.NewInt(1), uint(bits-prefix))
+	return hostMask.Sub(hostMask, big.NewInt(1))
+}
+
+// blockSize returns the number of addresses in a network of the given prefix length
+func blockSize(prefix, bits int) *big.Int {
+	return new(big.Int).Lsh(big.NewInt(1), uint(bits-prefix))
+}
+
+// maxAddress returns the highest address of the given width
+func maxAddress(bits int) *big.Int {
+	return hostMaskFor(0, bits)
+}
+
+// lastAddress returns the last address of the network starting at networkID
+func lastAddress(networkID *big.Int, prefix, bits int) *big.Int {
+	return new(big.Int).Or(networkID, hostMaskFor(prefix, bits))
+}
+
+// IPv6HostRange returns the first and last host address of an IPv6 network.
+// The network address is the subnet-router anycast address (RFC 4291), so
+// hosts start one above it except in /127 and /128 networks.
+func IPv6HostRange(networkID *big.Int, prefix int) (*big.Int, *big.Int) {
+	hostMin := new(big.Int).Set(networkID)
+	if prefix < 127 {
+		hostMin.Add(hostMin, big.NewInt(1))
+	}
+	return hostMin, lastAddress(networkID, prefix, 128)
+}
+
+// bigToString formats an address of the given width as a string
+func bigToString(ip *big.Int, bits int) string {
+	if bits == 32 {
+		return IPToString(uint32(ip.Uint64()))
+	}
+	return IPv6ToString(ip)
+}
+
+// bigToCIDR formats a network address and prefix length in CIDR notation
+func bigToCIDR(networkID *big.Int, prefix, bits int) string {
+	return fmt.Sprintf("%s/%d", bigToString(networkID, bits), prefix)
+}
@@ -0,0 +1,107 @@
+package calculator
+
+import "net/netip"
+
+// Classification describes what an address is used for, following the IANA
+// special-purpose address registries
+type Classification struct {
+	Address     string `json:"address"`
+	Family      string `json:"family"`
+	Class       string `json:"class,omitempty"` // IPv4 only
+	Category    string `json:"category"`
+	Description string `json:"description"`
+	Range       string `json:"range,omitempty"` // The special-purpose block, if any
+	RFC         string `json:"rfc,omitempty"`
+	Global      bool   `json:"global"` // Routable on the public internet
+}
+
+// specialRange is an entry of a special-purpose address registry
+type specialRange struct {
+	prefix      netip.Prefix
+	category    string
+	description string
+	rfc         string
+	global      bool
+}
+
+// specialRanges lists the special-purpose IPv4 and IPv6 blocks
+var specialRanges = []specialRange{
+	{netip.MustParsePrefix("0.0.0.0/8"), "this-network", "\"This\" network", "RFC 791", false},
+	{netip.MustParsePrefix("10.0.0.0/8"), "private", "Private-use network", "RFC 1918", false},
+	{netip.MustParsePrefix("100.64.0.0/10"), "shared", "Shared address space (carrier-grade NAT)", "RFC 6598", false},
+	{netip.MustParsePrefix("127.0.0.0/8"), "loopback", "Loopback", "RFC 1122", false},
+	{netip.MustParsePrefix("169.254.0.0/16"), "link-local", "Link-local", "RFC 3927", false},
+	{netip.MustParsePrefix("172.16.0.0/12"), "private", "Private-use network", "RFC 1918", false},
+	{netip.MustParsePrefix("192.0.0.0/24"), "protocol", "IETF protocol assignments", "RFC 6890", false},
+	{netip.MustParsePrefix("192.0.2.0/24"), "documentation", "Documentation (TEST-NET-1)", "RFC 5737", false},
+	{netip.MustParsePrefix("192.88.99.0/24"), "deprecated", "6to4 relay anycast (deprecated)", "RFC 7526", false},
+	{netip.MustParsePrefix("192.168.0.0/16"), "private", "Private-use network", "RFC 1918", false},
+	{netip.MustParsePrefix("198.18.0.0/15"), "benchmarking", "Network device benchmarking", "RFC 2544", false},
+	{netip.MustParsePrefix("198.51.100.0/24"), "documentation", "Documentation (TEST-NET-2)", "RFC 5737", false},
+	{netip.MustParsePrefix("203.0.113.0/24"), "documentation", "Documentation (TEST-NET-3)", "RFC 5737", false},
+	{netip.MustParsePrefix("224.0.0.0/4"), "multicast", "Multicast", "RFC 5771", false},
+	{netip.MustParsePrefix("240.0.0.0/4"), "reserved", "Reserved for future use", "RFC 1112", false},
+	{netip.MustParsePrefix("255.255.255.255/32"), "broadcast", "Limited broadcast", "RFC 919", false},
+
+	{netip.MustParsePrefix("::/128"), "unspecified", "Unspecified address", "RFC 4291", false},
+	{netip.MustParsePrefix("::1/128"), "loopback", "Loopback", "RFC 4291", false},
+	{netip.MustParsePrefix("::ffff:0:0/96"), "ipv4-mapped", "IPv4-mapped address", "RFC 4291", false},
+	{netip.MustParsePrefix("64:ff9b::/96"), "translation", "IPv4/IPv6 translation (NAT64)", "RFC 6052", true},
+	{netip.MustParsePrefix("64:ff9b:1::/48"), "translation", "Local-use IPv4/IPv6 translation", "RFC 8215", false},
+	{netip.MustParsePrefix("100::/64"), "discard", "Discard-only", "RFC 6666", false},
+	{netip.MustParsePrefix("2001::/23"), "protocol", "IETF protocol assignments", "RFC 2928", false},
+	{netip.MustParsePrefix("2001::/32"), "tunnel", "Teredo", "RFC 4380", true},
+	{netip.MustParsePrefix("2001:db8::/32"), "documentation", "Documentation", "RFC 3849", false},
+	{netip.MustParsePrefix("2002::/16"), "tunnel", "6to4", "RFC 3056", true},
+	{netip.MustParsePrefix("3fff::/20"), "documentation", "Documentation", "RFC 9637", false},
+	{netip.MustParsePrefix("fc00::/7"), "unique-local", "Unique local address", "RFC 4193", false},
+	{netip.MustParsePrefix("fe80::/10"), "link-local", "Link-local unicast", "RFC 4291", false},
+	{netip.MustParsePrefix("ff00::/8"), "multicast", "Multicast", "RFC 4291", false},
+}
+
+// globalUnicastIPv6 is the block IANA allocates IPv6 unicast addresses from
+var globalUnicastIPv6 = netip.MustParsePrefix("2000::/3")
+
+// Classify returns the special-purpose category of an IPv4 or IPv6 address.
+// The most specific matching block wins; other IPv4 addresses and IPv6
+// addresses in 2000::/3 are global unicast.
+func Classify(ipStr string) (*Classification, error) {
+	addr, err := netip.ParseAddr(ipStr)
+	if err != nil || addr.Zone() != "" {
+		return nil, newParseError(ErrInvalidAddress, FieldAddress, ipStr, addressErrorPosition(ipStr), "invalid IP address: %s", ipStr)
+	}
+
+	result := &Classification{Address: addr.String(), Family: "IPv4"}
+	if addr.Is4() {
+		ip, _ := ParseIPv4(addr.String())
+		result.Class = GetClass(ip)
+	} else {
+		result.Family = "IPv6"
+	}
+
+	var best *specialRange
+	for i := range specialRanges {
+		special := &specialRanges[i]
+		if special.prefix.Contains(addr) && (best == nil || special.prefix.Bits() > best.prefix.Bits()) {
+			best = special
+		}
+	}
+
+	switch {
+	case best != nil:
+		result.Category = best.category
+		result.Description = best.description
+		result.Range = best.prefix.String()
+		result.RFC = best.rfc
+		result.Global = best.global
+	case addr.Is4() || globalUnicastIPv6.Contains(addr):
+		result.Category = "global"
+		result.Description = "Global unicast"
+		result.Global = true
+	default:
+		result.Category = "reserved"
+		result.Description = "Reserved by IETF"
+		result.RFC = "RFC 4291"
+	}
+	return result, nil
+}
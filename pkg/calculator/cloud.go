@@ -0,0 +1,137 @@
+package calculator
+
+import (
+	"sort"
+	"strings"
+)
+
+// ReservedAddress is an address a cloud provider reserves in a subnet
+type ReservedAddress struct {
+	Address uint32
+	Purpose string
+}
+
+// CloudProvider describes the IPv4 subnet rules of a cloud provider
+type CloudProvider struct {
+	Name      string
+	MinPrefix int // Prefix length of the largest allowed subnet
+	MaxPrefix int // Prefix length of the smallest allowed subnet
+	// Reserved lists the reserved addresses by offset from the network
+	// address. Negative offsets count back from the broadcast address, so
+	// -1 is the last address of the subnet.
+	Reserved []CloudReservation
+}
+
+// CloudReservation is an address reserved by offset within every subnet
+type CloudReservation struct {
+	Offset  int
+	Purpose string
+}
+
+// cloudProviders holds the built-in cloud provider presets
+var cloudProviders = map[string]CloudProvider{
+	"aws": {
+		Name:      "AWS",
+		MinPrefix: 16,
+		MaxPrefix: 28,
+		Reserved: []CloudReservation{
+			{0, "Network address"},
+			{1, "VPC router"},
+			{2, "Amazon DNS server"},
+			{3, "Reserved for future use"},
+			{-1, "Broadcast address (unsupported in a VPC)"},
+		},
+	},
+	"azure": {
+		Name:      "Azure",
+		MinPrefix: 2,
+		MaxPrefix: 29,
+		Reserved: []CloudReservation{
+			{0, "Network address"},
+			{1, "Default gateway"},
+			{2, "Azure DNS mapping"},
+			{3, "Azure DNS mapping"},
+			{-1, "Broadcast address"},
+		},
+	},
+	"gcp": {
+		Name:      "Google Cloud",
+		MinPrefix: 4,
+		MaxPrefix: 29,
+		Reserved: []CloudReservation{
+			{0, "Network address"},
+			{1, "Default gateway"},
+			{-2, "Reserved for future use"},
+			{-1, "Broadcast address"},
+		},
+	},
+}
+
+// CloudProviderNames returns the names of the built-in cloud provider presets
+func CloudProviderNames() []string {
+	var names []string
+	for name := range cloudProviders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// GetCloudProvider returns a built-in cloud provider preset by name
+func GetCloudProvider(name string) (CloudProvider, error) {
+	provider, ok := cloudProviders[strings.ToLower(name)]
+	if !ok {
+		return CloudProvider{}, newError(ErrUnknownProvider, "unknown cloud provider: %s (must be one of %s)", name, strings.Join(CloudProviderNames(), ", "))
+	}
+	return provider, nil
+}
+
+// CheckPrefix returns an error if the provider does not allow subnets of this prefix length
+func (p CloudProvider) CheckPrefix(bitCount int) error {
+	if bitCount < p.MinPrefix || bitCount > p.MaxPrefix {
+		return newError(ErrInvalidPrefix, "%s subnets must be between /%d and /%d, got /%d", p.Name, p.MinPrefix, p.MaxPrefix, bitCount)
+	}
+	return nil
+}
+
+// ReservedAt returns the addresses the provider reserves in a subnet
+func (p CloudProvider) ReservedAt(networkID, broadcast uint32) []ReservedAddress {
+	var result []ReservedAddress
+	for _, reservation := range p.Reserved {
+		address := networkID + uint32(reservation.Offset)
+		if reservation.Offset < 0 {
+			address = broadcast - uint32(-reservation.Offset-1)
+		}
+		result = append(result, ReservedAddress{Address: address, Purpose: reservation.Purpose})
+	}
+	return result
+}
+
+// ApplyCloudProvider adjusts a network for the addresses a cloud provider
+// reserves, updating HostMin, HostMax and HostsCount and listing the
+// reserved addresses in Reserved
+func ApplyCloudProvider(network *IPv4Network, provider CloudProvider) error {
+	if err := provider.CheckPrefix(network.BitCount); err != nil {
+		return err
+	}
+
+	network.Reserved = provider.ReservedAt(network.NetworkID, network.Broadcast)
+
+	// Usable hosts start after the reserved addresses at the start of the
+	// subnet and end before the ones at the end
+	reserved := make(map[uint32]bool)
+	for _, address := range network.Reserved {
+		reserved[address.Address] = true
+	}
+	network.HostMin = network.NetworkID
+	for reserved[network.HostMin] {
+		network.HostMin++
+	}
+	network.HostMax = network.Broadcast
+	for reserved[network.HostMax] {
+		network.HostMax--
+	}
+	network.HostsCount = network.Broadcast - network.NetworkID + 1 - uint32(len(reserved))
+
+	return nil
+}
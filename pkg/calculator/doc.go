@@ -0,0 +1,30 @@
+// Package calculator computes IPv4 and IPv6 networks: netmasks, host
+// ranges, splits, ranges, aggregates and address arithmetic.
+//
+// IPv4 addresses are handled as uint32 values and IPv6 addresses as
+// *big.Int values. FromPrefix and the other netip functions convert to and
+// from net/netip.
+//
+// The string-based functions, such as CalculateNetwork, CalculateIPv6Network
+// and SplitNetwork, take the address and netmask separately. The netmask
+// may be a bit count, with or without a slash, or a dotted-decimal mask.
+// Aggregate and Exclude work on lists of networks in CIDR notation.
+//
+// ParseTargets reads ranges and nmap-style targets, which expand to CIDR
+// blocks, a count or a stream of addresses. ParseInput splits input as it
+// is pasted from tools and logs into an address, zone ID, prefix and port.
+//
+// ParseInetAton reads the legacy forms the C library's inet_aton accepts
+// and says how it read them, while ParseIPv4Strict rejects those that other
+// parsers read differently.
+//
+// # Errors
+//
+// Every error wraps one of the sentinel errors such as ErrInvalidAddress,
+// ErrInvalidNetmask or ErrInsufficientSpace, so callers can test it with
+// errors.Is. Errors about unparsable input are *ParseError values, which
+// also carry the offending input, its role and the position of the first
+// invalid character.
+//
+// The messages themselves are unchanged from earlier releases.
+package calculator
@@ -0,0 +1,180 @@
+package calculator
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Sentinel errors returned by the calculator. Every error the package
+// returns wraps one of them, so callers can tell failures apart with
+// errors.Is instead of matching message text.
+var (
+	ErrInvalidAddress    = errors.New("invalid IP address")
+	ErrAddressFamily     = errors.New("wrong address family")
+	ErrInvalidNetmask    = errors.New("invalid netmask")
+	ErrInvalidPrefix     = errors.New("invalid prefix length")
+	ErrInvalidWildcard   = errors.New("invalid wildcard mask")
+	ErrInvalidRange      = errors.New("invalid address range")
+	ErrInvalidSize       = errors.New("invalid size or count")
+	ErrInsufficientSpace = errors.New("insufficient address space")
+	ErrOutOfRange        = errors.New("outside the network or address space")
+	ErrTooManySubnets    = errors.New("too many subnets")
+	ErrUnknownProvider   = errors.New("unknown cloud provider")
+	ErrAmbiguousAddress  = errors.New("ambiguous IP address")
+)
+
+// Fields of a ParseError, naming the role of the offending input
+const (
+	FieldAddress  = "address"
+	FieldNetmask  = "netmask"
+	FieldPrefix   = "prefix"
+	FieldWildcard = "wildcard"
+	FieldCIDR     = "cidr"
+	FieldPort     = "port"
+)
+
+// ParseError reports input that could not be parsed. Err is the sentinel
+// error for the failure, and Position is the byte offset in Input of the
+// first character at fault, which is len(Input) when something is missing
+// at the end.
+type ParseError struct {
+	Field    string // One of the Field constants
+	Input    string
+	Position int
+	Err      error
+	msg      string
+}
+
+// Error returns the message for the failure, such as "invalid netmask:
+// 255.0.255.0 (not contiguous)"
+func (e *ParseError) Error() string {
+	return e.msg
+}
+
+// Unwrap returns the sentinel error
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// newParseError returns a ParseError with a formatted message
+func newParseError(sentinel error, field, input string, position int, format string, args ...any) error {
+	return &ParseError{
+		Field:    field,
+		Input:    input,
+		Position: position,
+		Err:      sentinel,
+		msg:      fmt.Sprintf(format, args...),
+	}
+}
+
+// calcError is an error that is not about parsing, wrapping its sentinel
+// error while keeping its own message
+type calcError struct {
+	err error
+	msg string
+}
+
+func (e *calcError) Error() string {
+	return e.msg
+}
+
+func (e *calcError) Unwrap() error {
+	return e.err
+}
+
+// newError returns an error wrapping sentinel with a formatted message
+func newError(sentinel error, format string, args ...any) error {
+	return &calcError{err: sentinel, msg: fmt.Sprintf(format, args...)}
+}
+
+// addressErrorPosition returns the byte offset of the first character that
+// keeps input from being an IP address
+func addressErrorPosition(input string) int {
+	if strings.Contains(input, ":") {
+		return ipv6ErrorPosition(input)
+	}
+	return ipv4ErrorPosition(input, 0)
+}
+
+// ipv4ErrorPosition returns the position of the first invalid octet of a
+// dotted-decimal address, adding offset to the result
+func ipv4ErrorPosition(input string, offset int) int {
+	start := 0
+	for octet := 0; ; octet++ {
+		end := strings.IndexByte(input[start:], '.')
+		if end < 0 {
+			end = len(input)
+		} else {
+			end += start
+		}
+		if octet == 4 {
+			// A fifth octet: the dot before it is at fault
+			return offset + start - 1
+		}
+
+		field := input[start:end]
+		for i := 0; i < len(field); i++ {
+			if field[i] < '0' || field[i] > '9' {
+				return offset + start + i
+			}
+		}
+		if field == "" || len(field) > 3 || (len(field) > 1 && field[0] == '0') || atoiOctet(field) > 255 {
+			return offset + start
+		}
+
+		if end == len(input) {
+			if octet < 3 {
+				return offset + len(input)
+			}
+			return offset
+		}
+		start = end + 1
+	}
+}
+
+// atoiOctet converts up to three decimal digits
+func atoiOctet(digits string) int {
+	value := 0
+	for i := 0; i < len(digits); i++ {
+		value = value*10 + int(digits[i]-'0')
+	}
+	return value
+}
+
+// ipv6ErrorPosition returns the position of the first invalid character or
+// group of an IPv6 address
+func ipv6ErrorPosition(input string) int {
+	groupStart := 0
+	doubleColon := -1
+	for i := 0; i < len(input); i++ {
+		c := input[i]
+		switch {
+		case c == ':':
+			if i > 0 && input[i-1] == ':' {
+				if doubleColon >= 0 || (i > 1 && input[i-2] == ':') {
+					return i
+				}
+				doubleColon = i
+			}
+			groupStart = i + 1
+		case c == '.':
+			// An embedded IPv4 address ends the address
+			return ipv4ErrorPosition(input[groupStart:], groupStart)
+		case strings.IndexByte("0123456789abcdefABCDEF", c) >= 0:
+			if i-groupStart >= 4 {
+				return groupStart
+			}
+		default:
+			return i
+		}
+	}
+	if strings.HasSuffix(input, ":") && !strings.HasSuffix(input, "::") {
+		return len(input) - 1
+	}
+	if doubleColon < 0 {
+		// Too few groups
+		return len(input)
+	}
+	return 0
+}
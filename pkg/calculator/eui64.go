@@ -0,0 +1,46 @@
+package calculator
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"net/netip"
+)
+
+// EUI64 returns the modified EUI-64 interface identifier that SLAAC
+// derives from a 48-bit MAC address: ff:fe is inserted in the middle and
+// the universal/local bit is flipped (RFC 4291, appendix A). It returns nil
+// for other hardware addresses.
+func EUI64(mac net.HardwareAddr) []byte {
+	if len(mac) != 6 {
+		return nil
+	}
+	return []byte{mac[0] ^ 0x02, mac[1], mac[2], 0xFF, 0xFE, mac[3], mac[4], mac[5]}
+}
+
+// InterfaceIDHint describes how the interface identifier, the last 64 bits,
+// of an IPv6 address was most likely chosen. An identifier built from mac
+// or from another MAC address is reported as EUI-64, one with only a few
+// low bits set as manually assigned, and anything else as random, which is
+// a temporary privacy address (RFC 4941) or a stable opaque one (RFC 7217).
+// It returns "" for IPv4 addresses.
+func InterfaceIDHint(addr netip.Addr, mac net.HardwareAddr) string {
+	if !addr.Is6() || addr.Is4In6() {
+		return ""
+	}
+	raw := addr.As16()
+	id := raw[8:]
+
+	eui := EUI64(mac)
+	switch {
+	case eui != nil && bytes.Equal(id, eui):
+		return "EUI-64 from this interface's MAC, which the address reveals"
+	case id[3] == 0xFF && id[4] == 0xFE:
+		source := net.HardwareAddr{id[0] ^ 0x02, id[1], id[2], id[5], id[6], id[7]}
+		return fmt.Sprintf("EUI-64, from MAC %s", source)
+	case bytes.Equal(id[:6], make([]byte, 6)):
+		return "manually assigned"
+	default:
+		return "random: a privacy (RFC 4941) or stable opaque (RFC 7217) address"
+	}
+}
@@ -0,0 +1,143 @@
+package calculator_test
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+
+	"github.com/neontowel/ipcalc-go/pkg/calculator"
+)
+
+func ExampleFromPrefix() {
+	network, err := calculator.FromPrefix(netip.MustParsePrefix("10.1.2.3/20"))
+	if err != nil {
+		panic(err)
+	}
+	first, last := network.HostRange()
+	fmt.Println(network.Prefix(), first, last)
+
+	ipv4 := network.(*calculator.IPv4Network)
+	fmt.Println(ipv4.HostsCount, ipv4.Class)
+	// Output:
+	// 10.1.0.0/20 10.1.0.1 10.1.15.254
+	// 4094 A
+}
+
+func ExampleCalculateNetwork() {
+	network, err := calculator.CalculateNetwork("192.168.0.1", "255.255.255.0")
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(calculator.IPToString(network.Broadcast))
+	// Output: 192.168.0.255
+}
+
+func ExampleCalculateIPv6Network() {
+	network, err := calculator.CalculateIPv6Network("2001:db8::1", "64")
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(network.Prefix())
+	// Output: 2001:db8::/64
+}
+
+func ExampleSplitNetwork() {
+	subnets, err := calculator.SplitNetwork("192.168.0.0", "24", []int{100, 50, 20})
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(subnets)
+	// Output: [192.168.0.0/25 192.168.0.128/26 192.168.0.192/27]
+}
+
+func ExampleAggregate() {
+	merged, err := calculator.Aggregate([]string{"10.0.0.0/25", "10.0.0.128/25"})
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(merged)
+	// Output: [10.0.0.0/24]
+}
+
+func ExampleExclude() {
+	rest, err := calculator.Exclude([]string{"10.0.0.0/24"}, []string{"10.0.0.64/26"})
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(rest)
+	// Output: [10.0.0.0/26 10.0.0.128/25]
+}
+
+func ExampleParseTargets() {
+	targets, err := calculator.ParseTargets([]string{"10.0.1-3.1-254"})
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(targets.Count(), len(targets.CIDRs()))
+
+	listed := 0
+	for addr := range targets.Addresses() {
+		if listed == 3 {
+			break
+		}
+		fmt.Println(addr)
+		listed++
+	}
+	// Output:
+	// 762 42
+	// 10.0.1.1
+	// 10.0.1.2
+	// 10.0.1.3
+}
+
+func ExampleParseInput() {
+	in, err := calculator.ParseInput("[fe80::1%eth0]:22")
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(in.Address, in.Zone, in.Port, in.IPv6)
+
+	network, err := calculator.CalculateIPv6Network(in.Host(), "64")
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(network.Addr())
+	// Output:
+	// fe80::1 eth0 22 true
+	// fe80::1%eth0
+}
+
+func ExampleParseInetAton() {
+	ip, notes, err := calculator.ParseInetAton("0x7f.1")
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(calculator.IPToString(ip), notes)
+	// Output: 127.0.0.1 [a.b form: last part fills the low 24 bits! hexadecimal parts interpreted!]
+}
+
+func ExampleParseIPv4Strict() {
+	_, err := calculator.ParseIPv4Strict("010.0.0.1")
+	fmt.Println(errors.Is(err, calculator.ErrAmbiguousAddress))
+	fmt.Println(err)
+	// Output:
+	// true
+	// ambiguous IP address: 010.0.0.1 (010 has a leading zero: inet_aton reads it as octal 8, but it is 10 in decimal)
+}
+
+func ExampleParseError() {
+	_, err := calculator.CalculateNetwork("10.0.0.1", "255.0.255.0")
+	fmt.Println(errors.Is(err, calculator.ErrInvalidNetmask))
+
+	var parseErr *calculator.ParseError
+	if errors.As(err, &parseErr) {
+		fmt.Println(parseErr.Field, parseErr.Input, parseErr.Position)
+	}
+
+	_, err = calculator.SplitNetwork("10.0.0.0", "24", []int{200, 100})
+	fmt.Println(errors.Is(err, calculator.ErrInsufficientSpace))
+	// Output:
+	// true
+	// netmask 255.0.255.0 6
+	// true
+}
@@ -0,0 +1,193 @@
+package calculator
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// inetAtonPart is one dot-separated part of an inet_aton address
+type inetAtonPart struct {
+	text   string
+	offset int // Position of the part in the input
+	value  uint64
+	base   int
+}
+
+// ParseInetAton parses an IPv4 address the way the C library's inet_aton
+// does, accepting the legacy forms that dotted-decimal parsers reject:
+//
+//   - a, a.b and a.b.c, where the last part fills the remaining 32, 24 or
+//     16 bits, so 10.1 is 10.0.0.1 and 127.1 is 127.0.0.1
+//   - parts with a 0x prefix, read as hexadecimal, such as 0x7f.1
+//   - parts with a leading zero, read as octal, so 010.001.001.001 is
+//     8.1.1.1
+//
+// Alongside the address it returns notes on how the input was
+// interpreted, such as "octal octets interpreted!", which are empty for
+// plain dotted decimal.
+func ParseInetAton(s string) (uint32, []string, error) {
+	parts, err := splitInetAton(s)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	// Every part but the last is one octet; the last fills the rest
+	var ip uint32
+	last := parts[len(parts)-1]
+	lastBits := 8 * (5 - len(parts))
+	for i, part := range parts[:len(parts)-1] {
+		if part.value > 0xFF {
+			return 0, nil, newParseError(ErrInvalidAddress, FieldAddress, s, part.offset, "invalid IP address: %s (part %s is larger than 255)", s, part.text)
+		}
+		ip |= uint32(part.value) << (24 - 8*i)
+	}
+	if last.value >= 1<<lastBits {
+		return 0, nil, newParseError(ErrInvalidAddress, FieldAddress, s, last.offset, "invalid IP address: %s (last part %s does not fit in %d bits)", s, last.text, lastBits)
+	}
+	ip |= uint32(last.value)
+
+	noun := "octets"
+	switch len(parts) {
+	case 1:
+		noun = "number"
+	case 2, 3:
+		noun = "parts"
+	}
+	var notes []string
+	switch len(parts) {
+	case 1:
+		notes = append(notes, "single 32-bit number interpreted!")
+	case 2:
+		notes = append(notes, "a.b form: last part fills the low 24 bits!")
+	case 3:
+		notes = append(notes, "a.b.c form: last part fills the low 16 bits!")
+	}
+	if hasInetAtonBase(parts, 8) {
+		notes = append(notes, fmt.Sprintf("octal %s interpreted!", noun))
+	}
+	if hasInetAtonBase(parts, 16) {
+		notes = append(notes, fmt.Sprintf("hexadecimal %s interpreted!", noun))
+	}
+	return ip, notes, nil
+}
+
+// ParseIPv4Strict parses an IPv4 address like ParseInetAton, but rejects
+// input that inet_aton and dotted-decimal parsers read differently with an
+// error wrapping ErrAmbiguousAddress that explains both readings. That is
+// shorthand with fewer than four parts, which some tools expand as 10.1.0.0
+// rather than 10.0.0.1, and octets whose leading zero makes them octal.
+// Plain numbers and hexadecimal parts read the same everywhere and are
+// accepted.
+func ParseIPv4Strict(s string) (uint32, error) {
+	parts, err := splitInetAton(s)
+	if err != nil {
+		return 0, err
+	}
+	ip, _, err := ParseInetAton(s)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(parts) == 2 || len(parts) == 3 {
+		last := parts[len(parts)-1]
+		if last.value > 0xFF {
+			return 0, newParseError(ErrAmbiguousAddress, FieldAddress, s, last.offset,
+				"ambiguous IP address: %s (inet_aton reads it as %s, but it has fewer than four parts)",
+				s, IPToString(ip))
+		}
+		return 0, newParseError(ErrAmbiguousAddress, FieldAddress, s, last.offset,
+			"ambiguous IP address: %s (inet_aton reads it as %s, but it is often meant as %s)",
+			s, IPToString(ip), IPToString(padInetAton(parts)))
+	}
+	for _, part := range parts {
+		if part.base != 8 {
+			continue
+		}
+		decimal, err := strconv.ParseUint(part.text, 10, 32)
+		if err == nil && decimal == part.value {
+			continue
+		}
+		reading := "is not a decimal number"
+		if err == nil {
+			reading = fmt.Sprintf("is %d in decimal", decimal)
+		}
+		return 0, newParseError(ErrAmbiguousAddress, FieldAddress, s, part.offset,
+			"ambiguous IP address: %s (%s has a leading zero: inet_aton reads it as octal %d, but it %s)",
+			s, part.text, part.value, reading)
+	}
+	return ip, nil
+}
+
+// splitInetAton splits an inet_aton address into its parts and reads the
+// value of each
+func splitInetAton(s string) ([]inetAtonPart, error) {
+	fields := strings.Split(s, ".")
+	if len(fields) > 4 {
+		offset := len(strings.Join(fields[:4], "."))
+		return nil, newParseError(ErrInvalidAddress, FieldAddress, s, offset, "invalid IP address: %s (more than four parts)", s)
+	}
+
+	parts := make([]inetAtonPart, len(fields))
+	offset := 0
+	for i, field := range fields {
+		part := inetAtonPart{text: field, offset: offset, base: 10}
+		digits := field
+		switch {
+		case len(field) > 1 && (field[:2] == "0x" || field[:2] == "0X"):
+			part.base = 16
+			digits = field[2:]
+		case len(field) > 1 && field[0] == '0':
+			part.base = 8
+			digits = field[1:]
+		}
+
+		if digits == "" {
+			return nil, newParseError(ErrInvalidAddress, FieldAddress, s, offset+len(field), "invalid IP address: %s (empty part)", s)
+		}
+		if bad := strings.IndexFunc(digits, func(r rune) bool { return !isDigitInBase(r, part.base) }); bad >= 0 {
+			return nil, newParseError(ErrInvalidAddress, FieldAddress, s, offset+len(field)-len(digits)+bad, "invalid IP address: %s", s)
+		}
+		value, err := strconv.ParseUint(digits, part.base, 32)
+		if err != nil {
+			return nil, newParseError(ErrInvalidAddress, FieldAddress, s, offset, "invalid IP address: %s (part %s is larger than 32 bits)", s, field)
+		}
+		part.value = value
+
+		parts[i] = part
+		offset += len(field) + 1
+	}
+	return parts, nil
+}
+
+// isDigitInBase reports whether r is a digit in base 8, 10 or 16
+func isDigitInBase(r rune, base int) bool {
+	switch base {
+	case 8:
+		return r >= '0' && r <= '7'
+	case 16:
+		return strings.ContainsRune("0123456789abcdefABCDEF", r)
+	default:
+		return r >= '0' && r <= '9'
+	}
+}
+
+// hasInetAtonBase reports whether any part of an address is in the given base
+func hasInetAtonBase(parts []inetAtonPart, base int) bool {
+	for _, part := range parts {
+		if part.base == base {
+			return true
+		}
+	}
+	return false
+}
+
+// padInetAton returns the address that shorthand stands for when its parts
+// are read as the leading octets, with zeros filled in at the end
+func padInetAton(parts []inetAtonPart) uint32 {
+	var ip uint32
+	for i, part := range parts {
+		ip |= uint32(part.value) << (24 - 8*i)
+	}
+	return ip
+}
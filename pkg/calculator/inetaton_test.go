@@ -0,0 +1,112 @@
+package calculator
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestParseInetAton(t *testing.T) {
+	tests := []struct {
+		input    string
+		want     string
+		notes    []string
+		position int // of a parse error
+		err      error
+	}{
+		{input: "192.168.1.1", want: "192.168.1.1"},
+		{input: "3232235777", want: "192.168.1.1", notes: []string{"single 32-bit number interpreted!"}},
+		{input: "0xC0A80101", want: "192.168.1.1", notes: []string{"single 32-bit number interpreted!", "hexadecimal number interpreted!"}},
+		{input: "127.1", want: "127.0.0.1", notes: []string{"a.b form: last part fills the low 24 bits!"}},
+		{input: "10.1.258", want: "10.1.1.2", notes: []string{"a.b.c form: last part fills the low 16 bits!"}},
+		{input: "0x7f.1", want: "127.0.0.1", notes: []string{"a.b form: last part fills the low 24 bits!", "hexadecimal parts interpreted!"}},
+		{input: "010.001.001.001", want: "8.1.1.1", notes: []string{"octal octets interpreted!"}},
+		{input: "0300.0250.0001.0001", want: "192.168.1.1", notes: []string{"octal octets interpreted!"}},
+		{input: "0xC0.0250.1.1", want: "192.168.1.1", notes: []string{"octal octets interpreted!", "hexadecimal octets interpreted!"}},
+		{input: "0.0.0.0", want: "0.0.0.0"},
+		{input: "256.1.1.1", position: 0, err: ErrInvalidAddress},
+		{input: "1.1.1.256", position: 6, err: ErrInvalidAddress},
+		{input: "10.16777216", position: 3, err: ErrInvalidAddress},
+		{input: "4294967296", position: 0, err: ErrInvalidAddress},
+		{input: "1.2.3.4.5", position: 7, err: ErrInvalidAddress},
+		{input: "1..2", position: 2, err: ErrInvalidAddress},
+		{input: "08.1.1.1", position: 1, err: ErrInvalidAddress},
+		{input: "0x.1.1.1", position: 2, err: ErrInvalidAddress},
+		{input: "10.0.0.1a", position: 8, err: ErrInvalidAddress},
+		{input: "", position: 0, err: ErrInvalidAddress},
+	}
+
+	for _, tt := range tests {
+		ip, notes, err := ParseInetAton(tt.input)
+		if tt.err != nil {
+			var parseErr *ParseError
+			if !errors.Is(err, tt.err) || !errors.As(err, &parseErr) || parseErr.Position != tt.position {
+				t.Errorf("ParseInetAton(%q) = %s, %v, want %v at %d", tt.input, IPToString(ip), err, tt.err, tt.position)
+			}
+			continue
+		}
+		if err != nil || IPToString(ip) != tt.want || !slices.Equal(notes, tt.notes) {
+			t.Errorf("ParseInetAton(%q) = %s, %q, %v, want %s, %q", tt.input, IPToString(ip), notes, err, tt.want, tt.notes)
+		}
+	}
+}
+
+func TestParseIPv4Strict(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+		err   error
+		msg   string
+	}{
+		{input: "192.168.1.1", want: "192.168.1.1"},
+		{input: "3232235777", want: "192.168.1.1"},
+		{input: "0xC0.0xA8.1.1", want: "192.168.1.1"},
+		// A leading zero is harmless when octal and decimal agree
+		{input: "01.02.03.04", want: "1.2.3.4"},
+		{input: "0.0.0.0", want: "0.0.0.0"},
+		{
+			input: "010.0.0.1", err: ErrAmbiguousAddress,
+			msg: "ambiguous IP address: 010.0.0.1 (010 has a leading zero: inet_aton reads it as octal 8, but it is 10 in decimal)",
+		},
+		{
+			input: "10.0.0.010", err: ErrAmbiguousAddress,
+			msg: "ambiguous IP address: 10.0.0.010 (010 has a leading zero: inet_aton reads it as octal 8, but it is 10 in decimal)",
+		},
+		{
+			input: "10.1", err: ErrAmbiguousAddress,
+			msg: "ambiguous IP address: 10.1 (inet_aton reads it as 10.0.0.1, but it is often meant as 10.1.0.0)",
+		},
+		{
+			input: "192.168.1", err: ErrAmbiguousAddress,
+			msg: "ambiguous IP address: 192.168.1 (inet_aton reads it as 192.168.0.1, but it is often meant as 192.168.1.0)",
+		},
+		{
+			input: "10.65536", err: ErrAmbiguousAddress,
+			msg: "ambiguous IP address: 10.65536 (inet_aton reads it as 10.1.0.0, but it has fewer than four parts)",
+		},
+		{input: "256.0.0.1", err: ErrInvalidAddress, msg: "invalid IP address: 256.0.0.1 (part 256 is larger than 255)"},
+		{input: "09.0.0.1", err: ErrInvalidAddress, msg: "invalid IP address: 09.0.0.1"},
+	}
+
+	for _, tt := range tests {
+		ip, err := ParseIPv4Strict(tt.input)
+		if tt.err != nil {
+			if !errors.Is(err, tt.err) || err.Error() != tt.msg {
+				t.Errorf("ParseIPv4Strict(%q) = %s, %v, want %q", tt.input, IPToString(ip), err, tt.msg)
+			}
+			continue
+		}
+		if err != nil || IPToString(ip) != tt.want {
+			t.Errorf("ParseIPv4Strict(%q) = %s, %v, want %s", tt.input, IPToString(ip), err, tt.want)
+		}
+	}
+}
+
+func TestFormatOctalInetAton(t *testing.T) {
+	for _, s := range []string{"0.0.0.0", "10.0.0.1", "192.168.1.1", "255.255.255.255"} {
+		ip, _ := ParseIPv4(s)
+		if got, _, err := ParseInetAton(FormatOctal(ip)); err != nil || got != ip {
+			t.Errorf("ParseInetAton(%q) = %s, %v, want %s", FormatOctal(ip), IPToString(got), err, s)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package calculator
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Input is an address or network as written on a command line, in a
+// config file or in the output of tools such as ss, split into its parts
+// by ParseInput
+type Input struct {
+	Address string // Address without brackets, zone or port
+	Zone    string // Zone ID of an IPv6 address, such as eth0, if any
+	Prefix  string // Prefix length or netmask after a slash, if any
+	Port    string // Port after the address, if any
+	IPv6    bool   // Whether Address is written as an IPv6 address
+}
+
+// ParseInput splits an address or network into its parts. It accepts:
+//
+//   - an address with an optional prefix, such as 192.168.1.10/24 or
+//     2001:db8::1/64
+//   - an IPv6 address with a zone ID, such as fe80::1%eth0/64
+//   - an IPv4 address with a port, such as 192.168.1.10:8080
+//   - an IPv6 address in brackets, with an optional port, such as
+//     [2001:db8::1]:443 or [fe80::1%eth0]:22
+//
+// An address is IPv6 when it contains a colon once any port is removed, so
+// IPv4 host:port input is no longer mistaken for IPv6. The address itself
+// is not validated; the Calculate functions report invalid addresses.
+func ParseInput(s string) (Input, error) {
+	var in Input
+	host := s
+	offset := 0 // Position of host in s
+
+	if rest, found := strings.CutPrefix(s, "["); found {
+		end := strings.IndexByte(rest, ']')
+		if end < 0 {
+			return Input{}, newParseError(ErrInvalidAddress, FieldAddress, s, len(s), "invalid IP address: %s (missing ])", s)
+		}
+		host = rest[:end]
+		offset = 1
+
+		after := rest[end+1:]
+		switch {
+		case after == "":
+		case after[0] == ':':
+			in.Port = after[1:]
+		case after[0] == '/':
+			in.Prefix = after[1:]
+		default:
+			return Input{}, newParseError(ErrInvalidAddress, FieldAddress, s, end+2, "invalid IP address: %s (unexpected text after ])", s)
+		}
+	}
+
+	// A prefix follows the address and zone, inside or outside brackets
+	if address, prefix, found := strings.Cut(host, "/"); found {
+		if in.Prefix != "" {
+			return Input{}, newParseError(ErrInvalidPrefix, FieldPrefix, s, offset+len(address), "invalid prefix length: %s (given twice)", s)
+		}
+		host, in.Prefix = address, prefix
+	}
+
+	// IPv6 addresses have at least two colons, so a single colon without
+	// brackets separates an IPv4 address from its port
+	if offset == 0 && strings.Count(host, ":") == 1 {
+		address, port, _ := strings.Cut(host, ":")
+		host, in.Port = address, port
+		if in.Port == "" {
+			return Input{}, newParseError(ErrInvalidAddress, FieldPort, s, len(address)+1, "invalid port: %s (missing after :)", s)
+		}
+	}
+
+	if address, zone, found := strings.Cut(host, "%"); found {
+		if zone == "" {
+			return Input{}, newParseError(ErrInvalidAddress, FieldAddress, s, offset+len(host), "invalid IP address: %s (empty zone ID)", s)
+		}
+		host, in.Zone = address, zone
+	}
+
+	in.Address = host
+	in.IPv6 = strings.Contains(host, ":")
+	if in.Zone != "" && !in.IPv6 {
+		return Input{}, newParseError(ErrAddressFamily, FieldAddress, s, offset+len(host), "invalid IP address: %s (zone IDs are only valid for IPv6 addresses)", s)
+	}
+
+	// ss writes * for any port
+	if in.Port != "" && in.Port != "*" {
+		port, err := strconv.ParseUint(in.Port, 10, 16)
+		if err != nil {
+			position := strings.LastIndex(s, ":") + 1
+			return Input{}, newParseError(ErrInvalidAddress, FieldPort, s, position, "invalid port: %s (must be between 0 and 65535)", in.Port)
+		}
+		in.Port = strconv.FormatUint(port, 10)
+	}
+	return in, nil
+}
+
+// Host returns the address with its zone ID, such as fe80::1%eth0, as the
+// Calculate functions accept it
+func (in Input) Host() string {
+	if in.Zone == "" {
+		return in.Address
+	}
+	return in.Address + "%" + in.Zone
+}
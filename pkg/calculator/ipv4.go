@@ -1,8 +1,8 @@
 package calculator
 
 import (
-	"errors"
 	"fmt"
+	"math/bits"
 	"net"
 	"strconv"
 	"strings"
@@ -19,35 +19,47 @@ type IPv4Network struct {
 	HostMax    uint32
 	HostsCount uint32
 	Class      string
+	Reserved   []ReservedAddress // Addresses reserved by a cloud provider, if any
 }
 
-// ParseIPv4 parses an IPv4 address string into a uint32
+// ParseIPv4 parses an IPv4 address string into a uint32. Besides dotted
+// decimal it accepts the numeric forms of ParseNumericAddress.
 func ParseIPv4(ipStr string) (uint32, error) {
 	ip := net.ParseIP(ipStr)
 	if ip == nil {
-		return 0, fmt.Errorf("invalid IP address: %s", ipStr)
+		if addr, err := ParseNumericAddress(ipStr); err == nil {
+			if !addr.Is4() {
+				return 0, newParseError(ErrAddressFamily, FieldAddress, ipStr, 0, "not an IPv4 address: %s", ipStr)
+			}
+			return IPv4FromAddr(addr)
+		}
+		return 0, newParseError(ErrInvalidAddress, FieldAddress, ipStr, addressErrorPosition(ipStr), "invalid IP address: %s", ipStr)
 	}
 	
 	// Ensure it's an IPv4 address
 	ip = ip.To4()
 	if ip == nil {
-		return 0, fmt.Errorf("not an IPv4 address: %s", ipStr)
+		return 0, newParseError(ErrAddressFamily, FieldAddress, ipStr, 0, "not an IPv4 address: %s", ipStr)
 	}
 	
 	return uint32(ip[0])<<24 | uint32(ip[1])<<16 | uint32(ip[2])<<8 | uint32(ip[3]), nil
 }
 
 // ParseNetmask parses a netmask string into a uint32 and bit count
-// It accepts CIDR notation (e.g., "24" or "/24") or dotted decimal (e.g., "255.255.255.0")
+// It accepts CIDR notation (e.g., "24" or "/24"), dotted decimal (e.g., "255.255.255.0")
+// or hexadecimal as BSD ifconfig prints it (e.g., "0xffffff00")
 func ParseNetmask(maskStr string) (uint32, int, error) {
-	// Remove leading slash if present
+	// Remove leading slash if present, keeping the input for errors
+	input := maskStr
+	offset := len(maskStr)
 	maskStr = strings.TrimPrefix(maskStr, "/")
+	offset -= len(maskStr)
 	
 	// Try to parse as CIDR bit count
 	bitCount, err := strconv.Atoi(maskStr)
 	if err == nil {
 		if bitCount < 0 || bitCount > 32 {
-			return 0, 0, fmt.Errorf("invalid bit count: %d (must be between 0 and 32)", bitCount)
+			return 0, 0, newParseError(ErrInvalidNetmask, FieldNetmask, input, offset, "invalid bit count: %d (must be between 0 and 32)", bitCount)
 		}
 		
 		// Calculate the netmask from the bit count
@@ -59,15 +71,29 @@ func ParseNetmask(maskStr string) (uint32, int, error) {
 		return mask, bitCount, nil
 	}
 	
-	// Try to parse as dotted decimal
-	mask, err := ParseIPv4(maskStr)
-	if err != nil {
-		return 0, 0, fmt.Errorf("invalid netmask: %s", maskStr)
+	// Try to parse as hexadecimal, then as dotted decimal
+	var mask uint32
+	digits, isHex := strings.CutPrefix(strings.ToLower(maskStr), "0x")
+	if isHex {
+		value, err := strconv.ParseUint(digits, 16, 32)
+		if err != nil || len(digits) != 8 {
+			return 0, 0, newParseError(ErrInvalidNetmask, FieldNetmask, input, offset, "invalid netmask: %s (hexadecimal masks have 8 digits)", maskStr)
+		}
+		mask = uint32(value)
+	} else {
+		mask, err = ParseIPv4(maskStr)
+		if err != nil {
+			return 0, 0, newParseError(ErrInvalidNetmask, FieldNetmask, input, offset+addressErrorPosition(maskStr), "invalid netmask: %s", maskStr)
+		}
 	}
 	
 	// Validate the netmask (must be contiguous 1s followed by contiguous 0s)
 	if !isValidNetmask(mask) {
-		return 0, 0, fmt.Errorf("invalid netmask: %s (not contiguous)", maskStr)
+		position := netmaskErrorPosition(maskStr, mask)
+		if isHex {
+			position = hexNetmaskErrorPosition(mask)
+		}
+		return 0, 0, newParseError(ErrInvalidNetmask, FieldNetmask, input, offset+position, "invalid netmask: %s (not contiguous)", maskStr)
 	}
 	
 	// Count the bits
@@ -83,6 +109,32 @@ func ParseNetmask(maskStr string) (uint32, int, error) {
 	return mask, bitCount, nil
 }
 
+// hexNetmaskErrorPosition returns the position in a hexadecimal netmask,
+// after its 0x prefix, of the digit holding the first 1 bit after a 0 bit
+func hexNetmaskErrorPosition(mask uint32) int {
+	firstZero := bits.LeadingZeros32(^mask)
+	stray := mask & (uint32(0xFFFFFFFF) >> firstZero)
+	return 2 + bits.LeadingZeros32(stray)/4
+}
+
+// netmaskErrorPosition returns the position in a dotted-decimal netmask of
+// the octet holding the first 1 bit after a 0 bit
+func netmaskErrorPosition(maskStr string, mask uint32) int {
+	firstZero := bits.LeadingZeros32(^mask)
+	stray := mask & (uint32(0xFFFFFFFF) >> firstZero)
+	octet := bits.LeadingZeros32(stray) / 8
+
+	position := 0
+	for ; octet > 0; octet-- {
+		dot := strings.IndexByte(maskStr[position:], '.')
+		if dot < 0 {
+			return 0
+		}
+		position += dot + 1
+	}
+	return position
+}
+
 // isValidNetmask checks if a netmask is valid (contiguous 1s followed by contiguous 0s)
 func isValidNetmask(mask uint32) bool {
 	// Find the first 0 bit
@@ -222,7 +274,7 @@ func Deaggregate(startStr, endStr string) ([]string, error) {
 	}
 	
 	if start > end {
-		return nil, errors.New("start address must be less than or equal to end address")
+		return nil, newError(ErrInvalidRange, "start address must be less than or equal to end address")
 	}
 	
 	var result []string
@@ -266,7 +318,7 @@ func SplitNetwork(networkStr, maskStr string, sizes []int) ([]string, error) {
 	// Calculate total hosts in the network
 	// Ensure network.BitCount is within safe range before conversion
 	if network.BitCount < 0 || network.BitCount > 32 {
-		return nil, fmt.Errorf("invalid bit count: %d, must be between 0 and 32", network.BitCount)
+		return nil, newError(ErrInvalidNetmask, "invalid bit count: %d, must be between 0 and 32", network.BitCount)
 	}
 	totalHosts := uint32(1) << (32 - uint32(network.BitCount))
 	
@@ -281,7 +333,7 @@ func SplitNetwork(networkStr, maskStr string, sizes []int) ([]string, error) {
 		// Ensure size is valid before the comparison
 		safeSize := size
 		if safeSize < 0 {
-			return nil, fmt.Errorf("subnet size cannot be negative: %d", safeSize)
+			return nil, newError(ErrInvalidSize, "subnet size cannot be negative: %d", safeSize)
 		}
 		
 		// Use a completely safe comparison approach without unsafe conversions
@@ -291,7 +343,7 @@ func SplitNetwork(networkStr, maskStr string, sizes []int) ([]string, error) {
 			for int(hostsNeeded)-2 < safeSize {
 				// Check for potential overflow before multiplying
 				if hostsNeeded > (1<<31) {
-					return nil, fmt.Errorf("required hosts calculation would overflow: %d", hostsNeeded)
+					return nil, newError(ErrInsufficientSpace, "required hosts calculation would overflow: %d", hostsNeeded)
 				}
 				hostsNeeded *= 2
 			}
@@ -302,7 +354,7 @@ func SplitNetwork(networkStr, maskStr string, sizes []int) ([]string, error) {
 	}
 	
 	if totalRequired > totalHosts {
-		return nil, fmt.Errorf("requested subnet sizes exceed available space (%d > %d)", totalRequired, totalHosts)
+		return nil, newError(ErrInsufficientSpace, "requested subnet sizes exceed available space (%d > %d)", totalRequired, totalHosts)
 	}
 	
 	// Allocate subnets
@@ -14,19 +14,25 @@ type IPv6Network struct {
 	PrefixLen   int
 	NetworkID   *big.Int
 	NetworkMask *big.Int
+	Zone        string // Zone ID of the address, such as eth0, if any
 }
 
-// ParseIPv6 parses an IPv6 address string into a big.Int
+// ParseIPv6 parses an IPv6 address string into a big.Int. Besides the
+// usual notation it accepts integers too large for IPv4, as described by
+// ParseNumericAddress.
 func ParseIPv6(ipStr string) (*big.Int, error) {
 	ip := net.ParseIP(ipStr)
 	if ip == nil {
-		return nil, fmt.Errorf("invalid IP address: %s", ipStr)
+		if addr, err := ParseNumericAddress(ipStr); err == nil && addr.Is6() {
+			return IPv6FromAddr(addr)
+		}
+		return nil, newParseError(ErrInvalidAddress, FieldAddress, ipStr, addressErrorPosition(ipStr), "invalid IP address: %s", ipStr)
 	}
 	
 	// Ensure it's an IPv6 address
 	ip = ip.To16()
 	if ip == nil {
-		return nil, fmt.Errorf("not an IPv6 address: %s", ipStr)
+		return nil, newParseError(ErrAddressFamily, FieldAddress, ipStr, 0, "not an IPv6 address: %s", ipStr)
 	}
 	
 	// Convert to big.Int
@@ -38,18 +44,25 @@ func ParseIPv6(ipStr string) (*big.Int, error) {
 
 // ParseIPv6Prefix parses an IPv6 prefix length
 func ParseIPv6Prefix(prefixStr string) (int, error) {
-	// Remove leading slash if present
+	// Remove leading slash if present, keeping the input for errors
+	input := prefixStr
+	offset := len(prefixStr)
 	prefixStr = strings.TrimPrefix(prefixStr, "/")
+	offset -= len(prefixStr)
 	
 	// Parse as integer
 	prefix, err := strconv.Atoi(prefixStr)
 	if err != nil {
-		return 0, fmt.Errorf("invalid prefix length: %s", prefixStr)
+		position := strings.IndexFunc(prefixStr, func(r rune) bool { return r < '0' || r > '9' })
+		if position < 0 {
+			position = 0
+		}
+		return 0, newParseError(ErrInvalidPrefix, FieldPrefix, input, offset+position, "invalid prefix length: %s", prefixStr)
 	}
 	
 	// Validate range
 	if prefix < 0 || prefix > 128 {
-		return 0, fmt.Errorf("invalid prefix length: %d (must be between 0 and 128)", prefix)
+		return 0, newParseError(ErrInvalidPrefix, FieldPrefix, input, offset, "invalid prefix length: %d (must be between 0 and 128)", prefix)
 	}
 	
 	return prefix, nil
@@ -64,17 +77,17 @@ func IPv6ToNetworkID(ip *big.Int, prefixLen int) (*big.Int, error) {
 	
 	// Shift left to remove the host bits
 	if prefixLen < 0 {
-		return nil, fmt.Errorf("prefix length cannot be negative: %d", prefixLen)
+		return nil, newError(ErrInvalidPrefix, "prefix length cannot be negative: %d", prefixLen)
 	}
 	if prefixLen > 128 {
-		return nil, fmt.Errorf("prefix length cannot exceed 128: %d", prefixLen)
+		return nil, newError(ErrInvalidPrefix, "prefix length cannot exceed 128: %d", prefixLen)
 	}
 	
 	if prefixLen < 128 {
 		shiftBits := 128 - prefixLen // Calculate shift bits as int first
 		// Ensure shiftBits is positive before converting to uint
 		if shiftBits < 0 {
-			return nil, fmt.Errorf("invalid shift bits calculation: %d", shiftBits)
+			return nil, newError(ErrInvalidPrefix, "invalid shift bits calculation: %d", shiftBits)
 		}
 		// Now it's safe to convert to uint
 		uShiftBits := uint(shiftBits)
@@ -103,8 +116,15 @@ func IPv6ToString(ipInt *big.Int) string {
 	return ip.String()
 }
 
-// CalculateIPv6Network calculates network details from an IPv6 address and prefix
+// CalculateIPv6Network calculates network details from an IPv6 address and prefix.
+// The address may carry a zone ID, as in fe80::1%eth0, which is kept in Zone.
 func CalculateIPv6Network(ipStr, prefixStr string) (*IPv6Network, error) {
+	input := ipStr
+	ipStr, zone, hasZone := strings.Cut(ipStr, "%")
+	if hasZone && zone == "" {
+		return nil, newParseError(ErrInvalidAddress, FieldAddress, input, len(input), "invalid IP address: %s (empty zone ID)", input)
+	}
+
 	ip, err := ParseIPv6(ipStr)
 	if err != nil {
 		return nil, err
@@ -122,17 +142,17 @@ func CalculateIPv6Network(ipStr, prefixStr string) (*IPv6Network, error) {
 	
 	// Shift left to remove the host bits
 	if prefix < 0 {
-		return nil, fmt.Errorf("prefix length cannot be negative: %d", prefix)
+		return nil, newError(ErrInvalidPrefix, "prefix length cannot be negative: %d", prefix)
 	}
 	if prefix > 128 {
-		return nil, fmt.Errorf("prefix length cannot exceed 128: %d", prefix)
+		return nil, newError(ErrInvalidPrefix, "prefix length cannot exceed 128: %d", prefix)
 	}
 	
 	if prefix < 128 {
 		shiftBits := 128 - prefix // Calculate shift bits as int first
 		// Ensure shiftBits is positive before converting to uint
 		if shiftBits < 0 {
-			return nil, fmt.Errorf("invalid shift bits calculation: %d", shiftBits)
+			return nil, newError(ErrInvalidPrefix, "invalid shift bits calculation: %d", shiftBits)
 		}
 		// Now it's safe to convert to uint
 		uShiftBits := uint(shiftBits)
@@ -149,6 +169,7 @@ func CalculateIPv6Network(ipStr, prefixStr string) (*IPv6Network, error) {
 		PrefixLen:   prefix,
 		NetworkID:   networkID,
 		NetworkMask: networkMask,
+		Zone:        zone,
 	}, nil
 }
 
@@ -0,0 +1,142 @@
+package calculator
+
+import (
+	"math/big"
+	"net/netip"
+	"strconv"
+)
+
+// Network is an IPv4 or IPv6 network, an *IPv4Network or an *IPv6Network
+type Network interface {
+	// Addr returns the address the network was calculated from
+	Addr() netip.Addr
+	// Prefix returns the network prefix, with the host bits cleared
+	Prefix() netip.Prefix
+	// HostRange returns the first and last usable host addresses
+	HostRange() (netip.Addr, netip.Addr)
+}
+
+// FromPrefix calculates the network of an IPv4 or IPv6 prefix. The result
+// is an *IPv4Network for IPv4 prefixes and an *IPv6Network otherwise,
+// including for IPv4-mapped IPv6 prefixes.
+func FromPrefix(prefix netip.Prefix) (Network, error) {
+	if prefix.Addr().Is4() {
+		return IPv4FromPrefix(prefix)
+	}
+	return IPv6FromPrefix(prefix)
+}
+
+// IPv4FromPrefix calculates the network of an IPv4 prefix. The host bits of
+// the prefix are kept as the network's Address.
+func IPv4FromPrefix(prefix netip.Prefix) (*IPv4Network, error) {
+	if !prefix.IsValid() {
+		return nil, newError(ErrInvalidPrefix, "invalid prefix: %s", prefix)
+	}
+	if !prefix.Addr().Is4() {
+		return nil, newError(ErrAddressFamily, "not an IPv4 prefix: %s", prefix)
+	}
+	return CalculateNetwork(prefix.Addr().String(), strconv.Itoa(prefix.Bits()))
+}
+
+// IPv6FromPrefix calculates the network of an IPv6 prefix. The host bits of
+// the prefix are kept as the network's Address.
+func IPv6FromPrefix(prefix netip.Prefix) (*IPv6Network, error) {
+	if !prefix.IsValid() {
+		return nil, newError(ErrInvalidPrefix, "invalid prefix: %s", prefix)
+	}
+	if !prefix.Addr().Is6() {
+		return nil, newError(ErrAddressFamily, "not an IPv6 prefix: %s", prefix)
+	}
+	return CalculateIPv6Network(prefix.Addr().String(), strconv.Itoa(prefix.Bits()))
+}
+
+// AddrFromIPv4 converts an IPv4 address to a netip.Addr
+func AddrFromIPv4(ip uint32) netip.Addr {
+	return netip.AddrFrom4([4]byte{byte(ip >> 24), byte(ip >> 16), byte(ip >> 8), byte(ip)})
+}
+
+// IPv4FromAddr converts a netip.Addr holding an IPv4 address to a uint32.
+// IPv4-mapped IPv6 addresses are not converted.
+func IPv4FromAddr(addr netip.Addr) (uint32, error) {
+	if !addr.Is4() {
+		return 0, newParseError(ErrAddressFamily, FieldAddress, addr.String(), 0, "not an IPv4 address: %s", addr)
+	}
+	b := addr.As4()
+	return uint32(b[0])<<24 | uint32(b[1])<<16 | uint32(b[2])<<8 | uint32(b[3]), nil
+}
+
+// AddrFromIPv6 converts an IPv6 address to a netip.Addr. Bits above the
+// lowest 128 are ignored.
+func AddrFromIPv6(ip *big.Int) netip.Addr {
+	var b [16]byte
+	bytes := ip.Bytes()
+	if len(bytes) > 16 {
+		bytes = bytes[len(bytes)-16:]
+	}
+	copy(b[16-len(bytes):], bytes)
+	return netip.AddrFrom16(b)
+}
+
+// IPv6FromAddr converts a netip.Addr holding an IPv6 address to a big.Int.
+// The zone, if any, is dropped.
+func IPv6FromAddr(addr netip.Addr) (*big.Int, error) {
+	if !addr.Is6() {
+		return nil, newParseError(ErrAddressFamily, FieldAddress, addr.String(), 0, "not an IPv6 address: %s", addr)
+	}
+	b := addr.As16()
+	return new(big.Int).SetBytes(b[:]), nil
+}
+
+// AddrFromBig converts an address of the given width (32 or 128 bits) to a
+// netip.Addr
+func AddrFromBig(ip *big.Int, bits int) netip.Addr {
+	if bits == 32 {
+		return AddrFromIPv4(uint32(ip.Uint64()))
+	}
+	return AddrFromIPv6(ip)
+}
+
+// BigFromAddr converts an IPv4 or IPv6 address to a big.Int. The zone, if
+// any, is dropped.
+func BigFromAddr(addr netip.Addr) *big.Int {
+	return new(big.Int).SetBytes(addr.AsSlice())
+}
+
+// Addr returns the address the network was calculated from
+func (n *IPv4Network) Addr() netip.Addr {
+	return AddrFromIPv4(n.Address)
+}
+
+// Prefix returns the network prefix, such as 192.168.0.0/24
+func (n *IPv4Network) Prefix() netip.Prefix {
+	return netip.PrefixFrom(AddrFromIPv4(n.NetworkID), n.BitCount)
+}
+
+// HostRange returns the first and last usable host addresses
+func (n *IPv4Network) HostRange() (netip.Addr, netip.Addr) {
+	return AddrFromIPv4(n.HostMin), AddrFromIPv4(n.HostMax)
+}
+
+// Addr returns the address the network was calculated from, with its zone ID
+func (n *IPv6Network) Addr() netip.Addr {
+	return AddrFromIPv6(n.Address).WithZone(n.Zone)
+}
+
+// Prefix returns the network prefix, such as 2001:db8::/64
+func (n *IPv6Network) Prefix() netip.Prefix {
+	return netip.PrefixFrom(AddrFromIPv6(n.NetworkID), n.PrefixLen)
+}
+
+// HostRange returns the first and last host addresses. The network address
+// is the subnet-router anycast address, so hosts start one above it except
+// in /127 and /128 networks.
+func (n *IPv6Network) HostRange() (netip.Addr, netip.Addr) {
+	first, last := IPv6HostRange(n.NetworkID, n.PrefixLen)
+	return AddrFromIPv6(first), AddrFromIPv6(last)
+}
+
+// Prefix returns the subnet as a netip.Prefix
+func (s Subnet) Prefix() netip.Prefix {
+	prefix, _ := netip.ParsePrefix(s.Network)
+	return prefix
+}
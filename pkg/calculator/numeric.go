@@ -0,0 +1,126 @@
+package calculator
+
+import (
+	"fmt"
+	"math/big"
+	"net/netip"
+	"strconv"
+	"strings"
+)
+
+// ParseNumericAddress parses an address written as a number, the forms
+// databases and flow exports store addresses in:
+//
+//   - a decimal integer, such as 3232235777, without leading zeros, which
+//     inet_aton would read as octal
+//   - a hexadecimal integer with a 0x prefix, such as 0xC0A80101
+//   - dotted hexadecimal, such as C0.A8.01.01, when at least one octet
+//     holds a hex letter or every octet has a 0x prefix
+//
+// Integers up to 0xFFFFFFFF and hexadecimal integers of up to eight digits
+// are IPv4 addresses; larger ones, up to 128 bits, are IPv6 addresses.
+func ParseNumericAddress(s string) (netip.Addr, error) {
+	if addr, ok := parseDottedHex(s); ok {
+		return addr, nil
+	}
+
+	value := new(big.Int)
+	digits, isHex := strings.CutPrefix(strings.ToLower(s), "0x")
+	base := 10
+	if isHex {
+		base = 16
+	}
+	if digits == "" || strings.ContainsAny(digits, "+-_") {
+		return netip.Addr{}, numericError(s)
+	}
+	if !isHex && len(digits) > 1 && digits[0] == '0' {
+		return netip.Addr{}, newParseError(ErrInvalidAddress, FieldAddress, s, 0,
+			"invalid IP address: %s (a leading zero makes it octal to inet_aton)", s)
+	}
+	if _, ok := value.SetString(digits, base); !ok {
+		return netip.Addr{}, numericError(s)
+	}
+
+	switch {
+	case value.BitLen() > 128:
+		return netip.Addr{}, numericError(s)
+	case value.BitLen() <= 32 && (!isHex || len(digits) <= 8):
+		return AddrFromIPv4(uint32(value.Uint64())), nil
+	default:
+		return AddrFromIPv6(value), nil
+	}
+}
+
+// numericError returns the error for input that is not a numeric address
+func numericError(s string) error {
+	position := strings.IndexFunc(s, func(r rune) bool {
+		return !strings.ContainsRune("0123456789abcdefABCDEFxX", r)
+	})
+	if position < 0 {
+		position = 0
+	}
+	return newParseError(ErrInvalidAddress, FieldAddress, s, position, "invalid IP address: %s", s)
+}
+
+// parseDottedHex parses an IPv4 address written as four hexadecimal octets
+func parseDottedHex(s string) (netip.Addr, bool) {
+	parts := strings.Split(s, ".")
+	if len(parts) != 4 {
+		return netip.Addr{}, false
+	}
+
+	var octets [4]byte
+	prefixed, letters := 0, false
+	for i, part := range parts {
+		if digits, found := strings.CutPrefix(strings.ToLower(part), "0x"); found {
+			part = digits
+			prefixed++
+		}
+		if len(part) < 1 || len(part) > 2 {
+			return netip.Addr{}, false
+		}
+		value, err := strconv.ParseUint(part, 16, 8)
+		if err != nil {
+			return netip.Addr{}, false
+		}
+		letters = letters || strings.ContainsAny(strings.ToLower(part), "abcdef")
+		octets[i] = byte(value)
+	}
+
+	// Without a letter or prefixes this is ordinary dotted decimal
+	if !letters && prefixed != 4 {
+		return netip.Addr{}, false
+	}
+	return netip.AddrFrom4(octets), true
+}
+
+// FormatDecimal returns an IPv4 address as a decimal integer, such as 3232235777
+func FormatDecimal(ip uint32) string {
+	return strconv.FormatUint(uint64(ip), 10)
+}
+
+// FormatHex returns an IPv4 address as a hexadecimal integer, such as 0xC0A80101
+func FormatHex(ip uint32) string {
+	return fmt.Sprintf("0x%08X", ip)
+}
+
+// FormatDottedHex returns an IPv4 address as dotted hexadecimal octets, such as C0.A8.01.01
+func FormatDottedHex(ip uint32) string {
+	return fmt.Sprintf("%02X.%02X.%02X.%02X", ip>>24, (ip>>16)&0xFF, (ip>>8)&0xFF, ip&0xFF)
+}
+
+// FormatOctal returns an IPv4 address as dotted octal octets in the form
+// inet_aton accepts, such as 0300.0250.0001.0001
+func FormatOctal(ip uint32) string {
+	return fmt.Sprintf("%04o.%04o.%04o.%04o", ip>>24, (ip>>16)&0xFF, (ip>>8)&0xFF, ip&0xFF)
+}
+
+// FormatIPv6Decimal returns an IPv6 address as a decimal integer
+func FormatIPv6Decimal(ip *big.Int) string {
+	return ip.String()
+}
+
+// FormatIPv6Hex returns an IPv6 address as a 32-digit hexadecimal integer
+func FormatIPv6Hex(ip *big.Int) string {
+	return fmt.Sprintf("0x%032X", ip)
+}
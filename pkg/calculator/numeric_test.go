@@ -0,0 +1,87 @@
+package calculator
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestParseNumericAddress(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+		err   error
+	}{
+		{"3232235777", "192.168.1.1", nil},
+		{"0", "0.0.0.0", nil},
+		{"4294967295", "255.255.255.255", nil},
+		{"0xC0A80101", "192.168.1.1", nil},
+		{"0xc0a80101", "192.168.1.1", nil},
+		{"0x0", "0.0.0.0", nil},
+		{"C0.A8.01.01", "192.168.1.1", nil},
+		{"0xC0.0xA8.0x01.0x01", "192.168.1.1", nil},
+		{"0x10.0x00.0x00.0x01", "16.0.0.1", nil},
+		// Past 32 bits, or past eight hex digits, numbers are IPv6
+		{"4294967296", "::1:0:0", nil},
+		{"0x000000001", "::1", nil},
+		{"42540766411282592856903984951653826561", "2001:db8::1", nil},
+		{"0x20010DB8000000000000000000000001", "2001:db8::1", nil},
+		{"0xFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF", "ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff", nil},
+		{"0x1FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF", "", ErrInvalidAddress},
+		// inet_aton reads a leading zero as octal
+		{"0300", "", ErrInvalidAddress},
+		{"010", "", ErrInvalidAddress},
+		{"-1", "", ErrInvalidAddress},
+		{"+1", "", ErrInvalidAddress},
+		{"1_000", "", ErrInvalidAddress},
+		{"0x", "", ErrInvalidAddress},
+		{"0xG1", "", ErrInvalidAddress},
+		{"", "", ErrInvalidAddress},
+		{"10.0.0.1", "", ErrInvalidAddress},
+		{"C0.A8.01", "", ErrInvalidAddress},
+		{"C0.A8.01.100", "", ErrInvalidAddress},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseNumericAddress(tt.input)
+		if tt.err != nil {
+			if !errors.Is(err, tt.err) {
+				t.Errorf("ParseNumericAddress(%q) = %s, %v, want %v", tt.input, got, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || got.String() != tt.want {
+			t.Errorf("ParseNumericAddress(%q) = %s, %v, want %s", tt.input, got, err, tt.want)
+		}
+	}
+}
+
+func TestFormatNumeric(t *testing.T) {
+	ip, _ := ParseIPv4("192.168.1.1")
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{"FormatDecimal", FormatDecimal(ip), "3232235777"},
+		{"FormatHex", FormatHex(ip), "0xC0A80101"},
+		{"FormatDottedHex", FormatDottedHex(ip), "C0.A8.01.01"},
+		{"FormatOctal", FormatOctal(ip), "0300.0250.0001.0001"},
+		{"FormatHex of zero", FormatHex(0), "0x00000000"},
+		{"FormatIPv6Decimal", FormatIPv6Decimal(big.NewInt(1)), "1"},
+		{"FormatIPv6Hex", FormatIPv6Hex(big.NewInt(1)), "0x00000000000000000000000000000001"},
+	}
+
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %s, want %s", tt.name, tt.got, tt.want)
+		}
+	}
+
+	// Every form reads back as the same address
+	for _, form := range []string{FormatDecimal(ip), FormatHex(ip), FormatDottedHex(ip)} {
+		if addr, err := ParseNumericAddress(form); err != nil || addr.String() != "192.168.1.1" {
+			t.Errorf("ParseNumericAddress(%q) = %s, %v, want 192.168.1.1", form, addr, err)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package calculator
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// MaxSubnets is the largest number of subnets an equal split will enumerate
+const MaxSubnets = 1 << 16
+
+// Subnet describes one subnet produced by an equal split.
+// Broadcast is empty for IPv6 networks, which have no broadcast address.
+type Subnet struct {
+	Network   string
+	Broadcast string
+	HostMin   string
+	HostMax   string
+	Hosts     *big.Int
+}
+
+// SplitNetworkByCount splits a network into count subnets of equal size.
+// The subnet size is the largest one that fits count subnets; when count is
+// not a power of two the space after the last subnet is left unallocated.
+func SplitNetworkByCount(ipStr, maskStr string, count int) ([]Subnet, error) {
+	if count < 1 {
+		return nil, newError(ErrInvalidSize, "invalid subnet count: %d (must be at least 1)", count)
+	}
+
+	networkID, prefix, bits, err := ParseNetworkAny(ipStr, maskStr)
+	if err != nil {
+		return nil, err
+	}
+
+	// Reject counts that cannot fit or be listed before searching for the
+	// prefix length, so that huge counts do not overflow the shift below
+	if bits-prefix < 63 && count > 1<<(bits-prefix) {
+		return nil, newError(ErrInsufficientSpace, "cannot split /%d into %d subnets: it holds only %d addresses", prefix, count, 1<<(bits-prefix))
+	}
+	if count > MaxSubnets {
+		return nil, newError(ErrTooManySubnets, "cannot list %d subnets (limit is %d)", count, MaxSubnets)
+	}
+
+	// Find the number of extra prefix bits needed to hold count subnets
+	newBits := 0
+	for (1 << newBits) < count {
+		newBits++
+	}
+
+	return splitNetwork(networkID, prefix+newBits, bits, count)
+}
+
+// SplitNetworkByPrefix splits a network into all subnets of the given prefix length
+func SplitNetworkByPrefix(ipStr, maskStr string, newPrefix int) ([]Subnet, error) {
+	networkID, prefix, bits, err := ParseNetworkAny(ipStr, maskStr)
+	if err != nil {
+		return nil, err
+	}
+
+	if newPrefix < 0 || newPrefix > bits {
+		return nil, newError(ErrInvalidPrefix, "invalid prefix length: %d (must be between 0 and %d)", newPrefix, bits)
+	}
+	if newPrefix < prefix {
+		return nil, newError(ErrInvalidPrefix, "cannot split /%d into larger /%d subnets", prefix, newPrefix)
+	}
+	if newPrefix-prefix > 16 {
+		return nil, newError(ErrTooManySubnets, "splitting /%d into /%d subnets would produce more than %d subnets", prefix, newPrefix, MaxSubnets)
+	}
+
+	return splitNetwork(networkID, newPrefix, bits, 1<<(newPrefix-prefix))
+}
+
+// splitNetwork returns count consecutive subnets of the given prefix length starting at networkID
+func splitNetwork(networkID *big.Int, prefix, bits, count int) ([]Subnet, error) {
+	if count > MaxSubnets {
+		return nil, newError(ErrTooManySubnets, "cannot list %d subnets (limit is %d)", count, MaxSubnets)
+	}
+
+	size := blockSize(prefix, bits)
+	current := new(big.Int).Set(networkID)
+	result := make([]Subnet, 0, count)
+
+	for i := 0; i < count; i++ {
+		subnet, err := newSubnet(current, prefix, bits)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, subnet)
+		current.Add(current, size)
+	}
+
+	return result, nil
+}
+
+// newSubnet builds the Subnet description for a network address and prefix length
+func newSubnet(networkID *big.Int, prefix, bits int) (Subnet, error) {
+	if bits == 32 {
+		network, err := CalculateNetwork(bigToString(networkID, bits), fmt.Sprintf("%d", prefix))
+		if err != nil {
+			return Subnet{}, err
+		}
+
+		// HostsCount overflows for a /0, so count the hosts directly
+		hosts := new(big.Int).SetUint64(uint64(network.HostsCount))
+		if prefix == 0 {
+			hosts.Sub(blockSize(0, 32), big.NewInt(2))
+		}
+
+		return Subnet{
+			Network:   bigToCIDR(networkID, prefix, bits),
+			Broadcast: IPToString(network.Broadcast),
+			HostMin:   IPToString(network.HostMin),
+			HostMax:   IPToString(network.HostMax),
+			Hosts:     hosts,
+		}, nil
+	}
+
+	hostMin, hostMax := IPv6HostRange(networkID, prefix)
+	hosts := new(big.Int).Sub(hostMax, hostMin)
+
+	return Subnet{
+		Network: bigToCIDR(networkID, prefix, bits),
+		HostMin: bigToString(hostMin, bits),
+		HostMax: bigToString(hostMax, bits),
+		Hosts:   hosts.Add(hosts, big.NewInt(1)),
+	}, nil
+}
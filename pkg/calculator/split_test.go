@@ -0,0 +1,97 @@
+package calculator
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+// networks returns the network of each subnet
+func networks(subnets []Subnet) []string {
+	var result []string
+	for _, subnet := range subnets {
+		result = append(result, subnet.Network)
+	}
+	return result
+}
+
+func TestSplitNetworkByCount(t *testing.T) {
+	tests := []struct {
+		ip, mask string
+		count    int
+		want     []string
+		err      error
+	}{
+		{"192.168.0.0", "24", 4, []string{"192.168.0.0/26", "192.168.0.64/26", "192.168.0.128/26", "192.168.0.192/26"}, nil},
+		{"192.168.0.77", "24", 3, []string{"192.168.0.0/26", "192.168.0.64/26", "192.168.0.128/26"}, nil},
+		{"10.0.0.0", "8", 1, []string{"10.0.0.0/8"}, nil},
+		{"10.0.0.0", "30", 4, []string{"10.0.0.0/32", "10.0.0.1/32", "10.0.0.2/32", "10.0.0.3/32"}, nil},
+		{"2001:db8::", "32", 2, []string{"2001:db8::/33", "2001:db8:8000::/33"}, nil},
+		{"10.0.0.0", "30", 5, nil, ErrInsufficientSpace},
+		{"10.0.0.0", "32", 2, nil, ErrInsufficientSpace},
+		{"10.0.0.0", "8", 1<<63 - 1, nil, ErrInsufficientSpace},
+		{"2001:db8::", "32", 1<<63 - 1, nil, ErrTooManySubnets},
+		{"10.0.0.0", "8", MaxSubnets + 1, nil, ErrTooManySubnets},
+		{"10.0.0.0", "8", 0, nil, ErrInvalidSize},
+		{"10.0.0.0", "33", 2, nil, ErrInvalidNetmask},
+	}
+
+	for _, tt := range tests {
+		got, err := SplitNetworkByCount(tt.ip, tt.mask, tt.count)
+		if !errors.Is(err, tt.err) || (tt.err != nil && err == nil) || !slices.Equal(networks(got), tt.want) {
+			t.Errorf("SplitNetworkByCount(%q, %q, %d) = %v, %v, want %v, %v", tt.ip, tt.mask, tt.count, networks(got), err, tt.want, tt.err)
+		}
+	}
+}
+
+func TestSplitNetworkByPrefix(t *testing.T) {
+	tests := []struct {
+		ip, mask  string
+		newPrefix int
+		want      []string
+		err       error
+	}{
+		{"10.0.0.0", "22", 24, []string{"10.0.0.0/24", "10.0.1.0/24", "10.0.2.0/24", "10.0.3.0/24"}, nil},
+		{"10.0.0.0", "24", 24, []string{"10.0.0.0/24"}, nil},
+		{"2001:db8::", "62", 64, []string{"2001:db8::/64", "2001:db8:0:1::/64", "2001:db8:0:2::/64", "2001:db8:0:3::/64"}, nil},
+		{"10.0.0.0", "24", 23, nil, ErrInvalidPrefix},
+		{"10.0.0.0", "24", 33, nil, ErrInvalidPrefix},
+		{"10.0.0.0", "8", 25, nil, ErrTooManySubnets},
+	}
+
+	for _, tt := range tests {
+		got, err := SplitNetworkByPrefix(tt.ip, tt.mask, tt.newPrefix)
+		if !errors.Is(err, tt.err) || (tt.err != nil && err == nil) || !slices.Equal(networks(got), tt.want) {
+			t.Errorf("SplitNetworkByPrefix(%q, %q, %d) = %v, %v, want %v, %v", tt.ip, tt.mask, tt.newPrefix, networks(got), err, tt.want, tt.err)
+		}
+	}
+}
+
+func TestSplitNetworkHosts(t *testing.T) {
+	tests := []struct {
+		ip, mask                           string
+		count                              int
+		broadcast, hostMin, hostMax, hosts string
+	}{
+		{"192.168.0.0", "24", 2, "192.168.0.127", "192.168.0.1", "192.168.0.126", "126"},
+		{"10.0.0.0", "30", 2, "10.0.0.1", "10.0.0.0", "10.0.0.1", "2"},
+		{"0.0.0.0", "0", 1, "255.255.255.255", "0.0.0.1", "255.255.255.254", "4294967294"},
+		// IPv6 hosts start above the subnet-router anycast address
+		{"2001:db8::", "64", 2, "", "2001:db8::1", "2001:db8::7fff:ffff:ffff:ffff", "9223372036854775807"},
+		{"2001:db8::", "126", 2, "", "2001:db8::", "2001:db8::1", "2"},
+		{"2001:db8::", "127", 2, "", "2001:db8::", "2001:db8::", "1"},
+	}
+
+	for _, tt := range tests {
+		subnets, err := SplitNetworkByCount(tt.ip, tt.mask, tt.count)
+		if err != nil {
+			t.Errorf("SplitNetworkByCount(%q, %q, %d) failed: %v", tt.ip, tt.mask, tt.count, err)
+			continue
+		}
+		got := subnets[0]
+		if got.Broadcast != tt.broadcast || got.HostMin != tt.hostMin || got.HostMax != tt.hostMax || got.Hosts.String() != tt.hosts {
+			t.Errorf("SplitNetworkByCount(%q, %q, %d)[0] = %s %s-%s %s, want %s %s-%s %s", tt.ip, tt.mask, tt.count,
+				got.Broadcast, got.HostMin, got.HostMax, got.Hosts, tt.broadcast, tt.hostMin, tt.hostMax, tt.hosts)
+		}
+	}
+}
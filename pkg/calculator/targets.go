@@ -0,0 +1,357 @@
+package calculator
+
+import (
+	"iter"
+	"math/big"
+	"net/netip"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maxTargetRanges is the most ranges an nmap octet specification may
+// expand to before merging
+const maxTargetRanges = 1 << 16
+
+// Targets is a set of addresses given by target specifications, held as
+// sorted, merged ranges with IPv4 ranges before IPv6 ranges
+type Targets struct {
+	ranges []addressRange
+}
+
+// ParseTargets parses target specifications as scan scope documents and
+// nmap write them. Each specification may be a comma-separated list of:
+//
+//   - an address or a network in CIDR notation, such as 10.0.0.0/24
+//   - a range of addresses, such as 10.0.0.5-10.0.0.50 or
+//     2001:db8::1-2001:db8::ff
+//   - an IPv4 address with nmap octet ranges, such as 10.0.0.5-50 or
+//     10.0.1-3.1-254, where * stands for 0-255 and an open end for 0 or
+//     255
+//
+// Overlapping and adjacent targets are merged.
+func ParseTargets(specs []string) (*Targets, error) {
+	var ranges []addressRange
+	for _, target := range splitTargets(specs) {
+		parsed, err := parseTarget(target)
+		if err != nil {
+			return nil, err
+		}
+		ranges = append(ranges, parsed...)
+	}
+	if len(ranges) == 0 {
+		return nil, newError(ErrInvalidRange, "no targets given")
+	}
+	return &Targets{ranges: mergeRanges(ranges)}, nil
+}
+
+// CountTargets returns the number of ranges target specifications expand to
+// before they are merged, without expanding them, so that callers can
+// enforce a limit before calling ParseTargets
+func CountTargets(specs []string) (int, error) {
+	count := 0
+	for _, target := range splitTargets(specs) {
+		if !isOctetTarget(target) {
+			count++
+			continue
+		}
+		_, _, ranges, err := parseOctets(target)
+		if err != nil {
+			return 0, err
+		}
+		count += ranges
+	}
+	return count, nil
+}
+
+// splitTargets splits target specifications into their comma-separated
+// targets, dropping empty ones
+func splitTargets(specs []string) []string {
+	var targets []string
+	for _, spec := range specs {
+		for _, target := range strings.Split(spec, ",") {
+			// Scope documents often write ranges as "a - b"
+			target = strings.Join(strings.Fields(target), "")
+			if target != "" {
+				targets = append(targets, target)
+			}
+		}
+	}
+	return targets
+}
+
+// isOctetTarget reports whether a target is an IPv4 address with nmap octet
+// ranges rather than an address, network or range of full addresses
+func isOctetTarget(target string) bool {
+	if strings.Contains(target, "/") || !strings.ContainsAny(target, "-*") || strings.Contains(target, ":") {
+		return false
+	}
+	_, endStr, _ := strings.Cut(target, "-")
+	return strings.Count(target, "-") != 1 || strings.Count(endStr, ".") != 3
+}
+
+// parseTarget parses a single target into its address ranges
+func parseTarget(target string) ([]addressRange, error) {
+	if isOctetTarget(target) {
+		return parseOctetRanges(target)
+	}
+
+	// Networks and single addresses
+	if strings.Contains(target, "/") || !strings.ContainsAny(target, "-*") {
+		networkID, prefix, bits, err := parseCIDRAny(target)
+		if err != nil {
+			return nil, err
+		}
+		return []addressRange{{networkID, lastAddress(networkID, prefix, bits), bits}}, nil
+	}
+
+	// A range between two full addresses; IPv6 ranges need both in full
+	startStr, endStr, _ := strings.Cut(target, "-")
+	start, end, bits, err := parseAddressRange(startStr, endStr)
+	if err != nil {
+		return nil, err
+	}
+	return []addressRange{{start, end, bits}}, nil
+}
+
+// parseAddressRange parses the first and last address of a range
+func parseAddressRange(startStr, endStr string) (*big.Int, *big.Int, int, error) {
+	start, _, bits, err := parseCIDRAny(startStr)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	end, _, endBits, err := parseCIDRAny(endStr)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	if bits != endBits {
+		return nil, nil, 0, newError(ErrAddressFamily, "%s and %s are different address families", startStr, endStr)
+	}
+	if start.Cmp(end) > 0 {
+		return nil, nil, 0, newError(ErrInvalidRange, "start address must be less than or equal to end address")
+	}
+	return start, end, bits, nil
+}
+
+// parseOctetRanges parses an IPv4 address whose octets may be nmap ranges,
+// returning a range for every combination of the leading octets
+func parseOctetRanges(target string) ([]addressRange, error) {
+	octets, last, _, err := parseOctets(target)
+	if err != nil {
+		return nil, err
+	}
+
+	shift := 8 * (3 - last)
+	var ranges []addressRange
+	var expand func(i int, base uint32)
+	expand = func(i int, base uint32) {
+		if i == last {
+			start := base | octets[last][0]<<shift
+			end := base | octets[last][1]<<shift | (1<<shift - 1)
+			ranges = append(ranges, addressRange{big.NewInt(int64(start)), big.NewInt(int64(end)), 32})
+			return
+		}
+		for value := octets[i][0]; value <= octets[i][1]; value++ {
+			expand(i+1, base|value<<(24-8*i))
+		}
+	}
+	expand(0, 0)
+	return ranges, nil
+}
+
+// parseOctets parses the octets of an nmap target. It returns the low and
+// high value of each octet, the index of the octet the ranges end at, and
+// the number of ranges the target expands to.
+func parseOctets(target string) ([4][2]uint32, int, int, error) {
+	var octets [4][2]uint32
+	parts := strings.Split(target, ".")
+	if len(parts) != 4 {
+		return octets, 0, 0, newParseError(ErrInvalidAddress, FieldAddress, target, addressErrorPosition(target), "invalid IP address: %s", target)
+	}
+
+	offset := 0
+	for i, part := range parts {
+		low, high, err := parseOctetRange(part)
+		if err != nil {
+			return octets, 0, 0, newParseError(ErrInvalidAddress, FieldAddress, target, offset, "invalid IP address: %s (%v)", target, err)
+		}
+		octets[i] = [2]uint32{low, high}
+		offset += len(part) + 1
+	}
+
+	// Trailing octets covering 0-255 extend each range instead of
+	// multiplying the number of ranges
+	last := 3
+	for last > 0 && octets[last] == [2]uint32{0, 255} {
+		last--
+	}
+	count := 1
+	for _, octet := range octets[:last] {
+		count *= int(octet[1] - octet[0] + 1)
+		if count > maxTargetRanges {
+			return octets, 0, 0, newError(ErrTooManySubnets, "%s expands to more than %d ranges", target, maxTargetRanges)
+		}
+	}
+	return octets, last, count, nil
+}
+
+// parseOctetRange parses one octet of an nmap target: a number, a range
+// such as 1-254 with optional ends, or *
+func parseOctetRange(part string) (uint32, uint32, error) {
+	if part == "*" {
+		return 0, 255, nil
+	}
+	lowStr, highStr, isRange := strings.Cut(part, "-")
+	if !isRange {
+		highStr = lowStr
+	}
+
+	parse := func(s string, empty uint32) (uint32, error) {
+		if s == "" && isRange {
+			return empty, nil
+		}
+		value, err := strconv.ParseUint(s, 10, 8)
+		if err != nil || strings.HasPrefix(s, "+") {
+			return 0, newError(ErrInvalidAddress, "invalid octet %q", part)
+		}
+		return uint32(value), nil
+	}
+	low, err := parse(lowStr, 0)
+	if err != nil {
+		return 0, 0, err
+	}
+	high, err := parse(highStr, 255)
+	if err != nil {
+		return 0, 0, err
+	}
+	if low > high {
+		return 0, 0, newError(ErrInvalidRange, "octet range %s is reversed", part)
+	}
+	return low, high, nil
+}
+
+// mergeRanges sorts ranges, IPv4 first, and merges overlapping and
+// adjacent ranges of the same family
+func mergeRanges(ranges []addressRange) []addressRange {
+	sort.Slice(ranges, func(i, j int) bool {
+		if ranges[i].bits != ranges[j].bits {
+			return ranges[i].bits < ranges[j].bits
+		}
+		return ranges[i].start.Cmp(ranges[j].start) < 0
+	})
+
+	var merged []addressRange
+	for _, r := range ranges {
+		if len(merged) > 0 {
+			last := &merged[len(merged)-1]
+			if last.bits == r.bits && new(big.Int).Add(last.end, big.NewInt(1)).Cmp(r.start) >= 0 {
+				if r.end.Cmp(last.end) > 0 {
+					last.end = r.end
+				}
+				continue
+			}
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
+
+// CIDRs returns the minimal list of CIDR blocks covering the targets
+func (t *Targets) CIDRs() []string {
+	var result []string
+	for _, r := range t.ranges {
+		result = append(result, rangeToCIDRs(r.start, r.end, r.bits)...)
+	}
+	return result
+}
+
+// Count returns the number of addresses in the targets
+func (t *Targets) Count() *big.Int {
+	count := new(big.Int)
+	for _, r := range t.ranges {
+		count.Add(count, new(big.Int).Sub(r.end, r.start))
+		count.Add(count, big.NewInt(1))
+	}
+	return count
+}
+
+// Addresses returns every address of the targets in order. The sequence
+// may be very long, so callers should stop when they have enough.
+func (t *Targets) Addresses() iter.Seq[netip.Addr] {
+	return func(yield func(netip.Addr) bool) {
+		for _, r := range t.ranges {
+			for current := new(big.Int).Set(r.start); current.Cmp(r.end) <= 0; current.Add(current, big.NewInt(1)) {
+				addr := AddrFromIPv6(current)
+				if r.bits == 32 {
+					addr = AddrFromIPv4(uint32(current.Uint64()))
+				}
+				if !yield(addr) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// DeaggregateAny returns the minimal list of CIDR blocks covering an
+// inclusive range of IPv4 or IPv6 addresses
+func DeaggregateAny(startStr, endStr string) ([]string, error) {
+	start, end, bits, err := parseAddressRange(startStr, endStr)
+	if err != nil {
+		return nil, err
+	}
+	return rangeToCIDRs(start, end, bits), nil
+}
+
+// Exclude returns the targets without the addresses of other
+func (t *Targets) Exclude(other *Targets) *Targets {
+	var result []addressRange
+	for _, r := range t.ranges {
+		pieces := []addressRange{r}
+		for _, excluded := range other.ranges {
+			if excluded.bits != r.bits {
+				continue
+			}
+			var remaining []addressRange
+			for _, piece := range pieces {
+				remaining = append(remaining, subtractRange(piece, excluded)...)
+			}
+			pieces = remaining
+		}
+		result = append(result, pieces...)
+	}
+	return &Targets{ranges: result}
+}
+
+// subtractRange returns the parts of r outside excluded, which has the same
+// address family
+func subtractRange(r, excluded addressRange) []addressRange {
+	if excluded.end.Cmp(r.start) < 0 || excluded.start.Cmp(r.end) > 0 {
+		return []addressRange{r}
+	}
+
+	var result []addressRange
+	if excluded.start.Cmp(r.start) > 0 {
+		result = append(result, addressRange{r.start, new(big.Int).Sub(excluded.start, big.NewInt(1)), r.bits})
+	}
+	if excluded.end.Cmp(r.end) < 0 {
+		result = append(result, addressRange{new(big.Int).Add(excluded.end, big.NewInt(1)), r.end, r.bits})
+	}
+	return result
+}
+
+// Exclude returns the smallest list of CIDR blocks covering the addresses
+// of networks that are not in excluded, as in removing a reserved block
+// from an allocation. Both lists accept the target specifications of
+// ParseTargets.
+func Exclude(networks, excluded []string) ([]string, error) {
+	targets, err := ParseTargets(networks)
+	if err != nil {
+		return nil, err
+	}
+	other, err := ParseTargets(excluded)
+	if err != nil {
+		return nil, err
+	}
+	return targets.Exclude(other).CIDRs(), nil
+}
@@ -0,0 +1,130 @@
+package calculator
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestParseTargets(t *testing.T) {
+	tests := []struct {
+		specs []string
+		cidrs []string
+		count string
+		err   error
+	}{
+		{specs: []string{"10.0.0.0/24"}, cidrs: []string{"10.0.0.0/24"}, count: "256"},
+		{specs: []string{"10.0.0.1"}, cidrs: []string{"10.0.0.1/32"}, count: "1"},
+		{specs: []string{"10.0.0.5-10.0.0.8"}, cidrs: []string{"10.0.0.5/32", "10.0.0.6/31", "10.0.0.8/32"}, count: "4"},
+		// Scope documents often put spaces around the dash
+		{specs: []string{"10.0.0.5 - 10.0.0.8"}, cidrs: []string{"10.0.0.5/32", "10.0.0.6/31", "10.0.0.8/32"}, count: "4"},
+		{specs: []string{"10.0.0.5-8"}, cidrs: []string{"10.0.0.5/32", "10.0.0.6/31", "10.0.0.8/32"}, count: "4"},
+		{specs: []string{"10.0.1-2.*"}, cidrs: []string{"10.0.1.0/24", "10.0.2.0/24"}, count: "512"},
+		{specs: []string{"10.0.1-2.1"}, cidrs: []string{"10.0.1.1/32", "10.0.2.1/32"}, count: "2"},
+		{specs: []string{"10.0.0.-1"}, cidrs: []string{"10.0.0.0/31"}, count: "2"},
+		{specs: []string{"10.0.0.254-"}, cidrs: []string{"10.0.0.254/31"}, count: "2"},
+		{specs: []string{"2001:db8::1-2001:db8::2"}, cidrs: []string{"2001:db8::1/128", "2001:db8::2/128"}, count: "2"},
+		// Overlapping and adjacent targets merge, IPv4 before IPv6
+		{
+			specs: []string{"2001:db8::/64", "10.0.0.128/25,10.0.0.0/25", "10.0.0.5"},
+			cidrs: []string{"10.0.0.0/24", "2001:db8::/64"}, count: "18446744073709551872",
+		},
+		{specs: []string{"10.0.0.0/24", ", ,"}, cidrs: []string{"10.0.0.0/24"}, count: "256"},
+		{specs: []string{""}, err: ErrInvalidRange},
+		{specs: []string{"10.0.0.8-10.0.0.5"}, err: ErrInvalidRange},
+		{specs: []string{"10.0.0.9-5"}, err: ErrInvalidAddress},
+		{specs: []string{"10.0.0.1-2001:db8::1"}, err: ErrAddressFamily},
+		{specs: []string{"10.0.0.1-300"}, err: ErrInvalidAddress},
+		{specs: []string{"10.0.1-3"}, err: ErrInvalidAddress},
+		{specs: []string{"10.0.0.0/33"}, err: ErrInvalidNetmask},
+		{specs: []string{"*.*.*.1"}, err: ErrTooManySubnets},
+	}
+
+	for _, tt := range tests {
+		targets, err := ParseTargets(tt.specs)
+		if tt.err != nil {
+			if !errors.Is(err, tt.err) {
+				t.Errorf("ParseTargets(%q) error = %v, want %v", tt.specs, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseTargets(%q) failed: %v", tt.specs, err)
+			continue
+		}
+		if cidrs := targets.CIDRs(); !slices.Equal(cidrs, tt.cidrs) || targets.Count().String() != tt.count {
+			t.Errorf("ParseTargets(%q) = %v count %s, want %v count %s", tt.specs, cidrs, targets.Count(), tt.cidrs, tt.count)
+		}
+	}
+}
+
+func TestTargetsAddresses(t *testing.T) {
+	targets, err := ParseTargets([]string{"2001:db8::1", "10.0.0.254-10.0.1.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for addr := range targets.Addresses() {
+		got = append(got, addr.String())
+	}
+	want := []string{"10.0.0.254", "10.0.0.255", "10.0.1.0", "10.0.1.1", "2001:db8::1"}
+	if !slices.Equal(got, want) {
+		t.Errorf("Addresses() = %v, want %v", got, want)
+	}
+}
+
+func TestCountTargets(t *testing.T) {
+	tests := []struct {
+		specs []string
+		want  int
+		err   error
+	}{
+		{specs: []string{"10.0.0.0/24,10.0.1.0/24", "10.0.0.1-10.0.0.9"}, want: 3},
+		{specs: []string{"10.0.0.1-9"}, want: 1},
+		{specs: []string{"10.0.1-3.1"}, want: 3},
+		// Trailing whole octets extend a range
+		{specs: []string{"10.1-2.*.*"}, want: 1},
+		{specs: []string{"10.*.*.1"}, want: 65536},
+		{specs: []string{"*.*.*.1"}, err: ErrTooManySubnets},
+		{specs: []string{"10.0.1-3"}, err: ErrInvalidAddress},
+	}
+
+	for _, tt := range tests {
+		got, err := CountTargets(tt.specs)
+		if !errors.Is(err, tt.err) || (tt.err != nil && err == nil) || got != tt.want {
+			t.Errorf("CountTargets(%q) = %d, %v, want %d, %v", tt.specs, got, err, tt.want, tt.err)
+		}
+	}
+}
+
+func TestExclude(t *testing.T) {
+	tests := []struct {
+		networks, excluded []string
+		want               []string
+		err                error
+	}{
+		{
+			networks: []string{"10.0.0.0/24"}, excluded: []string{"10.0.0.0/26"},
+			want: []string{"10.0.0.64/26", "10.0.0.128/25"},
+		},
+		{
+			networks: []string{"10.0.0.0/30"}, excluded: []string{"10.0.0.1"},
+			want: []string{"10.0.0.0/32", "10.0.0.2/31"},
+		},
+		// Excluded addresses of another family are ignored
+		{
+			networks: []string{"10.0.0.0/31", "2001:db8::/127"}, excluded: []string{"2001:db8::1", "10.0.0.0/8"},
+			want: []string{"2001:db8::/128"},
+		},
+		{networks: []string{"10.0.0.0/24"}, excluded: []string{"10.0.0.0/16"}},
+		{networks: []string{"10.0.0.0/24"}, excluded: []string{"10.0.0.300"}, err: ErrInvalidAddress},
+	}
+
+	for _, tt := range tests {
+		got, err := Exclude(tt.networks, tt.excluded)
+		if !errors.Is(err, tt.err) || (tt.err != nil && err == nil) || !slices.Equal(got, tt.want) {
+			t.Errorf("Exclude(%q, %q) = %v, %v, want %v, %v", tt.networks, tt.excluded, got, err, tt.want, tt.err)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package calculator
+
+import (
+	"math/big"
+	"net"
+	"strings"
+)
+
+// The functions in this file follow the semantics and error messages of
+// Terraform's cidrsubnet, cidrhost, cidrnetmask and cidrsubnets functions,
+// so expressions can be checked before running terraform plan.
+
+// parseTerraformCIDR parses a prefix the way Terraform does, returning the
+// network address, prefix length and address width in bits
+func parseTerraformCIDR(prefix string) (*big.Int, int, int, error) {
+	_, network, err := net.ParseCIDR(prefix)
+	if err != nil {
+		position, sentinel := cidrErrorPosition(prefix)
+		return nil, 0, 0, newParseError(sentinel, FieldCIDR, prefix, position, "invalid CIDR expression: %s", err)
+	}
+	ones, bits := network.Mask.Size()
+	return new(big.Int).SetBytes(network.IP), ones, bits, nil
+}
+
+// cidrErrorPosition returns the position of the first invalid part of a
+// CIDR expression and the sentinel error for it
+func cidrErrorPosition(prefix string) (int, error) {
+	ipStr, _, found := strings.Cut(prefix, "/")
+	switch {
+	case net.ParseIP(ipStr) == nil:
+		return addressErrorPosition(ipStr), ErrInvalidAddress
+	case !found:
+		return len(prefix), ErrInvalidPrefix
+	default:
+		return len(ipStr) + 1, ErrInvalidPrefix
+	}
+}
+
+// CIDRSubnet calculates a subnet address within a prefix, like Terraform's
+// cidrsubnet(prefix, newbits, netnum)
+func CIDRSubnet(prefix string, newBits int, netNum int64) (string, error) {
+	networkID, prefixLen, bits, err := parseTerraformCIDR(prefix)
+	if err != nil {
+		return "", err
+	}
+
+	// Terraform limits extensions to 32 bits for portability with 32-bit systems
+	if newBits > 32 {
+		return "", newError(ErrInvalidSize, "may not extend prefix by more than 32 bits")
+	}
+	if newBits < 0 {
+		return "", newError(ErrInvalidSize, "must extend prefix by at least 0 bits")
+	}
+
+	newPrefixLen := prefixLen + newBits
+	if newPrefixLen > bits {
+		return "", newError(ErrInsufficientSpace, "insufficient address space to extend prefix of %d by %d", prefixLen, newBits)
+	}
+
+	maxNetNum := int64(1)<<newBits - 1
+	if netNum < 0 || netNum > maxNetNum {
+		return "", newError(ErrOutOfRange, "prefix extension of %d does not accommodate a subnet numbered %d", newBits, netNum)
+	}
+
+	subnet := new(big.Int).Lsh(big.NewInt(netNum), uint(bits-newPrefixLen))
+	subnet.Or(subnet, networkID)
+	return bigToCIDR(subnet, newPrefixLen, bits), nil
+}
+
+// CIDRHost calculates a host address within a prefix, like Terraform's
+// cidrhost(prefix, hostnum). Negative host numbers count back from the end
+// of the prefix, so -1 is the last address.
+func CIDRHost(prefix string, hostNum *big.Int) (string, error) {
+	networkID, prefixLen, bits, err := parseTerraformCIDR(prefix)
+	if err != nil {
+		return "", err
+	}
+
+	hostCount := blockSize(prefixLen, bits)
+	offset := new(big.Int).Set(hostNum)
+	if offset.Sign() < 0 {
+		offset.Add(offset, hostCount)
+	}
+	if offset.Sign() < 0 || offset.Cmp(hostCount) >= 0 {
+		return "", newError(ErrOutOfRange, "prefix of %d does not accommodate a host numbered %s", prefixLen, hostNum)
+	}
+
+	return bigToString(offset.Add(offset, networkID), bits), nil
+}
+
+// CIDRNetmask returns the dotted-decimal netmask of an IPv4 prefix, like
+// Terraform's cidrnetmask(prefix)
+func CIDRNetmask(prefix string) (string, error) {
+	_, prefixLen, bits, err := parseTerraformCIDR(prefix)
+	if err != nil {
+		return "", err
+	}
+	if bits != 32 {
+		return "", newError(ErrAddressFamily, "IPv6 addresses cannot have a netmask: %s", prefix)
+	}
+	return bigToString(prefixMask(prefixLen, bits), bits), nil
+}
+
+// CIDRSubnets allocates consecutive subnets within a prefix, each extending
+// the prefix by the given number of bits, like Terraform's
+// cidrsubnets(prefix, newbits...). Each subnet starts at the first address
+// after the previous one that is aligned on its own size.
+func CIDRSubnets(prefix string, newBits ...int) ([]string, error) {
+	networkID, prefixLen, bits, err := parseTerraformCIDR(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	protocol := "IPv4"
+	if bits == 128 {
+		protocol = "IPv6"
+	}
+	networkEnd := lastAddress(networkID, prefixLen, bits)
+
+	// next is the first address not yet allocated
+	next := new(big.Int).Set(networkID)
+	var result []string
+	for i, extension := range newBits {
+		if extension < 1 {
+			return nil, newError(ErrInvalidSize, "newbits %d: must extend prefix by at least one bit", i+1)
+		}
+		if extension > 32 {
+			return nil, newError(ErrInvalidSize, "newbits %d: may not extend prefix by more than 32 bits", i+1)
+		}
+		length := prefixLen + extension
+		if length > bits {
+			return nil, newError(ErrInvalidPrefix, "newbits %d: would extend prefix to %d bits, which is too long for an %s address", i+1, length, protocol)
+		}
+
+		// Round up to the next boundary of this subnet's size
+		subnet := new(big.Int).Add(next, hostMaskFor(length, bits))
+		subnet.And(subnet, prefixMask(length, bits))
+		subnetEnd := lastAddress(subnet, length, bits)
+		if subnetEnd.Cmp(networkEnd) > 0 {
+			after := prefix
+			if len(result) > 0 {
+				after = result[len(result)-1]
+			}
+			return nil, newError(ErrInsufficientSpace, "newbits %d: not enough remaining address space for a subnet with a prefix of %d bits after %s", i+1, length, after)
+		}
+
+		result = append(result, bigToCIDR(subnet, length, bits))
+		next.Add(subnetEnd, big.NewInt(1))
+	}
+
+	return result, nil
+}
+
+// CIDRSubnetReverse returns the newbits and netnum arguments for which
+// cidrsubnet(prefix, newbits, netnum) produces the given subnet
+func CIDRSubnetReverse(prefix, subnet string) (int, int64, error) {
+	networkID, prefixLen, bits, err := parseTerraformCIDR(prefix)
+	if err != nil {
+		return 0, 0, err
+	}
+	subnetID, subnetLen, subnetBits, err := parseTerraformCIDR(subnet)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if subnetBits != bits {
+		return 0, 0, newError(ErrAddressFamily, "%s and %s are different address families", prefix, subnet)
+	}
+	if subnetLen < prefixLen || new(big.Int).And(subnetID, prefixMask(prefixLen, bits)).Cmp(networkID) != 0 {
+		return 0, 0, newError(ErrOutOfRange, "%s is not within %s", subnet, prefix)
+	}
+
+	newBits := subnetLen - prefixLen
+	if newBits > 32 {
+		return 0, 0, newError(ErrInvalidSize, "%s extends %s by %d bits, but cidrsubnet may not extend a prefix by more than 32 bits", subnet, prefix, newBits)
+	}
+
+	netNum := new(big.Int).Sub(subnetID, networkID)
+	netNum.Rsh(netNum, uint(bits-subnetLen))
+	return newBits, netNum.Int64(), nil
+}
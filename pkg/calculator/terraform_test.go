@@ -0,0 +1,146 @@
+package calculator
+
+import (
+	"errors"
+	"math/big"
+	"slices"
+	"testing"
+)
+
+// The expected values are those Terraform's own functions return; most
+// come from the examples in the Terraform documentation.
+
+func TestCIDRSubnet(t *testing.T) {
+	tests := []struct {
+		prefix  string
+		newBits int
+		netNum  int64
+		want    string
+		err     error
+	}{
+		{"172.16.0.0/12", 4, 2, "172.18.0.0/16", nil},
+		{"10.1.2.0/24", 4, 15, "10.1.2.240/28", nil},
+		{"10.1.2.3/24", 0, 0, "10.1.2.0/24", nil},
+		{"fd00:fd12:3456:7890::/56", 16, 162, "fd00:fd12:3456:7800:a200::/72", nil},
+		{"10.1.2.0/24", 4, 16, "", ErrOutOfRange},
+		{"10.1.2.0/24", 4, -1, "", ErrOutOfRange},
+		{"10.1.2.0/24", 9, 0, "", ErrInsufficientSpace},
+		{"::/0", 33, 0, "", ErrInvalidSize},
+		{"10.1.2.0/24", -1, 0, "", ErrInvalidSize},
+		{"10.1.2.0", 4, 0, "", ErrInvalidPrefix},
+		{"10.1.2.300/24", 4, 0, "", ErrInvalidAddress},
+	}
+
+	for _, tt := range tests {
+		got, err := CIDRSubnet(tt.prefix, tt.newBits, tt.netNum)
+		if !errors.Is(err, tt.err) || (tt.err != nil && err == nil) || got != tt.want {
+			t.Errorf("CIDRSubnet(%q, %d, %d) = %q, %v, want %q, %v", tt.prefix, tt.newBits, tt.netNum, got, err, tt.want, tt.err)
+		}
+	}
+}
+
+func TestCIDRHost(t *testing.T) {
+	tests := []struct {
+		prefix  string
+		hostNum int64
+		want    string
+		err     error
+	}{
+		{"10.12.112.0/20", 16, "10.12.112.16", nil},
+		{"10.12.112.0/20", 268, "10.12.113.12", nil},
+		{"fd00:fd12:3456:7890:00a2::/72", 34, "fd00:fd12:3456:7890::22", nil},
+		{"10.0.0.0/24", 0, "10.0.0.0", nil},
+		{"10.0.0.0/24", 255, "10.0.0.255", nil},
+		{"10.0.0.0/24", -1, "10.0.0.255", nil},
+		{"10.0.0.0/24", -256, "10.0.0.0", nil},
+		{"10.0.0.0/24", 256, "", ErrOutOfRange},
+		{"10.0.0.0/24", -257, "", ErrOutOfRange},
+		{"10.0.0.0/33", 1, "", ErrInvalidPrefix},
+	}
+
+	for _, tt := range tests {
+		got, err := CIDRHost(tt.prefix, big.NewInt(tt.hostNum))
+		if !errors.Is(err, tt.err) || (tt.err != nil && err == nil) || got != tt.want {
+			t.Errorf("CIDRHost(%q, %d) = %q, %v, want %q, %v", tt.prefix, tt.hostNum, got, err, tt.want, tt.err)
+		}
+	}
+}
+
+func TestCIDRNetmask(t *testing.T) {
+	tests := []struct {
+		prefix string
+		want   string
+		err    error
+	}{
+		{"172.16.0.0/12", "255.240.0.0", nil},
+		{"10.0.0.0/0", "0.0.0.0", nil},
+		{"10.0.0.1/32", "255.255.255.255", nil},
+		{"2001:db8::/32", "", ErrAddressFamily},
+	}
+
+	for _, tt := range tests {
+		got, err := CIDRNetmask(tt.prefix)
+		if !errors.Is(err, tt.err) || (tt.err != nil && err == nil) || got != tt.want {
+			t.Errorf("CIDRNetmask(%q) = %q, %v, want %q, %v", tt.prefix, got, err, tt.want, tt.err)
+		}
+	}
+}
+
+func TestCIDRSubnets(t *testing.T) {
+	tests := []struct {
+		prefix  string
+		newBits []int
+		want    []string
+		err     error
+	}{
+		{"10.1.0.0/16", []int{4, 4, 8, 4}, []string{"10.1.0.0/20", "10.1.16.0/20", "10.1.32.0/24", "10.1.48.0/20"}, nil},
+		{"fd00:fd12:3456:7890::/56", []int{16, 16, 16, 32},
+			[]string{"fd00:fd12:3456:7800::/72", "fd00:fd12:3456:7800:100::/72", "fd00:fd12:3456:7800:200::/72", "fd00:fd12:3456:7800:300::/88"}, nil},
+		{"10.0.0.0/24", []int{1, 1}, []string{"10.0.0.0/25", "10.0.0.128/25"}, nil},
+		{"10.0.0.0/24", nil, nil, nil},
+		{"10.0.0.0/24", []int{1, 1, 1}, nil, ErrInsufficientSpace},
+		{"10.0.0.0/24", []int{0}, nil, ErrInvalidSize},
+		{"10.0.0.0/24", []int{9}, nil, ErrInvalidPrefix},
+	}
+
+	for _, tt := range tests {
+		got, err := CIDRSubnets(tt.prefix, tt.newBits...)
+		if !errors.Is(err, tt.err) || (tt.err != nil && err == nil) || !slices.Equal(got, tt.want) {
+			t.Errorf("CIDRSubnets(%q, %v) = %v, %v, want %v, %v", tt.prefix, tt.newBits, got, err, tt.want, tt.err)
+		}
+	}
+}
+
+func TestCIDRSubnetReverse(t *testing.T) {
+	tests := []struct {
+		prefix, subnet string
+		newBits        int
+		netNum         int64
+		err            error
+	}{
+		{"10.0.0.0/16", "10.0.2.0/24", 8, 2, nil},
+		{"172.16.0.0/12", "172.18.0.0/16", 4, 2, nil},
+		{"fd00:fd12:3456:7890::/56", "fd00:fd12:3456:7800:a200::/72", 16, 162, nil},
+		{"10.0.0.0/16", "10.0.0.0/16", 0, 0, nil},
+		{"10.0.0.0/16", "10.1.0.0/24", 0, 0, ErrOutOfRange},
+		{"10.0.0.0/16", "10.0.0.0/8", 0, 0, ErrOutOfRange},
+		{"10.0.0.0/16", "2001:db8::/64", 0, 0, ErrAddressFamily},
+		{"2001:db8::/32", "2001:db8::/128", 0, 0, ErrInvalidSize},
+	}
+
+	for _, tt := range tests {
+		newBits, netNum, err := CIDRSubnetReverse(tt.prefix, tt.subnet)
+		if !errors.Is(err, tt.err) || (tt.err != nil && err == nil) || newBits != tt.newBits || netNum != tt.netNum {
+			t.Errorf("CIDRSubnetReverse(%q, %q) = %d, %d, %v, want %d, %d, %v",
+				tt.prefix, tt.subnet, newBits, netNum, err, tt.newBits, tt.netNum, tt.err)
+		}
+
+		// The arguments found must give the subnet back
+		if err == nil {
+			subnet, err := CIDRSubnet(tt.prefix, newBits, netNum)
+			if err != nil || subnet != tt.subnet {
+				t.Errorf("CIDRSubnet(%q, %d, %d) = %q, %v, want %q", tt.prefix, newBits, netNum, subnet, err, tt.subnet)
+			}
+		}
+	}
+}
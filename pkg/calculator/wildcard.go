@@ -0,0 +1,120 @@
+package calculator
+
+import (
+	"fmt"
+	"math/bits"
+	"strings"
+)
+
+// WildcardMatch represents an IPv4 address with an arbitrary Cisco wildcard mask.
+// Unlike a netmask the wildcard bits need not be contiguous: every set bit
+// may take either value, every clear bit must match Address.
+type WildcardMatch struct {
+	Address  uint32
+	Wildcard uint32
+}
+
+// ParseWildcardMatch parses an IPv4 address and a (possibly discontiguous) wildcard mask
+func ParseWildcardMatch(ipStr, wildcardStr string) (*WildcardMatch, error) {
+	ip, err := ParseIPv4(ipStr)
+	if err != nil {
+		return nil, err
+	}
+
+	wildcard, err := ParseIPv4(wildcardStr)
+	if err != nil {
+		return nil, newParseError(ErrInvalidWildcard, FieldWildcard, wildcardStr, addressErrorPosition(wildcardStr), "invalid wildcard mask: %s", wildcardStr)
+	}
+
+	// Bits covered by the wildcard are irrelevant, so clear them in the address
+	return &WildcardMatch{
+		Address:  ip &^ wildcard,
+		Wildcard: wildcard,
+	}, nil
+}
+
+// IsContiguous reports whether the wildcard is the inverse of a regular netmask
+func (w *WildcardMatch) IsContiguous() bool {
+	return isValidNetmask(^w.Wildcard)
+}
+
+// Count returns the number of addresses matched by the wildcard
+func (w *WildcardMatch) Count() uint64 {
+	return uint64(1) << bits.OnesCount32(w.Wildcard)
+}
+
+// Matches reports whether an address matches the wildcard
+func (w *WildcardMatch) Matches(ip uint32) bool {
+	return ip&^w.Wildcard == w.Address
+}
+
+// Pattern returns the bit pattern of the match, with x marking the wildcard bits
+func (w *WildcardMatch) Pattern() string {
+	var parts []string
+	for i := 0; i < 4; i++ {
+		var octet strings.Builder
+		for bit := 31 - i*8; bit > 23-i*8; bit-- {
+			switch {
+			case w.Wildcard&(1<<bit) != 0:
+				octet.WriteByte('x')
+			case w.Address&(1<<bit) != 0:
+				octet.WriteByte('1')
+			default:
+				octet.WriteByte('0')
+			}
+		}
+		parts = append(parts, octet.String())
+	}
+	return strings.Join(parts, ".")
+}
+
+// Addresses returns the matching addresses in ascending order, up to limit
+func (w *WildcardMatch) Addresses(limit int) []uint32 {
+	var result []uint32
+	for i := uint64(0); i < w.Count() && len(result) < limit; i++ {
+		result = append(result, w.Address|depositBits(i, w.Wildcard))
+	}
+	return result
+}
+
+// CIDRCount returns the number of networks in the minimal exact CIDR cover
+func (w *WildcardMatch) CIDRCount() uint64 {
+	return uint64(1) << (bits.OnesCount32(w.Wildcard) - bits.TrailingZeros32(^w.Wildcard))
+}
+
+// CIDRs returns the minimal set of networks matching exactly the same
+// addresses as the wildcard, in ascending order, up to limit.
+// The trailing wildcard bits form the host part of each network; every
+// other wildcard bit doubles the number of networks.
+func (w *WildcardMatch) CIDRs(limit int) []string {
+	hostBits := bits.TrailingZeros32(^w.Wildcard)
+	networkWildcard := w.Wildcard
+	if hostBits < 32 {
+		networkWildcard &^= (1 << hostBits) - 1
+	} else {
+		networkWildcard = 0
+	}
+
+	var result []string
+	for i := uint64(0); i < w.CIDRCount() && len(result) < limit; i++ {
+		networkID := w.Address | depositBits(i, networkWildcard)
+		result = append(result, fmt.Sprintf("%s/%d", IPToString(networkID), 32-hostBits))
+	}
+	return result
+}
+
+// depositBits scatters the low bits of value into the set bit positions of mask,
+// lowest bit first, so increasing values produce increasing results
+func depositBits(value uint64, mask uint32) uint32 {
+	var result uint32
+	for bit := 0; bit < 32 && value != 0; bit++ {
+		if mask&(1<<bit) == 0 {
+			continue
+		}
+		if value&1 != 0 {
+			result |= 1 << bit
+		}
+		value >>= 1
+	}
+	return result
+}
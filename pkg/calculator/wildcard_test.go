@@ -0,0 +1,119 @@
+package calculator
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestParseWildcardMatch(t *testing.T) {
+	tests := []struct {
+		ip, wildcard string
+		pattern      string
+		contiguous   bool
+		count        uint64
+		addresses    []string // the first three
+		cidrs        []string // the first three
+		err          error
+	}{
+		{
+			ip: "10.0.0.0", wildcard: "0.0.0.255",
+			pattern: "00001010.00000000.00000000.xxxxxxxx", contiguous: true, count: 256,
+			addresses: []string{"10.0.0.0", "10.0.0.1", "10.0.0.2"},
+			cidrs:     []string{"10.0.0.0/24"},
+		},
+		{
+			// Host bits set in the address are cleared
+			ip: "192.168.1.77", wildcard: "0.0.0.63",
+			pattern: "11000000.10101000.00000001.01xxxxxx", contiguous: true, count: 64,
+			addresses: []string{"192.168.1.64", "192.168.1.65", "192.168.1.66"},
+			cidrs:     []string{"192.168.1.64/26"},
+		},
+		{
+			// Odd third octets
+			ip: "10.0.1.0", wildcard: "0.0.254.255",
+			pattern: "00001010.00000000.xxxxxxx1.xxxxxxxx", count: 32768,
+			addresses: []string{"10.0.1.0", "10.0.1.1", "10.0.1.2"},
+			cidrs:     []string{"10.0.1.0/24", "10.0.3.0/24", "10.0.5.0/24"},
+		},
+		{
+			// Every eighth address, with no host part
+			ip: "10.0.0.7", wildcard: "0.0.0.248",
+			pattern: "00001010.00000000.00000000.xxxxx111", count: 32,
+			addresses: []string{"10.0.0.7", "10.0.0.15", "10.0.0.23"},
+			cidrs:     []string{"10.0.0.7/32", "10.0.0.15/32", "10.0.0.23/32"},
+		},
+		{
+			ip: "172.16.5.9", wildcard: "0.0.0.0",
+			pattern: "10101100.00010000.00000101.00001001", contiguous: true, count: 1,
+			addresses: []string{"172.16.5.9"},
+			cidrs:     []string{"172.16.5.9/32"},
+		},
+		{
+			ip: "10.0.0.0", wildcard: "255.255.255.255",
+			pattern: "xxxxxxxx.xxxxxxxx.xxxxxxxx.xxxxxxxx", contiguous: true, count: 1 << 32,
+			addresses: []string{"0.0.0.0", "0.0.0.1", "0.0.0.2"},
+			cidrs:     []string{"0.0.0.0/0"},
+		},
+		{ip: "10.0.0.300", wildcard: "0.0.0.255", err: ErrInvalidAddress},
+		{ip: "10.0.0.0", wildcard: "0.0.0.256", err: ErrInvalidWildcard},
+	}
+
+	for _, tt := range tests {
+		match, err := ParseWildcardMatch(tt.ip, tt.wildcard)
+		if tt.err != nil {
+			if !errors.Is(err, tt.err) {
+				t.Errorf("ParseWildcardMatch(%q, %q) error = %v, want %v", tt.ip, tt.wildcard, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseWildcardMatch(%q, %q) failed: %v", tt.ip, tt.wildcard, err)
+			continue
+		}
+
+		var addresses []string
+		for _, ip := range match.Addresses(3) {
+			addresses = append(addresses, IPToString(ip))
+		}
+		if match.Pattern() != tt.pattern || match.IsContiguous() != tt.contiguous || match.Count() != tt.count {
+			t.Errorf("%s %s = %s contiguous %v count %d, want %s %v %d", tt.ip, tt.wildcard,
+				match.Pattern(), match.IsContiguous(), match.Count(), tt.pattern, tt.contiguous, tt.count)
+		}
+		if !slices.Equal(addresses, tt.addresses) {
+			t.Errorf("%s %s Addresses(3) = %v, want %v", tt.ip, tt.wildcard, addresses, tt.addresses)
+		}
+		if cidrs := match.CIDRs(3); !slices.Equal(cidrs, tt.cidrs) {
+			t.Errorf("%s %s CIDRs(3) = %v, want %v", tt.ip, tt.wildcard, cidrs, tt.cidrs)
+		}
+	}
+}
+
+func TestWildcardMatches(t *testing.T) {
+	match, err := ParseWildcardMatch("10.0.1.0", "0.0.254.255")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{"10.0.1.0", true},
+		{"10.0.1.255", true},
+		{"10.0.255.7", true},
+		{"10.0.0.1", false},
+		{"10.0.2.1", false},
+		{"10.1.1.1", false},
+	}
+
+	for _, tt := range tests {
+		ip, _ := ParseIPv4(tt.ip)
+		if got := match.Matches(ip); got != tt.want {
+			t.Errorf("Matches(%s) = %v, want %v", tt.ip, got, tt.want)
+		}
+	}
+	if got := match.CIDRCount(); got != 128 {
+		t.Errorf("CIDRCount() = %d, want 128", got)
+	}
+}
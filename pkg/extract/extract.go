@@ -0,0 +1,228 @@
+// Package extract finds addresses and their masks in pasted interface and
+// router configuration text
+package extract
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/netip"
+	"strings"
+
+	"github.com/neontowel/ipcalc-go/pkg/calculator"
+)
+
+// Source is the kind of text an address was found in
+type Source string
+
+const (
+	Ifconfig Source = "ifconfig"
+	IPRoute2 Source = "iproute2"
+	IOS      Source = "IOS"
+	Junos    Source = "Junos"
+)
+
+// Entry is an address with its mask found on one line of text
+type Entry struct {
+	Line      int    // Line number in the text, 0 if unknown
+	Text      string // The line without surrounding whitespace
+	Source    Source
+	Address   string // Address, with a zone ID if the text has one
+	Mask      string // Prefix length or netmask as written, such as 24 or 0xffffff00
+	Broadcast string // Broadcast address as written, if any
+	IPv6      bool
+}
+
+// Load reads free text, such as the output of ifconfig or ip addr or a
+// router configuration, and returns every address/mask it finds. Lines
+// without one are skipped.
+func Load(r io.Reader) ([]Entry, error) {
+	var entries []Entry
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+
+	for scanner.Scan() {
+		lineNumber++
+		entry, ok := ParseLine(scanner.Text())
+		if !ok {
+			continue
+		}
+		entry.Line = lineNumber
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// ParseLine finds an address/mask on one line. It understands:
+//
+//   - inet 10.0.0.5 netmask 0xffffff00 broadcast 10.0.0.255 (BSD and
+//     Linux ifconfig), inet6 fe80::1%en0 prefixlen 64 and the older
+//     inet addr:10.0.0.5 Bcast:10.0.0.255 Mask:255.255.255.0
+//   - inet 10.0.0.5/24 brd 10.0.0.255 (ip addr)
+//   - ip address 10.0.0.1 255.255.255.0 and ipv6 address 2001:db8::1/64
+//     (Cisco IOS)
+//   - address 10.0.0.1/24; (Junos, also in set commands)
+//
+// It reports false for lines without an address and a mask.
+func ParseLine(line string) (Entry, bool) {
+	fields := strings.Fields(line)
+	for i := 0; i+1 < len(fields); i++ {
+		var entry Entry
+		var ok bool
+		switch fields[i] {
+		case "inet", "inet6":
+			entry, ok = parseInet(fields[i+1:])
+		case "address":
+			if i > 0 && (fields[i-1] == "ip" || fields[i-1] == "ipv6") {
+				entry, ok = parseIOS(fields[i+1:])
+			} else {
+				entry, ok = parseJunos(fields[i+1])
+			}
+		}
+		if ok {
+			entry.Text = strings.TrimSpace(line)
+			entry.IPv6 = strings.Contains(entry.Address, ":")
+			return entry, true
+		}
+	}
+	return Entry{}, false
+}
+
+// parseInet parses the fields after "inet" or "inet6" in ifconfig or ip
+// addr output
+func parseInet(fields []string) (Entry, bool) {
+	address := fields[0]
+	if address == "addr:" && len(fields) > 1 {
+		// net-tools ifconfig writes "inet6 addr: fe80::1/64"
+		fields = fields[1:]
+		address = "addr:" + fields[0]
+	}
+	address, netTools := strings.CutPrefix(address, "addr:")
+
+	// ip addr, and net-tools for IPv6, append the prefix length
+	if address, prefix, found := strings.Cut(address, "/"); found {
+		if !isAddress(address) {
+			return Entry{}, false
+		}
+		if netTools {
+			return Entry{Source: Ifconfig, Address: address, Mask: prefix}, true
+		}
+		return Entry{Source: IPRoute2, Address: address, Mask: prefix, Broadcast: value(fields, "brd")}, true
+	}
+	if !isAddress(address) {
+		return Entry{}, false
+	}
+
+	entry := Entry{Source: Ifconfig, Address: address}
+	for i, field := range fields[1:] {
+		key, rest, _ := strings.Cut(field, ":")
+		switch key {
+		case "netmask", "prefixlen", "broadcast":
+			if i+2 < len(fields) {
+				if key == "broadcast" {
+					entry.Broadcast = fields[i+2]
+				} else {
+					entry.Mask = fields[i+2]
+				}
+			}
+		case "Mask":
+			entry.Mask = rest
+		case "Bcast":
+			entry.Broadcast = rest
+		}
+	}
+	return entry, entry.Mask != ""
+}
+
+// parseIOS parses the fields after "ip address" or "ipv6 address"
+func parseIOS(fields []string) (Entry, bool) {
+	if address, prefix, found := strings.Cut(fields[0], "/"); found {
+		return Entry{Source: IOS, Address: address, Mask: prefix}, isAddress(address)
+	}
+	// "ip address dhcp" and the like have no mask
+	if len(fields) < 2 || !isAddress(fields[0]) {
+		return Entry{}, false
+	}
+	return Entry{Source: IOS, Address: fields[0], Mask: fields[1]}, true
+}
+
+// parseJunos parses the field after "address" in a Junos configuration
+func parseJunos(field string) (Entry, bool) {
+	field = strings.TrimRight(field, ";{")
+	address, prefix, found := strings.Cut(field, "/")
+	if !found || !isAddress(address) {
+		return Entry{}, false
+	}
+	return Entry{Source: Junos, Address: address, Mask: prefix}, true
+}
+
+// value returns the field following key, or "" if there is none
+func value(fields []string, key string) string {
+	for i := 0; i+1 < len(fields); i++ {
+		if fields[i] == key {
+			return fields[i+1]
+		}
+	}
+	return ""
+}
+
+// isAddress reports whether s is an IPv4 or IPv6 address, with an optional
+// zone ID
+func isAddress(s string) bool {
+	_, err := netip.ParseAddr(s)
+	return err == nil
+}
+
+// Check returns the inconsistencies between the parts of an IPv4 entry,
+// such as a broadcast address that does not match the netmask or a host
+// address that is the network or broadcast address, along with the
+// calculated network
+func (e Entry) Check() (*calculator.IPv4Network, []string, error) {
+	network, err := calculator.CalculateNetwork(e.Address, e.Mask)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var problems []string
+	if e.Broadcast != "" {
+		broadcast, err := calculator.ParseIPv4(e.Broadcast)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("broadcast %s is not a valid address", e.Broadcast))
+		} else if network.BitCount < 31 && broadcast != network.Broadcast {
+			problems = append(problems, fmt.Sprintf("broadcast %s does not match the netmask (expected %s)",
+				e.Broadcast, calculator.IPToString(network.Broadcast)))
+		}
+	}
+	if network.BitCount < 31 {
+		switch network.Address {
+		case network.NetworkID:
+			problems = append(problems, "the address is the network address")
+		case network.Broadcast:
+			problems = append(problems, "the address is the broadcast address")
+		}
+	}
+	return network, problems, nil
+}
+
+// CheckIPv6 returns the inconsistencies of an IPv6 entry, such as a host
+// address that is the subnet-router anycast address, along with the
+// calculated network
+func (e Entry) CheckIPv6() (*calculator.IPv6Network, []string, error) {
+	network, err := calculator.CalculateIPv6Network(e.Address, e.Mask)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var problems []string
+	if e.Broadcast != "" {
+		problems = append(problems, fmt.Sprintf("broadcast %s is given, but IPv6 has no broadcast addresses", e.Broadcast))
+	}
+	if network.PrefixLen < 127 && network.Address.Cmp(network.NetworkID) == 0 {
+		problems = append(problems, "the address is the subnet-router anycast address (RFC 4291)")
+	}
+	return network, problems, nil
+}
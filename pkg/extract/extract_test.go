@@ -0,0 +1,172 @@
+package extract
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/neontowel/ipcalc-go/pkg/calculator"
+)
+
+func TestParseLine(t *testing.T) {
+	tests := []struct {
+		line string
+		want Entry // zero when the line holds no address/mask
+	}{
+		{
+			line: "\tinet 10.0.0.5 netmask 0xffffff00 broadcast 10.0.0.255",
+			want: Entry{Source: Ifconfig, Address: "10.0.0.5", Mask: "0xffffff00", Broadcast: "10.0.0.255"},
+		},
+		{
+			line: "        inet 192.168.1.10  netmask 255.255.255.0  broadcast 192.168.1.255",
+			want: Entry{Source: Ifconfig, Address: "192.168.1.10", Mask: "255.255.255.0", Broadcast: "192.168.1.255"},
+		},
+		{
+			line: "\tinet6 fe80::1%en0 prefixlen 64 scopeid 0x4",
+			want: Entry{Source: Ifconfig, Address: "fe80::1%en0", Mask: "64", IPv6: true},
+		},
+		{
+			line: "          inet addr:10.0.0.5  Bcast:10.0.0.255  Mask:255.255.255.0",
+			want: Entry{Source: Ifconfig, Address: "10.0.0.5", Mask: "255.255.255.0", Broadcast: "10.0.0.255"},
+		},
+		{
+			line: "          inet6 addr: fe80::1/64 Scope:Link",
+			want: Entry{Source: Ifconfig, Address: "fe80::1", Mask: "64", IPv6: true},
+		},
+		{
+			line: "    inet 10.0.0.5/24 brd 10.0.0.255 scope global eth0",
+			want: Entry{Source: IPRoute2, Address: "10.0.0.5", Mask: "24", Broadcast: "10.0.0.255"},
+		},
+		{
+			line: "    inet6 2001:db8::5/64 scope global dynamic",
+			want: Entry{Source: IPRoute2, Address: "2001:db8::5", Mask: "64", IPv6: true},
+		},
+		{
+			line: " ip address 10.0.0.1 255.255.255.0",
+			want: Entry{Source: IOS, Address: "10.0.0.1", Mask: "255.255.255.0"},
+		},
+		{
+			line: " ipv6 address 2001:db8::1/64",
+			want: Entry{Source: IOS, Address: "2001:db8::1", Mask: "64", IPv6: true},
+		},
+		{
+			line: "                address 10.0.0.1/24;",
+			want: Entry{Source: Junos, Address: "10.0.0.1", Mask: "24"},
+		},
+		{
+			line: "set interfaces ge-0/0/0 unit 0 family inet address 10.0.0.1/30",
+			want: Entry{Source: Junos, Address: "10.0.0.1", Mask: "30"},
+		},
+		{line: ""},
+		{line: "eth0: flags=4163<UP,BROADCAST,RUNNING,MULTICAST>  mtu 1500"},
+		{line: " ip address dhcp"},
+		{line: "\tinet 10.0.0.5"},
+		{line: "    inet 10.0.0.300/24 brd 10.0.0.255"},
+		{line: "    address 10.0.0.1;"},
+		{line: "default via 192.168.1.1 dev eth0"},
+	}
+
+	for _, tt := range tests {
+		entry, ok := ParseLine(tt.line)
+		if tt.want.Address == "" {
+			if ok {
+				t.Errorf("ParseLine(%q) = %+v, want no entry", tt.line, entry)
+			}
+			continue
+		}
+		tt.want.Text = strings.TrimSpace(tt.line)
+		if !ok || entry != tt.want {
+			t.Errorf("ParseLine(%q) = %+v, %v, want %+v", tt.line, entry, ok, tt.want)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	entries, err := Load(strings.NewReader(`eth0: flags=4163<UP,BROADCAST,RUNNING,MULTICAST>  mtu 1500
+        inet 192.168.1.10  netmask 255.255.255.0  broadcast 192.168.1.255
+        inet6 fe80::1  prefixlen 64  scopeid 0x20<link>
+        ether 00:11:22:33:44:55  txqueuelen 1000  (Ethernet)
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, entry := range entries {
+		got = append(got, entry.Address+"/"+entry.Mask)
+		if entry.Line != len(got)+1 {
+			t.Errorf("%s is on line %d, want %d", entry.Address, entry.Line, len(got)+1)
+		}
+	}
+	if want := []string{"192.168.1.10/255.255.255.0", "fe80::1/64"}; !slices.Equal(got, want) {
+		t.Errorf("Load() = %v, want %v", got, want)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		entry    Entry
+		network  string
+		problems []string
+		err      bool
+	}{
+		{entry: Entry{Address: "10.0.0.5", Mask: "24", Broadcast: "10.0.0.255"}, network: "10.0.0.0"},
+		{entry: Entry{Address: "10.0.0.5", Mask: "0xffffff00"}, network: "10.0.0.0"},
+		{
+			entry:   Entry{Address: "10.0.0.5", Mask: "255.255.255.0", Broadcast: "10.0.255.255"},
+			network: "10.0.0.0", problems: []string{"broadcast 10.0.255.255 does not match the netmask (expected 10.0.0.255)"},
+		},
+		{
+			entry:   Entry{Address: "10.0.0.5", Mask: "24", Broadcast: "10.0.0.256"},
+			network: "10.0.0.0", problems: []string{"broadcast 10.0.0.256 is not a valid address"},
+		},
+		{entry: Entry{Address: "10.0.0.0", Mask: "24"}, network: "10.0.0.0", problems: []string{"the address is the network address"}},
+		{entry: Entry{Address: "10.0.0.255", Mask: "24"}, network: "10.0.0.0", problems: []string{"the address is the broadcast address"}},
+		// Point-to-point links use both addresses
+		{entry: Entry{Address: "10.0.0.0", Mask: "31"}, network: "10.0.0.0"},
+		{entry: Entry{Address: "10.0.0.5", Mask: "33"}, err: true},
+	}
+
+	for _, tt := range tests {
+		network, problems, err := tt.entry.Check()
+		if tt.err {
+			if err == nil {
+				t.Errorf("%s/%s Check() = %v, want an error", tt.entry.Address, tt.entry.Mask, problems)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s/%s Check() failed: %v", tt.entry.Address, tt.entry.Mask, err)
+			continue
+		}
+		if got := calculator.IPToString(network.NetworkID); got != tt.network || !slices.Equal(problems, tt.problems) {
+			t.Errorf("%s/%s Check() = %s, %q, want %s, %q", tt.entry.Address, tt.entry.Mask, got, problems, tt.network, tt.problems)
+		}
+	}
+}
+
+func TestCheckIPv6(t *testing.T) {
+	tests := []struct {
+		entry    Entry
+		problems []string
+	}{
+		{entry: Entry{Address: "2001:db8::5", Mask: "64"}},
+		{entry: Entry{Address: "2001:db8::", Mask: "64"}, problems: []string{"the address is the subnet-router anycast address (RFC 4291)"}},
+		{entry: Entry{Address: "2001:db8::", Mask: "127"}},
+		{
+			entry:    Entry{Address: "2001:db8::5", Mask: "64", Broadcast: "2001:db8::ffff"},
+			problems: []string{"broadcast 2001:db8::ffff is given, but IPv6 has no broadcast addresses"},
+		},
+	}
+
+	for _, tt := range tests {
+		_, problems, err := tt.entry.CheckIPv6()
+		if err != nil || !slices.Equal(problems, tt.problems) {
+			t.Errorf("%s/%s CheckIPv6() = %q, %v, want %q", tt.entry.Address, tt.entry.Mask, problems, err, tt.problems)
+		}
+	}
+
+	if _, _, err := (Entry{Address: "2001:db8::", Mask: "129"}).CheckIPv6(); err == nil {
+		t.Errorf("2001:db8::/129 CheckIPv6() succeeded, want an error")
+	}
+}
@@ -0,0 +1,71 @@
+package formatter
+
+import "strings"
+
+// BoundaryMarker is written between the network and host bits of binary
+// output when OutputFormat.MarkBoundary is set, as the Perl ipcalc does
+const BoundaryMarker = " "
+
+// FormatBits colors the binary form of an address, as returned by
+// calculator.FormatBinary or calculator.FormatIPv6Binary. The first
+// classBits bits are colored as class bits, the rest of the first prefix
+// bits as network bits and the remaining bits as host bits. Separators take
+// the color of the bits before them. With mark set, BoundaryMarker is
+// written before the first host bit.
+func FormatBits(binary string, prefix, classBits int, colors ColorCodes, mark bool) string {
+	var result strings.Builder
+	current := ""
+	bit := 0
+
+	for _, r := range binary {
+		if r == '0' || r == '1' {
+			if mark && bit == prefix {
+				if current != "" {
+					result.WriteString(colors.Reset)
+					current = ""
+				}
+				result.WriteString(BoundaryMarker)
+			}
+
+			color := colors.HostBits
+			switch {
+			case bit < classBits:
+				color = colors.Class
+			case bit < prefix:
+				color = colors.NetworkBits
+			}
+			if color != current {
+				if current != "" {
+					result.WriteString(colors.Reset)
+				}
+				result.WriteString(color)
+				current = color
+			}
+			bit++
+		}
+		result.WriteRune(r)
+	}
+
+	if current != "" {
+		result.WriteString(colors.Reset)
+	}
+	return result.String()
+}
+
+// classBitCount returns the number of leading bits that identify the class
+// of an IPv4 address: 0 for class A, 10 for B, 110 for C, 1110 for D and
+// 1111 for E
+func classBitCount(class string) int {
+	switch class {
+	case "A":
+		return 1
+	case "B":
+		return 2
+	case "C":
+		return 3
+	case "D", "E":
+		return 4
+	default:
+		return 0
+	}
+}
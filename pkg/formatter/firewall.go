@@ -0,0 +1,231 @@
+package formatter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/neontowel/ipcalc-go/pkg/calculator"
+)
+
+// FirewallTarget identifies a firewall configuration syntax
+type FirewallTarget string
+
+// Supported firewall targets
+const (
+	FirewallCisco     FirewallTarget = "cisco"
+	FirewallIptables  FirewallTarget = "iptables"
+	FirewallIp6tables FirewallTarget = "ip6tables"
+	FirewallNftables  FirewallTarget = "nftables"
+	FirewallIpset     FirewallTarget = "ipset"
+	FirewallPF        FirewallTarget = "pf"
+)
+
+// FirewallTargets lists the supported firewall targets
+var FirewallTargets = []FirewallTarget{
+	FirewallCisco,
+	FirewallIptables,
+	FirewallIp6tables,
+	FirewallNftables,
+	FirewallIpset,
+	FirewallPF,
+}
+
+// FirewallOptions controls how networks are turned into firewall rules
+type FirewallOptions struct {
+	Target FirewallTarget
+	Name   string // ACL, chain, set or table name
+	Deny   bool   // Generate deny/drop rules instead of permit/accept
+}
+
+// ParseFirewallTarget parses a firewall target name
+func ParseFirewallTarget(name string) (FirewallTarget, error) {
+	name = strings.ToLower(name)
+	for _, target := range FirewallTargets {
+		if string(target) == name {
+			return target, nil
+		}
+	}
+	return "", fmt.Errorf("unknown firewall target: %s (must be one of cisco, iptables, ip6tables, nftables, ipset, pf)", name)
+}
+
+// FormatFirewall formats a list of networks in CIDR notation as firewall configuration
+func FormatFirewall(networks []string, options FirewallOptions) (string, error) {
+	if options.Name == "" {
+		options.Name = defaultFirewallName(options.Target)
+	}
+
+	var ipv4, ipv6 []string
+	for _, network := range networks {
+		if strings.Contains(network, ":") {
+			ipv6 = append(ipv6, network)
+		} else {
+			ipv4 = append(ipv4, network)
+		}
+	}
+
+	switch options.Target {
+	case FirewallCisco:
+		return formatCiscoACL(ipv4, ipv6, options)
+	case FirewallIptables:
+		return formatIptables(ipv4, ipv6, options), nil
+	case FirewallIp6tables:
+		if len(ipv4) > 0 {
+			return "", fmt.Errorf("ip6tables rules only match IPv6 networks: %s (use iptables)", ipv4[0])
+		}
+		return formatIptables(nil, ipv6, options), nil
+	case FirewallNftables:
+		return formatNftables(ipv4, ipv6, options), nil
+	case FirewallIpset:
+		return formatIpset(ipv4, ipv6, options), nil
+	case FirewallPF:
+		return formatPF(networks, options), nil
+	default:
+		return "", fmt.Errorf("unknown firewall target: %s", options.Target)
+	}
+}
+
+// defaultFirewallName returns the default ACL, chain, set or table name for a target
+func defaultFirewallName(target FirewallTarget) string {
+	if target == FirewallIptables || target == FirewallIp6tables {
+		return "INPUT"
+	}
+	return "ipcalc"
+}
+
+// familyName returns the name for one address family, adding a suffix when both are present
+func familyName(name, suffix string, bothFamilies bool) string {
+	if bothFamilies {
+		return name + suffix
+	}
+	return name
+}
+
+// formatCiscoACL formats networks as Cisco IOS extended access list entries
+func formatCiscoACL(ipv4, ipv6 []string, options FirewallOptions) (string, error) {
+	action := "permit"
+	if options.Deny {
+		action = "deny"
+	}
+
+	var result strings.Builder
+
+	if len(ipv4) > 0 {
+		result.WriteString(fmt.Sprintf("ip access-list extended %s\n", options.Name))
+		for _, cidr := range ipv4 {
+			parts := strings.SplitN(cidr, "/", 2)
+			if len(parts) != 2 {
+				return "", fmt.Errorf("invalid network: %s", cidr)
+			}
+			network, err := calculator.CalculateNetwork(parts[0], parts[1])
+			if err != nil {
+				return "", err
+			}
+
+			// IOS has shorthands for single hosts and the whole address space
+			var source string
+			switch network.BitCount {
+			case 32:
+				source = "host " + calculator.IPToString(network.NetworkID)
+			case 0:
+				source = "any"
+			default:
+				source = fmt.Sprintf("%s %s",
+					calculator.IPToString(network.NetworkID),
+					calculator.IPToString(calculator.GetWildcardMask(network.Netmask)))
+			}
+			result.WriteString(fmt.Sprintf(" %s ip %s any\n", action, source))
+		}
+	}
+
+	if len(ipv6) > 0 {
+		result.WriteString(fmt.Sprintf("ipv6 access-list %s\n", familyName(options.Name, "-v6", len(ipv4) > 0)))
+		for _, cidr := range ipv6 {
+			result.WriteString(fmt.Sprintf(" %s ipv6 %s any\n", action, cidr))
+		}
+	}
+
+	return result.String(), nil
+}
+
+// formatIptables formats networks as iptables and ip6tables commands
+func formatIptables(ipv4, ipv6 []string, options FirewallOptions) string {
+	jump := "ACCEPT"
+	if options.Deny {
+		jump = "DROP"
+	}
+
+	var result strings.Builder
+	for _, cidr := range ipv4 {
+		result.WriteString(fmt.Sprintf("iptables -A %s -s %s -j %s\n", options.Name, cidr, jump))
+	}
+	for _, cidr := range ipv6 {
+		result.WriteString(fmt.Sprintf("ip6tables -A %s -s %s -j %s\n", options.Name, cidr, jump))
+	}
+	return result.String()
+}
+
+// formatNftables formats networks as nftables interval set definitions
+func formatNftables(ipv4, ipv6 []string, options FirewallOptions) string {
+	bothFamilies := len(ipv4) > 0 && len(ipv6) > 0
+
+	var result strings.Builder
+	writeSet := func(name, addrType string, networks []string) {
+		result.WriteString(fmt.Sprintf("set %s {\n", name))
+		result.WriteString(fmt.Sprintf("\ttype %s\n", addrType))
+		result.WriteString("\tflags interval\n")
+		result.WriteString("\telements = {\n")
+		for i, cidr := range networks {
+			separator := ","
+			if i == len(networks)-1 {
+				separator = ""
+			}
+			result.WriteString(fmt.Sprintf("\t\t%s%s\n", cidr, separator))
+		}
+		result.WriteString("\t}\n")
+		result.WriteString("}\n")
+	}
+
+	if len(ipv4) > 0 {
+		writeSet(familyName(options.Name, "_v4", bothFamilies), "ipv4_addr", ipv4)
+	}
+	if len(ipv6) > 0 {
+		writeSet(familyName(options.Name, "_v6", bothFamilies), "ipv6_addr", ipv6)
+	}
+	return result.String()
+}
+
+// formatIpset formats networks as an ipset restore file of hash:net sets
+func formatIpset(ipv4, ipv6 []string, options FirewallOptions) string {
+	bothFamilies := len(ipv4) > 0 && len(ipv6) > 0
+
+	var result strings.Builder
+	writeSet := func(name, family string, networks []string) {
+		result.WriteString(fmt.Sprintf("create %s hash:net family %s -exist\n", name, family))
+		for _, cidr := range networks {
+			result.WriteString(fmt.Sprintf("add %s %s -exist\n", name, cidr))
+		}
+	}
+
+	if len(ipv4) > 0 {
+		writeSet(familyName(options.Name, "-v4", bothFamilies), "inet", ipv4)
+	}
+	if len(ipv6) > 0 {
+		writeSet(familyName(options.Name, "-v6", bothFamilies), "inet6", ipv6)
+	}
+	return result.String()
+}
+
+// formatPF formats networks as a pf table definition
+func formatPF(networks []string, options FirewallOptions) string {
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("table <%s> persist {", options.Name))
+	for i, cidr := range networks {
+		separator := ","
+		if i == len(networks)-1 {
+			separator = ""
+		}
+		result.WriteString(fmt.Sprintf(" \\\n\t%s%s", cidr, separator))
+	}
+	result.WriteString(" }\n")
+	return result.String()
+}
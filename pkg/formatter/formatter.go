@@ -5,13 +5,27 @@ import (
 	"strings"
 
 	"github.com/neontowel/ipcalc-go/pkg/calculator"
+	"github.com/neontowel/ipcalc-go/pkg/routing"
 )
 
 // OutputFormat defines the format for the output
 type OutputFormat struct {
-	UseColor  bool
-	UseHTML   bool
-	UseBinary bool
+	UseColor     bool
+	UseHTML      bool
+	UseBinary    bool
+	MarkBoundary bool // Mark the network/host boundary in binary output
+
+	// Colors replaces DefaultColors for terminal output, if set
+	Colors *ColorCodes
+}
+
+// TerminalColors returns the colors for terminal output: Colors if set,
+// DefaultColors otherwise
+func (f OutputFormat) TerminalColors() ColorCodes {
+	if f.Colors != nil {
+		return *f.Colors
+	}
+	return DefaultColors()
 }
 
 // ColorCodes for terminal output
@@ -24,6 +38,10 @@ type ColorCodes struct {
 	Subnet   string
 	Error    string
 	Wildcard string
+
+	// Bits of binary output; class bits use Class
+	NetworkBits string
+	HostBits    string
 }
 
 // DefaultColors returns the default color codes
@@ -37,6 +55,9 @@ func DefaultColors() ColorCodes {
 		Subnet:   "\033[32m", // Green
 		Error:    "\033[31m", // Red
 		Wildcard: "\033[36m", // Cyan
+
+		NetworkBits: "\033[33m", // Yellow
+		HostBits:    "\033[36m", // Cyan
 	}
 }
 
@@ -51,6 +72,9 @@ func HTMLColors() ColorCodes {
 		Subnet:   "<font color=\"#663366\">",
 		Error:    "<font color=\"#ff0000\">",
 		Wildcard: "<font color=\"#00cccc\">",
+
+		NetworkBits: "<font color=\"#909090\">",
+		HostBits:    "<font color=\"#00cccc\">",
 	}
 }
 
@@ -68,7 +92,7 @@ func FormatIPv4Network(network *calculator.IPv4Network, format OutputFormat) str
 		colors = HTMLColors()
 		lineBreak = "<br>\n"
 	} else if format.UseColor {
-		colors = DefaultColors()
+		colors = format.TerminalColors()
 		lineBreak = "\n"
 	} else {
 		colors = NoColors()
@@ -84,10 +108,9 @@ func FormatIPv4Network(network *calculator.IPv4Network, format OutputFormat) str
 		colors.Reset))
 	
 	if format.UseBinary {
-		result.WriteString(fmt.Sprintf("          %s%s%s", 
-			colors.Binary, 
-			calculator.FormatBinary(network.Address), 
-			colors.Reset))
+		result.WriteString(fmt.Sprintf("%s%s",
+			"          ",
+			FormatBits(calculator.FormatBinary(network.Address), network.BitCount, 0, colors, format.MarkBoundary)))
 	}
 	result.WriteString(lineBreak)
 
@@ -99,10 +122,9 @@ func FormatIPv4Network(network *calculator.IPv4Network, format OutputFormat) str
 		colors.Reset))
 	
 	if format.UseBinary {
-		result.WriteString(fmt.Sprintf("   %s%s%s", 
-			colors.Binary, 
-			calculator.FormatBinary(network.Netmask), 
-			colors.Reset))
+		result.WriteString(fmt.Sprintf("%s%s",
+			"   ",
+			FormatBits(calculator.FormatBinary(network.Netmask), network.BitCount, 0, colors, format.MarkBoundary)))
 	}
 	result.WriteString(lineBreak)
 
@@ -114,15 +136,18 @@ func FormatIPv4Network(network *calculator.IPv4Network, format OutputFormat) str
 		colors.Reset))
 	
 	if format.UseBinary {
-		result.WriteString(fmt.Sprintf("            %s%s%s", 
-			colors.Binary, 
-			calculator.FormatBinary(wildcard), 
-			colors.Reset))
+		result.WriteString(fmt.Sprintf("%s%s",
+			"            ",
+			FormatBits(calculator.FormatBinary(wildcard), network.BitCount, 0, colors, format.MarkBoundary)))
 	}
 	result.WriteString(lineBreak)
 
 	result.WriteString("=>" + lineBreak)
 
+	// The network line also shows the bits that identify the address class,
+	// as long as they are network bits
+	classBits := min(classBitCount(network.Class), network.BitCount)
+
 	// Network line
 	result.WriteString(fmt.Sprintf("Network:   %s%s/%d%s", 
 		colors.Subnet, 
@@ -131,10 +156,9 @@ func FormatIPv4Network(network *calculator.IPv4Network, format OutputFormat) str
 		colors.Reset))
 	
 	if format.UseBinary {
-		result.WriteString(fmt.Sprintf("       %s%s%s", 
-			colors.Binary, 
-			calculator.FormatBinary(network.NetworkID), 
-			colors.Reset))
+		result.WriteString(fmt.Sprintf("%s%s",
+			"       ",
+			FormatBits(calculator.FormatBinary(network.NetworkID), network.BitCount, classBits, colors, format.MarkBoundary)))
 	}
 	result.WriteString(lineBreak)
 
@@ -145,10 +169,9 @@ func FormatIPv4Network(network *calculator.IPv4Network, format OutputFormat) str
 		colors.Reset))
 	
 	if format.UseBinary {
-		result.WriteString(fmt.Sprintf("          %s%s%s", 
-			colors.Binary, 
-			calculator.FormatBinary(network.HostMin), 
-			colors.Reset))
+		result.WriteString(fmt.Sprintf("%s%s",
+			"          ",
+			FormatBits(calculator.FormatBinary(network.HostMin), network.BitCount, 0, colors, format.MarkBoundary)))
 	}
 	result.WriteString(lineBreak)
 
@@ -159,10 +182,9 @@ func FormatIPv4Network(network *calculator.IPv4Network, format OutputFormat) str
 		colors.Reset))
 	
 	if format.UseBinary {
-		result.WriteString(fmt.Sprintf("          %s%s%s", 
-			colors.Binary, 
-			calculator.FormatBinary(network.HostMax), 
-			colors.Reset))
+		result.WriteString(fmt.Sprintf("%s%s",
+			"          ",
+			FormatBits(calculator.FormatBinary(network.HostMax), network.BitCount, 0, colors, format.MarkBoundary)))
 	}
 	result.WriteString(lineBreak)
 
@@ -174,14 +196,28 @@ func FormatIPv4Network(network *calculator.IPv4Network, format OutputFormat) str
 			colors.Reset))
 		
 		if format.UseBinary {
-			result.WriteString(fmt.Sprintf("          %s%s%s", 
-				colors.Binary, 
-				calculator.FormatBinary(network.Broadcast), 
-				colors.Reset))
+			result.WriteString(fmt.Sprintf("%s%s",
+				"          ",
+				FormatBits(calculator.FormatBinary(network.Broadcast), network.BitCount, 0, colors, format.MarkBoundary)))
 		}
 		result.WriteString(lineBreak)
 	}
 
+	// Reserved lines (only for cloud provider presets)
+	for i, reserved := range network.Reserved {
+		label := "Reserved:"
+		if i > 0 {
+			label = ""
+		}
+		result.WriteString(fmt.Sprintf("%-11s%s%-20s%s %s%s",
+			label,
+			colors.Netmask,
+			calculator.IPToString(reserved.Address),
+			colors.Reset,
+			reserved.Purpose,
+			lineBreak))
+	}
+
 	// Hosts/Net line
 	result.WriteString(fmt.Sprintf("Hosts/Net: %s%d%s", 
 		colors.Subnet, 
@@ -210,7 +246,7 @@ func FormatIPv6Network(network *calculator.IPv6Network, format OutputFormat) str
 		colors = HTMLColors()
 		lineBreak = "<br>\n"
 	} else if format.UseColor {
-		colors = DefaultColors()
+		colors = format.TerminalColors()
 		lineBreak = "\n"
 	} else {
 		colors = NoColors()
@@ -219,17 +255,21 @@ func FormatIPv6Network(network *calculator.IPv6Network, format OutputFormat) str
 
 	var result strings.Builder
 
+	address := calculator.IPv6ToString(network.Address)
+	if network.Zone != "" {
+		address += "%" + network.Zone
+	}
+
 	// Address line
 	result.WriteString(fmt.Sprintf("Address: %s%s%s", 
 		colors.Address, 
-		calculator.IPv6ToString(network.Address), 
+		address, 
 		colors.Reset))
 	
 	if format.UseBinary {
-		result.WriteString(fmt.Sprintf("     %s%s%s", 
-			colors.Binary, 
-			calculator.FormatIPv6Binary(network.Address), 
-			colors.Reset))
+		result.WriteString(fmt.Sprintf("%s%s",
+			"     ",
+			FormatBits(calculator.FormatIPv6Binary(network.Address), network.PrefixLen, 0, colors, format.MarkBoundary)))
 	}
 	result.WriteString(lineBreak)
 
@@ -240,10 +280,9 @@ func FormatIPv6Network(network *calculator.IPv6Network, format OutputFormat) str
 		colors.Reset))
 	
 	if format.UseBinary {
-		result.WriteString(fmt.Sprintf("                                      %s%s%s", 
-			colors.Binary, 
-			calculator.FormatIPv6Binary(network.NetworkMask), 
-			colors.Reset))
+		result.WriteString(fmt.Sprintf("%s%s",
+			"                                      ",
+			FormatBits(calculator.FormatIPv6Binary(network.NetworkMask), network.PrefixLen, 0, colors, format.MarkBoundary)))
 	}
 	result.WriteString(lineBreak)
 
@@ -255,10 +294,9 @@ func FormatIPv6Network(network *calculator.IPv6Network, format OutputFormat) str
 		colors.Reset))
 	
 	if format.UseBinary {
-		result.WriteString(fmt.Sprintf("                     %s%s%s", 
-			colors.Binary, 
-			calculator.FormatIPv6Binary(network.NetworkID), 
-			colors.Reset))
+		result.WriteString(fmt.Sprintf("%s%s",
+			"                     ",
+			FormatBits(calculator.FormatIPv6Binary(network.NetworkID), network.PrefixLen, 0, colors, format.MarkBoundary)))
 	}
 	
 	return result.String()
@@ -273,7 +311,7 @@ func FormatDeaggregation(networks []string, format OutputFormat) string {
 		colors = HTMLColors()
 		lineBreak = "<br>\n"
 	} else if format.UseColor {
-		colors = DefaultColors()
+		colors = format.TerminalColors()
 		lineBreak = "\n"
 	} else {
 		colors = NoColors()
@@ -302,7 +340,7 @@ func FormatSplitNetwork(networks []string, format OutputFormat) string {
 		colors = HTMLColors()
 		lineBreak = "<br>\n"
 	} else if format.UseColor {
-		colors = DefaultColors()
+		colors = format.TerminalColors()
 		lineBreak = "\n"
 	} else {
 		colors = NoColors()
@@ -323,6 +361,246 @@ func FormatSplitNetwork(networks []string, format OutputFormat) string {
 	return result.String()
 }
 
+// FormatSubnets formats the results of an equal network split
+func FormatSubnets(subnets []calculator.Subnet, format OutputFormat) string {
+	var colors ColorCodes
+	var lineBreak string
+
+	if format.UseHTML {
+		colors = HTMLColors()
+		lineBreak = "<br>\n"
+	} else if format.UseColor {
+		colors = format.TerminalColors()
+		lineBreak = "\n"
+	} else {
+		colors = NoColors()
+		lineBreak = "\n"
+	}
+
+	var result strings.Builder
+
+	for i, subnet := range subnets {
+		if i > 0 {
+			result.WriteString(lineBreak)
+		}
+
+		result.WriteString(fmt.Sprintf("Network:   %s%s%s%s",
+			colors.Subnet,
+			subnet.Network,
+			colors.Reset,
+			lineBreak))
+		result.WriteString(fmt.Sprintf("HostMin:   %s%s%s%s",
+			colors.Subnet,
+			subnet.HostMin,
+			colors.Reset,
+			lineBreak))
+		result.WriteString(fmt.Sprintf("HostMax:   %s%s%s%s",
+			colors.Subnet,
+			subnet.HostMax,
+			colors.Reset,
+			lineBreak))
+
+		if subnet.Broadcast != "" {
+			result.WriteString(fmt.Sprintf("Broadcast: %s%s%s%s",
+				colors.Subnet,
+				subnet.Broadcast,
+				colors.Reset,
+				lineBreak))
+		}
+
+		result.WriteString(fmt.Sprintf("Hosts/Net: %s%s%s%s",
+			colors.Subnet,
+			subnet.Hosts.String(),
+			colors.Reset,
+			lineBreak))
+	}
+
+	return result.String()
+}
+
+// FormatWildcardMatch formats an address with an arbitrary wildcard mask,
+// listing up to limit matching networks, or matching addresses if listAddresses is set
+func FormatWildcardMatch(match *calculator.WildcardMatch, limit int, listAddresses bool, format OutputFormat) string {
+	var colors ColorCodes
+	var lineBreak string
+
+	if format.UseHTML {
+		colors = HTMLColors()
+		lineBreak = "<br>\n"
+	} else if format.UseColor {
+		colors = format.TerminalColors()
+		lineBreak = "\n"
+	} else {
+		colors = NoColors()
+		lineBreak = "\n"
+	}
+
+	var result strings.Builder
+
+	// Address line
+	result.WriteString(fmt.Sprintf("Address:   %s%s%s",
+		colors.Address,
+		calculator.IPToString(match.Address),
+		colors.Reset))
+
+	if format.UseBinary {
+		result.WriteString(fmt.Sprintf("%s%s%s%s",
+			strings.Repeat(" ", 21-len(calculator.IPToString(match.Address))),
+			colors.Binary,
+			calculator.FormatBinary(match.Address),
+			colors.Reset))
+	}
+	result.WriteString(lineBreak)
+
+	// Wildcard line
+	result.WriteString(fmt.Sprintf("Wildcard:  %s%s%s",
+		colors.Wildcard,
+		calculator.IPToString(match.Wildcard),
+		colors.Reset))
+
+	if format.UseBinary {
+		result.WriteString(fmt.Sprintf("%s%s%s%s",
+			strings.Repeat(" ", 21-len(calculator.IPToString(match.Wildcard))),
+			colors.Binary,
+			calculator.FormatBinary(match.Wildcard),
+			colors.Reset))
+	}
+	result.WriteString(lineBreak)
+
+	// Pattern line
+	result.WriteString(fmt.Sprintf("Pattern:   %s%s%s%s",
+		colors.Binary,
+		match.Pattern(),
+		colors.Reset,
+		lineBreak))
+
+	result.WriteString("=>" + lineBreak)
+
+	// Matches line
+	contiguous := "contiguous"
+	if !match.IsContiguous() {
+		contiguous = "discontiguous"
+	}
+	result.WriteString(fmt.Sprintf("Matches:   %s%d%s addresses, %s wildcard%s",
+		colors.Subnet,
+		match.Count(),
+		colors.Reset,
+		contiguous,
+		lineBreak))
+
+	if listAddresses {
+		addresses := match.Addresses(limit)
+		for _, address := range addresses {
+			result.WriteString(fmt.Sprintf("           %s%s%s%s",
+				colors.Address,
+				calculator.IPToString(address),
+				colors.Reset,
+				lineBreak))
+		}
+		if remaining := match.Count() - uint64(len(addresses)); remaining > 0 {
+			result.WriteString(fmt.Sprintf("           ... %d more%s", remaining, lineBreak))
+		}
+		return result.String()
+	}
+
+	// CIDR cover lines
+	cidrs := match.CIDRs(limit)
+	result.WriteString(fmt.Sprintf("Networks:  %s%d%s%s",
+		colors.Subnet,
+		match.CIDRCount(),
+		colors.Reset,
+		lineBreak))
+	for _, cidr := range cidrs {
+		result.WriteString(fmt.Sprintf("           %s%s%s%s",
+			colors.Subnet,
+			cidr,
+			colors.Reset,
+			lineBreak))
+	}
+	if remaining := match.CIDRCount() - uint64(len(cidrs)); remaining > 0 {
+		result.WriteString(fmt.Sprintf("           ... %d more%s", remaining, lineBreak))
+	}
+
+	return result.String()
+}
+
+// FormatRouteLookup formats the result of looking up an address in a routing table
+func FormatRouteLookup(lookup routing.Result, format OutputFormat) string {
+	var colors ColorCodes
+	var lineBreak string
+
+	if format.UseHTML {
+		colors = HTMLColors()
+		lineBreak = "<br>\n"
+	} else if format.UseColor {
+		colors = format.TerminalColors()
+		lineBreak = "\n"
+	} else {
+		colors = NoColors()
+		lineBreak = "\n"
+	}
+
+	var result strings.Builder
+
+	// writeRoutes writes one labelled line per route, indenting continuation lines
+	writeRoutes := func(label string, routes []routing.Route, color string) {
+		for i, route := range routes {
+			if i > 0 {
+				label = ""
+			}
+			result.WriteString(fmt.Sprintf("%-11s%s%s%s%s",
+				label,
+				color,
+				route.String(),
+				colors.Reset,
+				lineBreak))
+		}
+	}
+
+	// Address line
+	result.WriteString(fmt.Sprintf("Address:   %s%s%s%s",
+		colors.Address,
+		lookup.Address,
+		colors.Reset,
+		lineBreak))
+
+	if len(lookup.Best) == 0 {
+		result.WriteString(fmt.Sprintf("Route:     %sno matching route%s%s",
+			colors.Error,
+			colors.Reset,
+			lineBreak))
+		return result.String()
+	}
+
+	writeRoutes("Route:", lookup.Best, colors.Subnet)
+	if len(lookup.Fallback) > 0 {
+		writeRoutes("Fallback:", lookup.Fallback, colors.Netmask)
+	} else {
+		result.WriteString(fmt.Sprintf("Fallback:  %snone%s%s",
+			colors.Error,
+			colors.Reset,
+			lineBreak))
+	}
+	writeRoutes("Covering:", lookup.Covering, colors.Wildcard)
+
+	return result.String()
+}
+
+// FormatValue formats a single labelled value such as a computed address
+func FormatValue(label, value string, format OutputFormat) string {
+	var colors ColorCodes
+
+	if format.UseHTML {
+		colors = HTMLColors()
+	} else if format.UseColor {
+		colors = format.TerminalColors()
+	} else {
+		colors = NoColors()
+	}
+
+	return fmt.Sprintf("%-11s%s%s%s", label+":", colors.Address, value, colors.Reset)
+}
+
 // FormatHTMLHeader returns the HTML header
 func FormatHTMLHeader() string {
 	return `<!DOCTYPE HTML PUBLIC "-//W3C//DTD HTML 4.01 Transitional//EN">
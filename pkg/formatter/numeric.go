@@ -0,0 +1,195 @@
+package formatter
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/neontowel/ipcalc-go/pkg/calculator"
+)
+
+// NumericForm identifies a numeric representation of addresses
+type NumericForm string
+
+// Supported numeric forms
+const (
+	NumericDecimal NumericForm = "dec" // Decimal integer, such as 3232235777
+	NumericHex     NumericForm = "hex" // Hexadecimal integer and, for IPv4, dotted hex octets
+	NumericOctal   NumericForm = "oct" // Dotted octal octets as inet_aton reads them (IPv4 only)
+)
+
+// NumericForms lists the supported numeric forms
+var NumericForms = []NumericForm{
+	NumericDecimal,
+	NumericHex,
+	NumericOctal,
+}
+
+// ParseNumericForms parses a list of numeric form names. "all" selects
+// every form, and the result is in the order of NumericForms.
+func ParseNumericForms(names []string) ([]NumericForm, error) {
+	selected := make(map[NumericForm]bool)
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "all" {
+			return NumericForms, nil
+		}
+
+		found := false
+		for _, form := range NumericForms {
+			if string(form) == name {
+				selected[form] = true
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown numeric format: %s (must be one of dec, hex, oct, all)", name)
+		}
+	}
+
+	var forms []NumericForm
+	for _, form := range NumericForms {
+		if selected[form] {
+			forms = append(forms, form)
+		}
+	}
+	return forms, nil
+}
+
+// numericRow is one address of a numeric forms table
+type numericRow struct {
+	label  string
+	color  string
+	values []string
+}
+
+// FormatIPv4Numeric formats the address, netmask, network and broadcast
+// address of a network in the given numeric forms, one column per form
+func FormatIPv4Numeric(network *calculator.IPv4Network, forms []NumericForm, format OutputFormat) string {
+	var headers []string
+	for _, form := range forms {
+		switch form {
+		case NumericDecimal:
+			headers = append(headers, "Decimal")
+		case NumericHex:
+			headers = append(headers, "Hex", "Dotted hex")
+		case NumericOctal:
+			headers = append(headers, "Octal")
+		}
+	}
+
+	values := func(ip uint32) []string {
+		var result []string
+		for _, form := range forms {
+			switch form {
+			case NumericDecimal:
+				result = append(result, calculator.FormatDecimal(ip))
+			case NumericHex:
+				result = append(result, calculator.FormatHex(ip), calculator.FormatDottedHex(ip))
+			case NumericOctal:
+				result = append(result, calculator.FormatOctal(ip))
+			}
+		}
+		return result
+	}
+
+	colors := numericColors(format)
+	rows := []numericRow{
+		{"Address:", colors.Address, values(network.Address)},
+		{"Netmask:", colors.Netmask, values(network.Netmask)},
+		{"Network:", colors.Subnet, values(network.NetworkID)},
+	}
+	if network.BitCount < 31 {
+		rows = append(rows, numericRow{"Broadcast:", colors.Subnet, values(network.Broadcast)})
+	}
+	return formatNumericTable(headers, rows, format)
+}
+
+// FormatIPv6Numeric formats the address, netmask and prefix of a network
+// in the given numeric forms. Octal has no IPv6 form and is skipped.
+func FormatIPv6Numeric(network *calculator.IPv6Network, forms []NumericForm, format OutputFormat) string {
+	var headers []string
+	for _, form := range forms {
+		switch form {
+		case NumericDecimal:
+			headers = append(headers, "Decimal")
+		case NumericHex:
+			headers = append(headers, "Hex")
+		}
+	}
+	if len(headers) == 0 {
+		return ""
+	}
+
+	values := func(ip *big.Int) []string {
+		var result []string
+		for _, form := range forms {
+			switch form {
+			case NumericDecimal:
+				result = append(result, calculator.FormatIPv6Decimal(ip))
+			case NumericHex:
+				result = append(result, calculator.FormatIPv6Hex(ip))
+			}
+		}
+		return result
+	}
+
+	colors := numericColors(format)
+	rows := []numericRow{
+		{"Address:", colors.Address, values(network.Address)},
+		{"Netmask:", colors.Netmask, values(network.NetworkMask)},
+		{"Prefix:", colors.Subnet, values(network.NetworkID)},
+	}
+	return formatNumericTable(headers, rows, format)
+}
+
+// numericColors returns the colors for a numeric forms table
+func numericColors(format OutputFormat) ColorCodes {
+	if format.UseHTML {
+		return HTMLColors()
+	} else if format.UseColor {
+		return format.TerminalColors()
+	}
+	return NoColors()
+}
+
+// formatNumericTable lays out a numeric forms table with a header line and
+// aligned columns
+func formatNumericTable(headers []string, rows []numericRow, format OutputFormat) string {
+	lineBreak := "\n"
+	if format.UseHTML {
+		lineBreak = "<br>\n"
+	}
+	colors := numericColors(format)
+
+	widths := make([]int, len(headers))
+	for i, header := range headers {
+		widths[i] = len(header)
+		for _, row := range rows {
+			widths[i] = max(widths[i], len(row.values[i]))
+		}
+	}
+
+	header := fmt.Sprintf("%-11s", "")
+	for i, name := range headers {
+		header += fmt.Sprintf("%-*s", widths[i]+2, name)
+	}
+
+	var result strings.Builder
+	result.WriteString(strings.TrimRight(header, " ") + lineBreak)
+
+	for r, row := range rows {
+		result.WriteString(fmt.Sprintf("%-11s", row.label))
+		for i, value := range row.values {
+			padding := ""
+			if i < len(row.values)-1 {
+				padding = strings.Repeat(" ", widths[i]+2-len(value))
+			}
+			result.WriteString(fmt.Sprintf("%s%s%s%s", row.color, value, colors.Reset, padding))
+		}
+		if r < len(rows)-1 {
+			result.WriteString(lineBreak)
+		}
+	}
+	return result.String()
+}
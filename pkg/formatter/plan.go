@@ -0,0 +1,247 @@
+package formatter
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/neontowel/ipcalc-go/pkg/planner"
+)
+
+// FormatJSON formats any result as indented JSON
+func FormatJSON(value any) (string, error) {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data) + "\n", nil
+}
+
+// FormatVPCPlan formats a VPC plan as a table
+func FormatVPCPlan(plan *planner.VPCPlan, format OutputFormat) string {
+	var colors ColorCodes
+	var lineBreak string
+
+	if format.UseHTML {
+		colors = HTMLColors()
+		lineBreak = "<br>\n"
+	} else if format.UseColor {
+		colors = format.TerminalColors()
+		lineBreak = "\n"
+	} else {
+		colors = NoColors()
+		lineBreak = "\n"
+	}
+
+	var result strings.Builder
+
+	// VPC line
+	provider := ""
+	if plan.Provider != "" {
+		provider = " (" + plan.Provider + ")"
+	}
+	result.WriteString(fmt.Sprintf("VPC:       %s%s%s%s%s",
+		colors.Subnet,
+		plan.CIDR,
+		colors.Reset,
+		provider,
+		lineBreak))
+
+	// Zones line
+	result.WriteString(fmt.Sprintf("Zones:     %s%d x /%d%s, %d spare%s",
+		colors.Netmask,
+		len(plan.Zones),
+		plan.ZonePrefix,
+		colors.Reset,
+		len(plan.SpareZones),
+		lineBreak))
+
+	result.WriteString("=>" + lineBreak)
+
+	// Subnet table
+	tierWidth := len("Tier")
+	for _, tier := range plan.Tiers {
+		tierWidth = max(tierWidth, len(tier))
+	}
+	result.WriteString(fmt.Sprintf("%-6s%-*s  %-20s%s%s", "Zone", tierWidth, "Tier", "Subnet", "Hosts", lineBreak))
+	for _, zone := range plan.Zones {
+		for _, subnet := range zone.Subnets {
+			result.WriteString(fmt.Sprintf("%-6s%-*s  %s%-20s%s%d%s",
+				zone.Name,
+				tierWidth,
+				subnet.Tier,
+				colors.Subnet,
+				subnet.CIDR,
+				colors.Reset,
+				subnet.Hosts,
+				lineBreak))
+		}
+		for _, spare := range zone.Spare {
+			result.WriteString(fmt.Sprintf("%-6s%-*s  %s%s%s%s",
+				zone.Name,
+				tierWidth,
+				"(spare)",
+				colors.Wildcard,
+				spare,
+				colors.Reset,
+				lineBreak))
+		}
+	}
+
+	// Spare zone lines
+	for i, spare := range plan.SpareZones {
+		label := "Spare:"
+		if i > 0 {
+			label = ""
+		}
+		result.WriteString(fmt.Sprintf("%-11s%s%s%s%s",
+			label,
+			colors.Wildcard,
+			spare,
+			colors.Reset,
+			lineBreak))
+	}
+
+	return result.String()
+}
+
+// FormatVPCPlanTerraform formats a VPC plan as a Terraform locals block,
+// with one list of subnets per tier ordered by zone
+func FormatVPCPlanTerraform(plan *planner.VPCPlan) string {
+	var result strings.Builder
+
+	result.WriteString("locals {\n")
+	result.WriteString(fmt.Sprintf("  vpc_cidr = %q\n", plan.CIDR))
+
+	for _, tier := range plan.Tiers {
+		var subnets []string
+		for _, zone := range plan.Zones {
+			for _, subnet := range zone.Subnets {
+				if subnet.Tier == tier {
+					subnets = append(subnets, fmt.Sprintf("%q", subnet.CIDR))
+				}
+			}
+		}
+		result.WriteString(fmt.Sprintf("  %s_subnets = [%s]\n", terraformIdentifier(tier), strings.Join(subnets, ", ")))
+	}
+
+	var spare []string
+	for _, cidr := range plan.SpareZones {
+		spare = append(spare, fmt.Sprintf("%q", cidr))
+	}
+	result.WriteString(fmt.Sprintf("  spare_zone_cidrs = [%s]\n", strings.Join(spare, ", ")))
+	result.WriteString("}\n")
+
+	return result.String()
+}
+
+// terraformIdentifier replaces characters Terraform does not allow in identifiers
+func terraformIdentifier(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-' {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// FormatKubernetesPlan formats a Kubernetes cluster network plan, followed
+// by the matching control plane flags
+func FormatKubernetesPlan(plan *planner.KubernetesPlan, format OutputFormat) string {
+	var colors ColorCodes
+	var lineBreak string
+
+	if format.UseHTML {
+		colors = HTMLColors()
+		lineBreak = "<br>\n"
+	} else if format.UseColor {
+		colors = format.TerminalColors()
+		lineBreak = "\n"
+	} else {
+		colors = NoColors()
+		lineBreak = "\n"
+	}
+
+	var result strings.Builder
+
+	// Node network line
+	result.WriteString(fmt.Sprintf("Nodes:     %s%s%s%s",
+		colors.Subnet,
+		plan.NodeCIDR,
+		colors.Reset,
+		lineBreak))
+
+	var clusterCIDRs, serviceCIDRs []string
+	var maskFlags, maskSizes []string
+	families := []struct {
+		name string
+		plan *planner.KubernetesFamilyPlan
+	}{{"ipv4", plan.IPv4}, {"ipv6", plan.IPv6}}
+	for _, family := range families {
+		if family.plan == nil {
+			continue
+		}
+		result.WriteString("=>" + lineBreak)
+
+		// Pod network lines
+		result.WriteString(fmt.Sprintf("Pods:      %s%s%s%s",
+			colors.Subnet,
+			family.plan.PodCIDR,
+			colors.Reset,
+			lineBreak))
+		result.WriteString(fmt.Sprintf("Per node:  %s/%d%s (%d addresses)%s",
+			colors.Netmask,
+			family.plan.NodeMaskSize,
+			colors.Reset,
+			family.plan.AddressesByNode,
+			lineBreak))
+		result.WriteString(fmt.Sprintf("Max nodes: %s%d%s%s",
+			colors.Address,
+			family.plan.NodeCapacity,
+			colors.Reset,
+			lineBreak))
+
+		// Service network lines
+		result.WriteString(fmt.Sprintf("Services:  %s%s%s%s",
+			colors.Subnet,
+			family.plan.ServiceCIDR,
+			colors.Reset,
+			lineBreak))
+		result.WriteString(fmt.Sprintf("Max svcs:  %s%d%s%s",
+			colors.Address,
+			family.plan.ServiceCapacity,
+			colors.Reset,
+			lineBreak))
+
+		clusterCIDRs = append(clusterCIDRs, family.plan.PodCIDR)
+		serviceCIDRs = append(serviceCIDRs, family.plan.ServiceCIDR)
+		maskFlags = append(maskFlags, fmt.Sprintf("--node-cidr-mask-size-%s=%d", family.name, family.plan.NodeMaskSize))
+		maskSizes = append(maskSizes, fmt.Sprintf("--node-cidr-mask-size=%d", family.plan.NodeMaskSize))
+	}
+
+	// Single-stack clusters use the unsuffixed mask size flag
+	if len(maskFlags) == 1 {
+		maskFlags = maskSizes
+	}
+
+	result.WriteString("=>" + lineBreak)
+	result.WriteString(fmt.Sprintf("kube-controller-manager --cluster-cidr=%s %s --service-cluster-ip-range=%s%s",
+		strings.Join(clusterCIDRs, ","),
+		strings.Join(maskFlags, " "),
+		strings.Join(serviceCIDRs, ","),
+		lineBreak))
+	result.WriteString(fmt.Sprintf("kube-apiserver --service-cluster-ip-range=%s%s",
+		strings.Join(serviceCIDRs, ","),
+		lineBreak))
+
+	// Warning lines
+	for _, warning := range plan.Warnings {
+		result.WriteString(fmt.Sprintf("%sWarning:%s %s%s",
+			colors.Error,
+			colors.Reset,
+			warning,
+			lineBreak))
+	}
+
+	return result.String()
+}
@@ -0,0 +1,220 @@
+package formatter
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// themes are the built-in terminal color themes by name
+var themes = map[string]func() ColorCodes{
+	"default":         DefaultColors,
+	"none":            NoColors,
+	"bright":          brightColors,
+	"solarized-dark":  solarizedDarkColors,
+	"solarized-light": solarizedLightColors,
+}
+
+// brightColors uses the bright variants of the basic ANSI colors, which
+// stay readable on dark backgrounds
+func brightColors() ColorCodes {
+	return ColorCodes{
+		Reset:    "\033[0m",
+		Address:  "\033[94m", // Bright blue
+		Netmask:  "\033[91m", // Bright red
+		Binary:   "\033[93m", // Bright yellow
+		Class:    "\033[95m", // Bright magenta
+		Subnet:   "\033[92m", // Bright green
+		Error:    "\033[91m", // Bright red
+		Wildcard: "\033[96m", // Bright cyan
+
+		NetworkBits: "\033[93m", // Bright yellow
+		HostBits:    "\033[96m", // Bright cyan
+	}
+}
+
+// solarizedDarkColors uses the Solarized accent colors in truecolor, so
+// they do not depend on how the terminal maps the basic ANSI colors
+func solarizedDarkColors() ColorCodes {
+	return ColorCodes{
+		Reset:    "\033[0m",
+		Address:  "\033[38;2;38;139;210m",  // Blue
+		Netmask:  "\033[38;2;203;75;22m",   // Orange
+		Binary:   "\033[38;2;147;161;161m", // Base1
+		Class:    "\033[38;2;211;54;130m",  // Magenta
+		Subnet:   "\033[38;2;133;153;0m",   // Green
+		Error:    "\033[38;2;220;50;47m",   // Red
+		Wildcard: "\033[38;2;42;161;152m",  // Cyan
+
+		NetworkBits: "\033[38;2;181;137;0m",  // Yellow
+		HostBits:    "\033[38;2;42;161;152m", // Cyan
+	}
+}
+
+// solarizedLightColors is solarizedDarkColors with the content tone for a
+// light background
+func solarizedLightColors() ColorCodes {
+	colors := solarizedDarkColors()
+	colors.Binary = "\033[38;2;88;110;117m" // Base01
+	return colors
+}
+
+// LookupTheme returns the terminal colors of a built-in theme
+func LookupTheme(name string) (ColorCodes, error) {
+	theme, ok := themes[strings.ToLower(name)]
+	if !ok {
+		return ColorCodes{}, fmt.Errorf("unknown color theme: %s (must be one of %s)", name, strings.Join(ThemeNames(), ", "))
+	}
+	return theme(), nil
+}
+
+// ThemeNames returns the names of the built-in themes in order
+func ThemeNames() []string {
+	names := make([]string, 0, len(themes))
+	for name := range themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ThemeRoles are the roles a theme assigns colors to
+var ThemeRoles = []string{"address", "netmask", "network-bits", "host-bits", "class", "error", "binary", "subnet", "wildcard"}
+
+// SetRole sets the color of one role, such as "address" or "network-bits",
+// from a color as ParseColor accepts it
+func (c *ColorCodes) SetRole(role, value string) error {
+	code, err := ParseColor(value)
+	if err != nil {
+		return err
+	}
+
+	switch role {
+	case "address":
+		c.Address = code
+	case "netmask":
+		c.Netmask = code
+	case "network-bits":
+		c.NetworkBits = code
+	case "host-bits":
+		c.HostBits = code
+	case "class":
+		c.Class = code
+	case "error":
+		c.Error = code
+	case "binary":
+		c.Binary = code
+	case "subnet":
+		c.Subnet = code
+	case "wildcard":
+		c.Wildcard = code
+	default:
+		return fmt.Errorf("unknown color role: %s (must be one of %s)", role, strings.Join(ThemeRoles, ", "))
+	}
+
+	// Themes based on "none" have no reset code yet
+	if code != "" {
+		c.Reset = "\033[0m"
+	}
+	return nil
+}
+
+// basicColors are the 16-color names in ANSI order
+var basicColors = []string{"black", "red", "green", "yellow", "blue", "magenta", "cyan", "white"}
+
+// attributes are the text attributes that may precede a color
+var attributes = map[string]string{
+	"bold":      "1",
+	"dim":       "2",
+	"italic":    "3",
+	"underline": "4",
+}
+
+// ParseColor returns the escape code for a color, which is one of:
+//
+//   - a 16-color name such as red or bright-blue
+//   - a number from 0 to 255 for the 256-color palette
+//   - a truecolor value such as #268bd2
+//   - none, for no color
+//
+// Attributes such as bold, dim, italic or underline may precede the color,
+// as in "bold #268bd2".
+func ParseColor(value string) (string, error) {
+	var params []string
+	fields := strings.Fields(strings.ToLower(value))
+	for i, field := range fields {
+		if attribute, ok := attributes[field]; ok {
+			params = append(params, attribute)
+			continue
+		}
+		if i != len(fields)-1 {
+			return "", fmt.Errorf("invalid color: %s (the color must come last)", value)
+		}
+
+		param, err := parseColorParam(field)
+		if err != nil {
+			return "", fmt.Errorf("invalid color: %s (%v)", value, err)
+		}
+		if param != "" {
+			params = append(params, param)
+		}
+	}
+	if len(params) == 0 {
+		return "", nil
+	}
+	return "\033[" + strings.Join(params, ";") + "m", nil
+}
+
+// parseColorParam returns the SGR parameter of a single color
+func parseColorParam(color string) (string, error) {
+	if color == "none" {
+		return "", nil
+	}
+
+	if hex, found := strings.CutPrefix(color, "#"); found {
+		rgb, err := strconv.ParseUint(hex, 16, 32)
+		if err != nil || len(hex) != 6 {
+			return "", fmt.Errorf("truecolor values have 6 hex digits")
+		}
+		return fmt.Sprintf("38;2;%d;%d;%d", rgb>>16, rgb>>8&0xFF, rgb&0xFF), nil
+	}
+
+	if index, err := strconv.Atoi(color); err == nil {
+		if index < 0 || index > 255 {
+			return "", fmt.Errorf("256-color values are between 0 and 255")
+		}
+		return fmt.Sprintf("38;5;%d", index), nil
+	}
+
+	name, bright := strings.CutPrefix(color, "bright-")
+	for i, basic := range basicColors {
+		if name != basic {
+			continue
+		}
+		if bright {
+			return strconv.Itoa(90 + i), nil
+		}
+		return strconv.Itoa(30 + i), nil
+	}
+	return "", fmt.Errorf("must be a color name, a number from 0 to 255 or #rrggbb")
+}
+
+// FormatThemePreview shows sample output in the colors of a theme
+func FormatThemePreview(name string, colors ColorCodes) string {
+	sample := func(label, color, text string) string {
+		return fmt.Sprintf("  %-13s%s%s%s\n", label, color, text, colors.Reset)
+	}
+
+	var result strings.Builder
+	result.WriteString(name + "\n")
+	result.WriteString(sample("address", colors.Address, "192.168.0.1"))
+	result.WriteString(sample("netmask", colors.Netmask, "255.255.255.0 = 24"))
+	result.WriteString(fmt.Sprintf("  %-13s%s\n", "bits", FormatBits("11000000.10101000.00000000.00000001", 24, 3, colors, false)))
+	result.WriteString(sample("class", colors.Class, "Class C"))
+	result.WriteString(sample("subnet", colors.Subnet, "192.168.0.0/24"))
+	result.WriteString(sample("wildcard", colors.Wildcard, "0.0.0.255"))
+	result.WriteString(sample("binary", colors.Binary, "00000000.00000000.00000000.11111111"))
+	result.WriteString(sample("error", colors.Error, "invalid IP address: 192.168.0.300"))
+	return result.String()
+}
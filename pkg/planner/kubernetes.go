@@ -0,0 +1,296 @@
+package planner
+
+import (
+	"fmt"
+	"math/big"
+	"math/bits"
+	"net/netip"
+
+	"github.com/neontowel/ipcalc-go/pkg/calculator"
+)
+
+// KubernetesSpec describes the cluster to plan. Pod and service CIDRs that
+// are not given are allocated from the supernet of the same address family,
+// avoiding the node network. Giving any IPv6 CIDR plans a dual-stack cluster.
+type KubernetesSpec struct {
+	NodeCIDR       string
+	PodCIDRs       []string // At most one per address family
+	ServiceCIDRs   []string // At most one per address family
+	Supernets      []string // At most one per address family
+	MaxNodes       int
+	MaxPodsPerNode int
+	Services       int
+	NodeMaskSize   int // IPv4 per-node pod mask, 0 to size it from MaxPodsPerNode
+	NodeMaskSize6  int // IPv6 per-node pod mask, 0 for the Kubernetes default of /64
+}
+
+// KubernetesPlan is a cluster network plan
+type KubernetesPlan struct {
+	NodeCIDR string                `json:"node_cidr"`
+	IPv4     *KubernetesFamilyPlan `json:"ipv4,omitempty"`
+	IPv6     *KubernetesFamilyPlan `json:"ipv6,omitempty"`
+	Warnings []string              `json:"warnings"`
+}
+
+// KubernetesFamilyPlan holds the pod and service networks of one address family
+type KubernetesFamilyPlan struct {
+	PodCIDR         string   `json:"pod_cidr"`
+	NodeMaskSize    int      `json:"node_cidr_mask_size"`
+	NodeCapacity    uint64   `json:"node_capacity"`
+	AddressesByNode *big.Int `json:"addresses_per_node"`
+	ServiceCIDR     string   `json:"service_cidr"`
+	ServiceCapacity uint64   `json:"service_capacity"`
+}
+
+// Limits enforced by the Kubernetes control plane
+const (
+	maxServiceHostBits   = 20 // kube-apiserver rejects larger service ranges
+	maxNodeMaskExtension = 16 // kube-controller-manager limit between cluster and node masks
+	defaultNodeMaskIPv6  = 64
+)
+
+// familyCIDRs groups the user-supplied CIDRs of one address family
+type familyCIDRs struct {
+	pod, service, supernet netip.Prefix
+}
+
+// PlanKubernetes computes the pod and service networks of a cluster
+func PlanKubernetes(spec KubernetesSpec) (*KubernetesPlan, error) {
+	if spec.MaxNodes < 1 || spec.MaxPodsPerNode < 1 || spec.Services < 1 {
+		return nil, fmt.Errorf("node count, pods per node and service count must be at least 1")
+	}
+
+	node, err := netip.ParsePrefix(spec.NodeCIDR)
+	if err != nil {
+		return nil, fmt.Errorf("invalid node CIDR: %s", spec.NodeCIDR)
+	}
+	node = node.Masked()
+
+	var ipv4, ipv6 familyCIDRs
+	assign := func(kind string, cidrs []string, field func(*familyCIDRs) *netip.Prefix) error {
+		for _, cidr := range cidrs {
+			prefix, err := netip.ParsePrefix(cidr)
+			if err != nil {
+				return fmt.Errorf("invalid %s CIDR: %s", kind, cidr)
+			}
+			family := &ipv4
+			if prefix.Addr().Is6() {
+				family = &ipv6
+			}
+			if field(family).IsValid() {
+				return fmt.Errorf("more than one %s CIDR for the same address family", kind)
+			}
+			*field(family) = prefix.Masked()
+		}
+		return nil
+	}
+	if err := assign("pod", spec.PodCIDRs, func(f *familyCIDRs) *netip.Prefix { return &f.pod }); err != nil {
+		return nil, err
+	}
+	if err := assign("service", spec.ServiceCIDRs, func(f *familyCIDRs) *netip.Prefix { return &f.service }); err != nil {
+		return nil, err
+	}
+	if err := assign("supernet", spec.Supernets, func(f *familyCIDRs) *netip.Prefix { return &f.supernet }); err != nil {
+		return nil, err
+	}
+
+	plan := &KubernetesPlan{NodeCIDR: node.String(), Warnings: []string{}}
+
+	// Check the node network holds every node
+	nodeAddresses := addressCount(node)
+	if node.Addr().Is4() && nodeAddresses > 2 {
+		nodeAddresses -= 2
+	}
+	if nodeAddresses < uint64(spec.MaxNodes) {
+		return nil, fmt.Errorf("node network %s has room for %d nodes, fewer than %d", node, nodeAddresses, spec.MaxNodes)
+	}
+
+	// IPv4 is planned unless only IPv6 networks were given
+	if ipv4 != (familyCIDRs{}) || ipv6 == (familyCIDRs{}) {
+		if !ipv4.supernet.IsValid() {
+			ipv4.supernet = netip.MustParsePrefix("10.0.0.0/8")
+		}
+		nodeMask := spec.NodeMaskSize
+		if nodeMask == 0 {
+			// Reserve twice the pods per node so addresses are not reused
+			// immediately when pods are replaced, as managed Kubernetes does
+			nodeMask = 32 - ceilLog2(uint64(spec.MaxPodsPerNode)*2)
+		}
+		plan.IPv4, err = planFamily(spec, ipv4, node, nodeMask, 32, plan)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if ipv6 != (familyCIDRs{}) {
+		if !ipv6.supernet.IsValid() && (!ipv6.pod.IsValid() || !ipv6.service.IsValid()) {
+			return nil, fmt.Errorf("dual-stack plans need an IPv6 supernet, or both IPv6 pod and service CIDRs")
+		}
+		nodeMask := spec.NodeMaskSize6
+		if nodeMask == 0 {
+			nodeMask = defaultNodeMaskIPv6
+		}
+		plan.IPv6, err = planFamily(spec, ipv6, node, nodeMask, 128, plan)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return plan, nil
+}
+
+// planFamily sizes, allocates and checks the pod and service networks of one address family
+func planFamily(spec KubernetesSpec, cidrs familyCIDRs, node netip.Prefix, nodeMask, width int, plan *KubernetesPlan) (*KubernetesFamilyPlan, error) {
+	family := "IPv4"
+	if width == 128 {
+		family = "IPv6"
+	}
+	if nodeMask < 1 || nodeMask > width {
+		return nil, fmt.Errorf("invalid %s node CIDR mask size: /%d", family, nodeMask)
+	}
+
+	// Check the per-node allocation holds the pods
+	perNode := uint64(1) << min(width-nodeMask, 63)
+	if perNode < uint64(spec.MaxPodsPerNode) {
+		return nil, fmt.Errorf("%s node mask /%d gives each node %d addresses, fewer than %d pods", family, nodeMask, perNode, spec.MaxPodsPerNode)
+	}
+	if width == 32 && perNode > uint64(spec.MaxPodsPerNode)*4 {
+		plan.Warnings = append(plan.Warnings, fmt.Sprintf("%s node mask /%d reserves %d addresses per node for %d pods, wasting %d%% of the pod network",
+			family, nodeMask, perNode, spec.MaxPodsPerNode, 100-uint64(spec.MaxPodsPerNode)*100/perNode))
+	}
+
+	// Size and allocate the pod network
+	podPrefixLen := nodeMask - ceilLog2(uint64(spec.MaxNodes))
+	if podPrefixLen < 0 {
+		return nil, fmt.Errorf("%s pod network for %d nodes with a /%d node mask exceeds the address space", family, spec.MaxNodes, nodeMask)
+	}
+	used := []netip.Prefix{node}
+	pod := cidrs.pod
+	if !pod.IsValid() {
+		var err error
+		pod, err = allocate(cidrs.supernet, podPrefixLen, used)
+		if err != nil {
+			return nil, fmt.Errorf("%s pod network: %w", family, err)
+		}
+	}
+	if nodeMask < pod.Bits() {
+		return nil, fmt.Errorf("%s node mask /%d is larger than the pod network %s", family, nodeMask, pod)
+	}
+	if nodeMask-pod.Bits() > maxNodeMaskExtension {
+		return nil, fmt.Errorf("%s node mask /%d is more than %d bits longer than the pod network %s, which kube-controller-manager rejects",
+			family, nodeMask, maxNodeMaskExtension, pod)
+	}
+	nodeCapacity := uint64(1) << (nodeMask - pod.Bits())
+	if nodeCapacity < uint64(spec.MaxNodes) {
+		return nil, fmt.Errorf("%s pod network %s with a /%d node mask allows only %d nodes, fewer than %d", family, pod, nodeMask, nodeCapacity, spec.MaxNodes)
+	}
+	if nodeCapacity*4 < uint64(spec.MaxNodes)*5 {
+		plan.Warnings = append(plan.Warnings, fmt.Sprintf("%s pod network %s with a /%d node mask limits the cluster to %d nodes, leaving little room to grow",
+			family, pod, nodeMask, nodeCapacity))
+	}
+	used = append(used, pod)
+
+	// Size and allocate the service network; kube-apiserver never hands out
+	// its first and last addresses
+	serviceHostBits := max(ceilLog2(uint64(spec.Services)+2), 1)
+	if serviceHostBits > maxServiceHostBits {
+		return nil, fmt.Errorf("%d services need an %s service network larger than the /%d kube-apiserver allows", spec.Services, family, width-maxServiceHostBits)
+	}
+	service := cidrs.service
+	if !service.IsValid() {
+		var err error
+		service, err = allocate(cidrs.supernet, width-serviceHostBits, used)
+		if err != nil {
+			return nil, fmt.Errorf("%s service network: %w", family, err)
+		}
+	}
+	if width-service.Bits() > maxServiceHostBits {
+		return nil, fmt.Errorf("%s service network %s is larger than the /%d kube-apiserver allows", family, service, width-maxServiceHostBits)
+	}
+	serviceCapacity := addressCount(service) - 2
+	if serviceCapacity < uint64(spec.Services) {
+		return nil, fmt.Errorf("%s service network %s has room for %d services, fewer than %d", family, service, serviceCapacity, spec.Services)
+	}
+
+	// Check that nothing overlaps
+	named := []struct {
+		name   string
+		prefix netip.Prefix
+	}{{"node network", node}, {"pod network", pod}, {"service network", service}}
+	for i := range named {
+		for j := i + 1; j < len(named); j++ {
+			if named[i].prefix.Overlaps(named[j].prefix) {
+				return nil, fmt.Errorf("%s %s overlaps %s %s", named[i].name, named[i].prefix, named[j].name, named[j].prefix)
+			}
+		}
+	}
+
+	return &KubernetesFamilyPlan{
+		PodCIDR:         pod.String(),
+		NodeMaskSize:    nodeMask,
+		NodeCapacity:    nodeCapacity,
+		AddressesByNode: new(big.Int).Lsh(big.NewInt(1), uint(width-nodeMask)),
+		ServiceCIDR:     service.String(),
+		ServiceCapacity: serviceCapacity,
+	}, nil
+}
+
+// allocate returns the first prefix of the given length within the supernet
+// that overlaps none of the used prefixes
+func allocate(supernet netip.Prefix, prefixLen int, used []netip.Prefix) (netip.Prefix, error) {
+	if !supernet.IsValid() {
+		return netip.Prefix{}, fmt.Errorf("no supernet to allocate from")
+	}
+	if prefixLen < supernet.Bits() {
+		return netip.Prefix{}, fmt.Errorf("a /%d does not fit in %s", prefixLen, supernet)
+	}
+
+	width := supernet.Addr().BitLen()
+	size := new(big.Int).Lsh(big.NewInt(1), uint(width-prefixLen))
+	candidate := calculator.BigFromAddr(supernet.Addr())
+
+	for {
+		// Past the top of the address space the candidate no longer fits
+		// in an address, so check its length before converting it
+		if candidate.BitLen() > width {
+			return netip.Prefix{}, fmt.Errorf("no free /%d left in %s", prefixLen, supernet)
+		}
+		prefix := netip.PrefixFrom(calculator.AddrFromBig(candidate, width), prefixLen)
+		if !supernet.Contains(prefix.Addr()) {
+			return netip.Prefix{}, fmt.Errorf("no free /%d left in %s", prefixLen, supernet)
+		}
+
+		var overlap *netip.Prefix
+		for i := range used {
+			if used[i].Overlaps(prefix) {
+				overlap = &used[i]
+				break
+			}
+		}
+		if overlap == nil {
+			return prefix, nil
+		}
+
+		// Skip past the overlapping prefix, to the next aligned candidate
+		end := calculator.BigFromAddr(overlap.Addr())
+		end.Add(end, new(big.Int).Lsh(big.NewInt(1), uint(width-overlap.Bits())))
+		end.Add(end, new(big.Int).Sub(size, big.NewInt(1)))
+		candidate = end.Sub(end, new(big.Int).Mod(end, size))
+		if candidate.Cmp(calculator.BigFromAddr(prefix.Addr())) <= 0 {
+			candidate = new(big.Int).Add(calculator.BigFromAddr(prefix.Addr()), size)
+		}
+	}
+}
+
+// addressCount returns the number of addresses in a prefix, capped at 2^63
+func addressCount(prefix netip.Prefix) uint64 {
+	return uint64(1) << min(prefix.Addr().BitLen()-prefix.Bits(), 63)
+}
+
+// ceilLog2 returns the number of bits needed to number n items
+func ceilLog2(n uint64) int {
+	if n <= 1 {
+		return 0
+	}
+	return bits.Len64(n - 1)
+}
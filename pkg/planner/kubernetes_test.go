@@ -0,0 +1,162 @@
+package planner
+
+import (
+	"net/netip"
+	"strings"
+	"testing"
+)
+
+func TestPlanKubernetes(t *testing.T) {
+	tests := []struct {
+		name     string
+		spec     KubernetesSpec
+		pod      string
+		service  string
+		pod6     string // IPv6 networks of dual-stack plans
+		service6 string
+		warnings int
+		err      string
+	}{
+		{
+			name:    "default supernet",
+			spec:    KubernetesSpec{NodeCIDR: "10.10.0.0/22"},
+			pod:     "10.0.0.0/17",
+			service: "10.0.128.0/22",
+		},
+		{
+			name:    "pod network given",
+			spec:    KubernetesSpec{NodeCIDR: "10.10.0.0/22", PodCIDRs: []string{"10.244.0.0/16"}},
+			pod:     "10.244.0.0/16",
+			service: "10.0.0.0/22",
+		},
+		{
+			name:     "supernet at the top of the address space",
+			spec:     KubernetesSpec{NodeCIDR: "255.255.255.0/24", Supernets: []string{"255.255.0.0/16"}, MaxNodes: 2},
+			pod:      "255.255.0.0/23",
+			service:  "255.255.4.0/22",
+			warnings: 1,
+		},
+		{
+			name:     "dual-stack",
+			spec:     KubernetesSpec{NodeCIDR: "10.10.0.0/22", Supernets: []string{"10.0.0.0/8", "fd00:10::/48"}},
+			pod:      "10.0.0.0/17",
+			service:  "10.0.128.0/22",
+			pod6:     "fd00:10::/57",
+			service6: "fd00:10:0:80::/118",
+		},
+		{
+			name:     "node mask wasting addresses",
+			spec:     KubernetesSpec{NodeCIDR: "10.10.0.0/22", MaxPodsPerNode: 8, NodeMaskSize: 24},
+			pod:      "10.0.0.0/17",
+			service:  "10.0.128.0/22",
+			warnings: 1,
+		},
+		{
+			name: "dual-stack without an IPv6 supernet",
+			spec: KubernetesSpec{NodeCIDR: "10.10.0.0/22", PodCIDRs: []string{"fd00:10::/56"}},
+			err:  "dual-stack plans need an IPv6 supernet, or both IPv6 pod and service CIDRs",
+		},
+		{
+			name: "too many services",
+			spec: KubernetesSpec{NodeCIDR: "10.10.0.0/22", Services: 2000000},
+			err:  "2000000 services need an IPv4 service network larger than the /12 kube-apiserver allows",
+		},
+		{
+			name: "supernet filled to the top of the address space",
+			spec: KubernetesSpec{NodeCIDR: "255.255.0.0/16", Supernets: []string{"255.255.0.0/16"}},
+			err:  "IPv4 pod network: no free /17 left in 255.255.0.0/16",
+		},
+		{
+			name: "pod network at the top of the address space",
+			spec: KubernetesSpec{NodeCIDR: "255.255.0.0/17", Supernets: []string{"255.255.0.0/16"}, MaxNodes: 128},
+			err:  "IPv4 service network: no free /22 left in 255.255.0.0/16",
+		},
+		{
+			name: "pod network too small",
+			spec: KubernetesSpec{NodeCIDR: "10.10.0.0/22", PodCIDRs: []string{"10.244.0.0/24"}},
+			err:  "IPv4 pod network 10.244.0.0/24 with a /24 node mask allows only 1 nodes, fewer than 100",
+		},
+		{
+			name: "node network too small",
+			spec: KubernetesSpec{NodeCIDR: "10.10.0.0/28"},
+			err:  "node network 10.10.0.0/28 has room for 14 nodes, fewer than 100",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := tt.spec
+			if spec.MaxNodes == 0 {
+				spec.MaxNodes = 100
+			}
+			if spec.MaxPodsPerNode == 0 {
+				spec.MaxPodsPerNode = 110
+			}
+			if spec.Services == 0 {
+				spec.Services = 1000
+			}
+
+			plan, err := PlanKubernetes(spec)
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Fatalf("PlanKubernetes() error = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("PlanKubernetes() error = %v", err)
+			}
+			if plan.IPv4.PodCIDR != tt.pod || plan.IPv4.ServiceCIDR != tt.service {
+				t.Errorf("PlanKubernetes() = pods %s, services %s, want %s, %s",
+					plan.IPv4.PodCIDR, plan.IPv4.ServiceCIDR, tt.pod, tt.service)
+			}
+			switch {
+			case tt.pod6 == "" && plan.IPv6 != nil:
+				t.Errorf("PlanKubernetes() planned IPv6 pods %s, want no IPv6 plan", plan.IPv6.PodCIDR)
+			case tt.pod6 != "" && plan.IPv6 == nil:
+				t.Errorf("PlanKubernetes() planned no IPv6, want pods %s", tt.pod6)
+			case tt.pod6 != "" && (plan.IPv6.PodCIDR != tt.pod6 || plan.IPv6.ServiceCIDR != tt.service6):
+				t.Errorf("PlanKubernetes() = IPv6 pods %s, services %s, want %s, %s",
+					plan.IPv6.PodCIDR, plan.IPv6.ServiceCIDR, tt.pod6, tt.service6)
+			}
+			if len(plan.Warnings) != tt.warnings {
+				t.Errorf("PlanKubernetes() warnings = %q, want %d", plan.Warnings, tt.warnings)
+			}
+		})
+	}
+}
+
+func TestAllocate(t *testing.T) {
+	tests := []struct {
+		supernet  string
+		prefixLen int
+		used      []string
+		want      string
+	}{
+		{"10.0.0.0/8", 16, nil, "10.0.0.0/16"},
+		{"10.0.0.0/8", 16, []string{"10.0.0.0/24"}, "10.1.0.0/16"},
+		{"10.0.0.0/8", 24, []string{"10.0.0.0/24", "10.0.1.0/25"}, "10.0.2.0/24"},
+		{"255.255.255.0/24", 25, []string{"255.255.255.0/25"}, "255.255.255.128/25"},
+		{"255.255.255.0/24", 25, []string{"255.255.255.0/25", "255.255.255.128/25"}, ""},
+		{"255.255.255.0/24", 25, []string{"255.255.255.0/24"}, ""},
+		{"ffff:ffff::/32", 48, []string{"ffff:ffff::/32"}, ""},
+		{"fd00::/48", 64, []string{"fd00::/64"}, "fd00:0:0:1::/64"},
+	}
+
+	for _, tt := range tests {
+		var used []netip.Prefix
+		for _, u := range tt.used {
+			used = append(used, netip.MustParsePrefix(u))
+		}
+		got, err := allocate(netip.MustParsePrefix(tt.supernet), tt.prefixLen, used)
+		if tt.want == "" {
+			if err == nil || !strings.HasPrefix(err.Error(), "no free /") {
+				t.Errorf("allocate(%s, /%d, %v) = %v, %v, want no free prefix", tt.supernet, tt.prefixLen, tt.used, got, err)
+			}
+			continue
+		}
+		if err != nil || got.String() != tt.want {
+			t.Errorf("allocate(%s, /%d, %v) = %v, %v, want %s", tt.supernet, tt.prefixLen, tt.used, got, err, tt.want)
+		}
+	}
+}
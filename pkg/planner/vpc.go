@@ -0,0 +1,289 @@
+// Package planner lays out address plans for cloud networks
+package planner
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/neontowel/ipcalc-go/pkg/calculator"
+)
+
+// Tier is one subnet tier of a VPC plan, such as public, private or data.
+// A tier is sized either for a number of hosts or by its relative weight.
+type Tier struct {
+	Name   string
+	Weight int // Share of the space left after host-sized tiers
+	Hosts  int // Required usable hosts; takes precedence over Weight
+}
+
+// VPCSpec describes the VPC to plan
+type VPCSpec struct {
+	CIDR       string
+	Zones      int
+	SpareZones int // Zone-sized blocks kept free for additional zones
+	Tiers      []Tier
+	Provider   *calculator.CloudProvider // nil to reserve only network and broadcast addresses
+}
+
+// VPCPlan is a symmetric subnet layout: every zone holds the same tiers at the same offsets
+type VPCPlan struct {
+	CIDR       string     `json:"cidr"`
+	Provider   string     `json:"provider,omitempty"`
+	ZonePrefix int        `json:"zone_prefix"`
+	Zones      []ZonePlan `json:"zones"`
+	SpareZones []string   `json:"spare_zones"`
+	Tiers      []string   `json:"tiers"`
+}
+
+// ZonePlan is the layout of one availability zone
+type ZonePlan struct {
+	Name    string          `json:"name"`
+	CIDR    string          `json:"cidr"`
+	Subnets []PlannedSubnet `json:"subnets"`
+	Spare   []string        `json:"spare"`
+}
+
+// PlannedSubnet is one tier's subnet in a zone
+type PlannedSubnet struct {
+	Tier  string `json:"tier"`
+	CIDR  string `json:"cidr"`
+	Hosts uint32 `json:"hosts"`
+}
+
+// ParseTier parses a tier specification: "name:weight" sizes the tier by
+// weight, "name:250h" sizes it for 250 usable hosts
+func ParseTier(spec string) (Tier, error) {
+	name, size, found := strings.Cut(spec, ":")
+	if !found {
+		size = "1"
+	}
+	if name == "" {
+		return Tier{}, fmt.Errorf("invalid tier: %s (missing name)", spec)
+	}
+
+	if hosts, ok := strings.CutSuffix(size, "h"); ok {
+		count, err := strconv.Atoi(hosts)
+		if err != nil || count < 1 {
+			return Tier{}, fmt.Errorf("invalid tier host count: %s", spec)
+		}
+		return Tier{Name: name, Hosts: count}, nil
+	}
+
+	weight, err := strconv.Atoi(size)
+	if err != nil || weight < 1 {
+		return Tier{}, fmt.Errorf("invalid tier weight: %s", spec)
+	}
+	return Tier{Name: name, Weight: weight}, nil
+}
+
+// PlanVPC lays out a VPC across availability zones. The VPC is split into
+// equal zone blocks, rounded up to a power of two so spare blocks remain
+// for growth, and each zone block holds the same tier subnets at the same
+// offsets. Space a zone does not use is reported as spare.
+func PlanVPC(spec VPCSpec) (*VPCPlan, error) {
+	if spec.Zones < 1 {
+		return nil, fmt.Errorf("invalid zone count: %d (must be at least 1)", spec.Zones)
+	}
+	if spec.SpareZones < 0 {
+		return nil, fmt.Errorf("invalid spare zone count: %d", spec.SpareZones)
+	}
+	if len(spec.Tiers) == 0 {
+		return nil, fmt.Errorf("at least one tier is required")
+	}
+
+	ipStr, maskStr, found := strings.Cut(spec.CIDR, "/")
+	if !found {
+		return nil, fmt.Errorf("invalid VPC CIDR: %s (missing prefix length)", spec.CIDR)
+	}
+	vpc, err := calculator.CalculateNetwork(ipStr, maskStr)
+	if err != nil {
+		return nil, err
+	}
+
+	// Find the zone block size that fits the zones and spare zones
+	zoneBlocks, err := calculator.SplitNetworkByCount(calculator.IPToString(vpc.NetworkID), maskStr, spec.Zones+spec.SpareZones)
+	if err != nil {
+		return nil, err
+	}
+	_, zonePrefix, _ := strings.Cut(zoneBlocks[0].Network, "/")
+	zoneBits, _ := strconv.Atoi(zonePrefix)
+
+	layout, err := layoutZone(spec.Tiers, zoneBits, spec.Provider)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &VPCPlan{
+		CIDR:       fmt.Sprintf("%s/%d", calculator.IPToString(vpc.NetworkID), vpc.BitCount),
+		ZonePrefix: zoneBits,
+		SpareZones: []string{},
+	}
+	if spec.Provider != nil {
+		plan.Provider = spec.Provider.Name
+	}
+	for _, tier := range spec.Tiers {
+		plan.Tiers = append(plan.Tiers, tier.Name)
+	}
+
+	// Zone blocks after the requested zones are left spare, including the
+	// ones gained by rounding the zone count up to a power of two
+	allZones, err := calculator.SplitNetworkByPrefix(calculator.IPToString(vpc.NetworkID), maskStr, zoneBits)
+	if err != nil {
+		return nil, err
+	}
+	for i, block := range allZones {
+		if i >= spec.Zones {
+			plan.SpareZones = append(plan.SpareZones, block.Network)
+			continue
+		}
+
+		zone, err := placeZone(zoneName(i), block.Network, layout)
+		if err != nil {
+			return nil, err
+		}
+		plan.Zones = append(plan.Zones, zone)
+	}
+
+	return plan, nil
+}
+
+// zoneLayout is the position of each tier subnet relative to the zone start
+type zoneLayout struct {
+	subnets []layoutSubnet
+	used    uint64
+	size    uint64
+}
+
+// layoutSubnet is one tier subnet of a zoneLayout
+type layoutSubnet struct {
+	tier   string
+	offset uint32
+	prefix int
+	hosts  uint32
+}
+
+// layoutZone sizes the tier subnets of a zone and places them, largest
+// first, so every subnet is aligned on its own size
+func layoutZone(tiers []Tier, zonePrefix int, provider *calculator.CloudProvider) (*zoneLayout, error) {
+	zoneSize := uint64(1) << (32 - zonePrefix)
+	reserved := uint64(2)
+	minPrefix, maxPrefix := 0, 30
+	if provider != nil {
+		reserved = uint64(len(provider.Reserved))
+		minPrefix, maxPrefix = provider.MinPrefix, provider.MaxPrefix
+	}
+
+	prefixes := make([]int, len(tiers))
+
+	// Host-sized tiers get the smallest subnet holding their hosts
+	var hostSpace uint64
+	totalWeight := 0
+	for i, tier := range tiers {
+		if tier.Hosts == 0 {
+			totalWeight += tier.Weight
+			continue
+		}
+		prefix := 32
+		for prefix > 0 && (uint64(1)<<(32-prefix)) < uint64(tier.Hosts)+reserved {
+			prefix--
+		}
+		prefix = min(prefix, maxPrefix)
+		if prefix < minPrefix {
+			return nil, fmt.Errorf("tier %s needs a /%d subnet, larger than the /%d limit", tier.Name, prefix, minPrefix)
+		}
+		prefixes[i] = prefix
+		hostSpace += uint64(1) << (32 - prefix)
+	}
+	if hostSpace > zoneSize {
+		return nil, fmt.Errorf("host-sized tiers need %d addresses per zone, but a /%d zone only has %d", hostSpace, zonePrefix, zoneSize)
+	}
+
+	// Weighted tiers share the remaining space, each rounded down to a power of two
+	remaining := zoneSize - hostSpace
+	for i, tier := range tiers {
+		if tier.Hosts != 0 {
+			continue
+		}
+		share := remaining * uint64(tier.Weight) / uint64(totalWeight)
+		prefix := 32
+		for prefix > 0 && (uint64(1)<<(33-prefix)) <= share {
+			prefix--
+		}
+		prefix = max(prefix, minPrefix)
+		if prefix > maxPrefix || (uint64(1)<<(32-prefix)) > share {
+			return nil, fmt.Errorf("tier %s gets %d addresses per zone, less than the smallest allowed /%d subnet", tier.Name, share, maxPrefix)
+		}
+		prefixes[i] = prefix
+	}
+
+	// Place the largest subnets first so each one is aligned
+	order := make([]int, len(tiers))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return prefixes[order[a]] < prefixes[order[b]]
+	})
+
+	layout := &zoneLayout{
+		subnets: make([]layoutSubnet, len(tiers)),
+		size:    zoneSize,
+	}
+	var offset uint64
+	for _, i := range order {
+		size := uint64(1) << (32 - prefixes[i])
+		layout.subnets[i] = layoutSubnet{
+			tier:   tiers[i].Name,
+			offset: uint32(offset),
+			prefix: prefixes[i],
+			hosts:  uint32(size - reserved),
+		}
+		offset += size
+	}
+	layout.used = offset
+
+	return layout, nil
+}
+
+// placeZone applies a zone layout to one zone block
+func placeZone(name, cidr string, layout *zoneLayout) (ZonePlan, error) {
+	ipStr, maskStr, _ := strings.Cut(cidr, "/")
+	block, err := calculator.CalculateNetwork(ipStr, maskStr)
+	if err != nil {
+		return ZonePlan{}, err
+	}
+
+	zone := ZonePlan{Name: name, CIDR: cidr, Spare: []string{}}
+	for _, subnet := range layout.subnets {
+		zone.Subnets = append(zone.Subnets, PlannedSubnet{
+			Tier:  subnet.tier,
+			CIDR:  fmt.Sprintf("%s/%d", calculator.IPToString(block.NetworkID+subnet.offset), subnet.prefix),
+			Hosts: subnet.hosts,
+		})
+	}
+
+	// The space after the last subnet stays free for new tiers
+	if layout.used < layout.size {
+		spare, err := calculator.Deaggregate(
+			calculator.IPToString(block.NetworkID+uint32(layout.used)),
+			calculator.IPToString(block.NetworkID+uint32(layout.size-1)))
+		if err != nil {
+			return ZonePlan{}, err
+		}
+		zone.Spare = spare
+	}
+
+	return zone, nil
+}
+
+// zoneName returns the letter suffix cloud providers use for the Nth zone
+func zoneName(i int) string {
+	name := ""
+	for i >= 0 {
+		name = string(rune('a'+i%26)) + name
+		i = i/26 - 1
+	}
+	return name
+}
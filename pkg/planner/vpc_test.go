@@ -0,0 +1,185 @@
+package planner
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/neontowel/ipcalc-go/pkg/calculator"
+)
+
+func TestParseTier(t *testing.T) {
+	tests := []struct {
+		spec string
+		want Tier
+		err  string
+	}{
+		{spec: "public", want: Tier{Name: "public", Weight: 1}},
+		{spec: "private:3", want: Tier{Name: "private", Weight: 3}},
+		{spec: "data:250h", want: Tier{Name: "data", Hosts: 250}},
+		{spec: ":2", err: "invalid tier: :2 (missing name)"},
+		{spec: "data:0h", err: "invalid tier host count: data:0h"},
+		{spec: "data:xh", err: "invalid tier host count: data:xh"},
+		{spec: "private:0", err: "invalid tier weight: private:0"},
+		{spec: "private:-1", err: "invalid tier weight: private:-1"},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseTier(tt.spec)
+		if tt.err != "" {
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("ParseTier(%q) error = %v, want %q", tt.spec, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ParseTier(%q) = %+v, %v, want %+v", tt.spec, got, err, tt.want)
+		}
+	}
+}
+
+func TestPlanVPC(t *testing.T) {
+	aws, err := calculator.GetCloudProvider("aws")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		spec       VPCSpec
+		zonePrefix int
+		zones      []string // zone blocks
+		subnets    []string // subnets of the first zone, in tier order
+		hosts      []uint32
+		spare      []string // spare space of the first zone
+		spareZones []string
+		err        string
+	}{
+		{
+			name:       "weighted tiers",
+			spec:       VPCSpec{CIDR: "10.0.0.0/16", Zones: 3, Tiers: []Tier{{Name: "public", Weight: 1}, {Name: "private", Weight: 2}}},
+			zonePrefix: 18,
+			zones:      []string{"10.0.0.0/18", "10.0.64.0/18", "10.0.128.0/18"},
+			subnets:    []string{"10.0.32.0/20", "10.0.0.0/19"},
+			hosts:      []uint32{4094, 8190},
+			spare:      []string{"10.0.48.0/20"},
+			spareZones: []string{"10.0.192.0/18"},
+		},
+		{
+			name:       "host-sized tiers",
+			spec:       VPCSpec{CIDR: "10.0.0.0/24", Zones: 2, Tiers: []Tier{{Name: "db", Hosts: 10}, {Name: "web", Hosts: 50}}},
+			zonePrefix: 25,
+			zones:      []string{"10.0.0.0/25", "10.0.0.128/25"},
+			subnets:    []string{"10.0.0.64/28", "10.0.0.0/26"},
+			hosts:      []uint32{14, 62},
+			spare:      []string{"10.0.0.80/28", "10.0.0.96/27"},
+			spareZones: []string{},
+		},
+		{
+			name:       "mixed tiers with spare zones",
+			spec:       VPCSpec{CIDR: "10.1.2.3/22", Zones: 2, SpareZones: 1, Tiers: []Tier{{Name: "web", Hosts: 100}, {Name: "app", Weight: 1}}},
+			zonePrefix: 24,
+			zones:      []string{"10.1.0.0/24", "10.1.1.0/24"},
+			subnets:    []string{"10.1.0.0/25", "10.1.0.128/25"},
+			hosts:      []uint32{126, 126},
+			spare:      []string{},
+			spareZones: []string{"10.1.2.0/24", "10.1.3.0/24"},
+		},
+		{
+			name:       "cloud provider reservations",
+			spec:       VPCSpec{CIDR: "10.0.0.0/24", Zones: 2, Tiers: []Tier{{Name: "web", Hosts: 59}, {Name: "db", Hosts: 11}}, Provider: &aws},
+			zonePrefix: 25,
+			zones:      []string{"10.0.0.0/25", "10.0.0.128/25"},
+			subnets:    []string{"10.0.0.0/26", "10.0.0.64/28"},
+			hosts:      []uint32{59, 11},
+			spare:      []string{"10.0.0.80/28", "10.0.0.96/27"},
+			spareZones: []string{},
+		},
+		{
+			name: "host-sized tiers larger than a zone",
+			spec: VPCSpec{CIDR: "10.0.0.0/24", Zones: 2, Tiers: []Tier{{Name: "web", Hosts: 200}}},
+			err:  "host-sized tiers need 256 addresses per zone, but a /25 zone only has 128",
+		},
+		{
+			name: "weighted tier below the smallest subnet",
+			spec: VPCSpec{CIDR: "10.0.0.0/28", Zones: 4, Tiers: []Tier{{Name: "a", Weight: 1}, {Name: "b", Weight: 1}}},
+			err:  "tier a gets 2 addresses per zone, less than the smallest allowed /30 subnet",
+		},
+		{
+			name: "tier larger than the provider allows",
+			spec: VPCSpec{CIDR: "10.0.0.0/8", Zones: 1, Tiers: []Tier{{Name: "big", Hosts: 100000}}, Provider: &aws},
+			err:  "tier big needs a /15 subnet, larger than the /16 limit",
+		},
+		{
+			name: "too many zones",
+			spec: VPCSpec{CIDR: "10.0.0.0/30", Zones: 5, Tiers: []Tier{{Name: "a", Weight: 1}}},
+			err:  "cannot split /30 into 5 subnets: it holds only 4 addresses",
+		},
+		{
+			name: "no zones",
+			spec: VPCSpec{CIDR: "10.0.0.0/16", Tiers: []Tier{{Name: "a", Weight: 1}}},
+			err:  "invalid zone count: 0 (must be at least 1)",
+		},
+		{
+			name: "no tiers",
+			spec: VPCSpec{CIDR: "10.0.0.0/16", Zones: 2},
+			err:  "at least one tier is required",
+		},
+		{
+			name: "missing prefix length",
+			spec: VPCSpec{CIDR: "10.0.0.0", Zones: 2, Tiers: []Tier{{Name: "a", Weight: 1}}},
+			err:  "invalid VPC CIDR: 10.0.0.0 (missing prefix length)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan, err := PlanVPC(tt.spec)
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Fatalf("PlanVPC() error = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("PlanVPC() error = %v", err)
+			}
+
+			var zones []string
+			for _, zone := range plan.Zones {
+				zones = append(zones, zone.CIDR)
+			}
+			if plan.ZonePrefix != tt.zonePrefix || !slices.Equal(zones, tt.zones) || !slices.Equal(plan.SpareZones, tt.spareZones) {
+				t.Errorf("PlanVPC() = zones /%d %v, spare %v, want /%d %v, spare %v",
+					plan.ZonePrefix, zones, plan.SpareZones, tt.zonePrefix, tt.zones, tt.spareZones)
+			}
+
+			var subnets []string
+			var hosts []uint32
+			for _, subnet := range plan.Zones[0].Subnets {
+				subnets = append(subnets, subnet.CIDR)
+				hosts = append(hosts, subnet.Hosts)
+			}
+			if !slices.Equal(subnets, tt.subnets) || !slices.Equal(hosts, tt.hosts) || !slices.Equal(plan.Zones[0].Spare, tt.spare) {
+				t.Errorf("PlanVPC() zone a = %v hosts %v spare %v, want %v hosts %v spare %v",
+					subnets, hosts, plan.Zones[0].Spare, tt.subnets, tt.hosts, tt.spare)
+			}
+
+			// Every zone holds the same tiers at the same offsets
+			for _, zone := range plan.Zones[1:] {
+				for i, subnet := range zone.Subnets {
+					if subnet.Tier != plan.Tiers[i] || len(zone.Spare) != len(tt.spare) {
+						t.Errorf("PlanVPC() zone %s = %+v, spare %v, not laid out like zone a", zone.Name, zone.Subnets, zone.Spare)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestZoneName(t *testing.T) {
+	for i, want := range map[int]string{0: "a", 1: "b", 25: "z", 26: "aa", 27: "ab", 701: "zz", 702: "aaa"} {
+		if got := zoneName(i); got != want {
+			t.Errorf("zoneName(%d) = %q, want %q", i, got, want)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package prefixtrie
+
+import (
+	"math/bits"
+	"net/netip"
+)
+
+// key holds up to 128 address bits, most significant bit first.
+// IPv4 addresses occupy the top 32 bits of hi.
+type key struct {
+	hi, lo uint64
+}
+
+// keyFromAddr converts an address into a trie key
+func keyFromAddr(addr netip.Addr) key {
+	if addr.Is4() {
+		b := addr.As4()
+		return key{hi: uint64(b[0])<<56 | uint64(b[1])<<48 | uint64(b[2])<<40 | uint64(b[3])<<32}
+	}
+	b := addr.As16()
+	var k key
+	for i := 0; i < 8; i++ {
+		k.hi = k.hi<<8 | uint64(b[i])
+		k.lo = k.lo<<8 | uint64(b[i+8])
+	}
+	return k
+}
+
+// bit returns bit i of the key, counting from the most significant bit
+func (k key) bit(i int) int {
+	if i < 64 {
+		return int(k.hi>>(63-i)) & 1
+	}
+	return int(k.lo>>(127-i)) & 1
+}
+
+// masked returns the key with all bits from position n onwards cleared
+func (k key) masked(n int) key {
+	switch {
+	case n <= 0:
+		return key{}
+	case n < 64:
+		return key{hi: k.hi &^ (^uint64(0) >> n)}
+	case n < 128:
+		return key{hi: k.hi, lo: k.lo &^ (^uint64(0) >> (n - 64))}
+	default:
+		return k
+	}
+}
+
+// commonBits returns the number of leading bits two keys share
+func (k key) commonBits(other key) int {
+	if diff := k.hi ^ other.hi; diff != 0 {
+		return bits.LeadingZeros64(diff)
+	}
+	return 64 + bits.LeadingZeros64(k.lo^other.lo)
+}
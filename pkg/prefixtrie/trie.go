@@ -0,0 +1,316 @@
+// Package prefixtrie provides a path-compressed binary trie keyed by IPv4 and
+// IPv6 prefixes, with longest-prefix-match lookups and ordered iteration.
+//
+// A Trie is safe for concurrent use: any number of readers may run in
+// parallel, while writers get exclusive access. Iterators hold the read lock
+// until the loop ends, so a loop body must not modify the trie it ranges over.
+package prefixtrie
+
+import (
+	"iter"
+	"net/netip"
+	"sync"
+)
+
+// Trie maps IPv4 and IPv6 prefixes to values of type V.
+// The zero value is an empty trie ready to use.
+type Trie[V any] struct {
+	mu   sync.RWMutex
+	ipv4 *node[V]
+	ipv6 *node[V]
+	size int
+}
+
+// node is a trie node. Nodes without a value only exist where two branches split.
+type node[V any] struct {
+	prefix   netip.Prefix
+	key      key
+	hasValue bool
+	value    V
+	children [2]*node[V]
+}
+
+// New returns an empty trie
+func New[V any]() *Trie[V] {
+	return &Trie[V]{}
+}
+
+// Len returns the number of prefixes stored in the trie
+func (t *Trie[V]) Len() int {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.size
+}
+
+// Insert stores a value for a prefix, replacing any existing value.
+// The prefix is masked first, so 10.1.2.3/8 is stored as 10.0.0.0/8.
+// Invalid prefixes are ignored.
+func (t *Trie[V]) Insert(prefix netip.Prefix, value V) {
+	t.Update(prefix, func(V, bool) V { return value })
+}
+
+// Update stores the value returned by fn for a prefix. fn receives the
+// current value and whether one was present. Invalid prefixes are ignored.
+func (t *Trie[V]) Update(prefix netip.Prefix, fn func(value V, ok bool) V) {
+	if !prefix.IsValid() {
+		return
+	}
+	prefix = prefix.Masked()
+	k := keyFromAddr(prefix.Addr())
+	bits := prefix.Bits()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	link := t.root(prefix.Addr())
+	for {
+		n := *link
+		if n == nil {
+			*link = t.newLeaf(prefix, k, fn)
+			return
+		}
+
+		nodeBits := n.prefix.Bits()
+		common := min(k.commonBits(n.key), nodeBits, bits)
+
+		// The node is an ancestor of the prefix, or the prefix itself
+		if common == nodeBits {
+			if bits == nodeBits {
+				if !n.hasValue {
+					t.size++
+				}
+				n.value = fn(n.value, n.hasValue)
+				n.hasValue = true
+				return
+			}
+			link = &n.children[k.bit(nodeBits)]
+			continue
+		}
+
+		// The prefix is an ancestor of the node
+		if common == bits {
+			leaf := t.newLeaf(prefix, k, fn)
+			leaf.children[n.key.bit(bits)] = n
+			*link = leaf
+			return
+		}
+
+		// The prefix and the node diverge, so join them under a new branch node
+		branchPrefix := netip.PrefixFrom(prefix.Addr(), common).Masked()
+		branch := &node[V]{prefix: branchPrefix, key: k.masked(common)}
+		branch.children[k.bit(common)] = t.newLeaf(prefix, k, fn)
+		branch.children[n.key.bit(common)] = n
+		*link = branch
+		return
+	}
+}
+
+// newLeaf creates a node holding the value fn returns for a new prefix
+func (t *Trie[V]) newLeaf(prefix netip.Prefix, k key, fn func(V, bool) V) *node[V] {
+	var zero V
+	t.size++
+	return &node[V]{prefix: prefix, key: k, hasValue: true, value: fn(zero, false)}
+}
+
+// Delete removes a prefix from the trie and reports whether it was present
+func (t *Trie[V]) Delete(prefix netip.Prefix) bool {
+	if !prefix.IsValid() {
+		return false
+	}
+	prefix = prefix.Masked()
+	k := keyFromAddr(prefix.Addr())
+	bits := prefix.Bits()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// Find the node, remembering the links to it and to its parent
+	var parentLink **node[V]
+	link := t.root(prefix.Addr())
+	for {
+		n := *link
+		if n == nil || n.prefix.Bits() > bits || k.commonBits(n.key) < n.prefix.Bits() {
+			return false
+		}
+		if n.prefix.Bits() == bits {
+			break
+		}
+		parentLink = link
+		link = &n.children[k.bit(n.prefix.Bits())]
+	}
+
+	n := *link
+	if !n.hasValue {
+		return false
+	}
+
+	var zero V
+	n.hasValue = false
+	n.value = zero
+	t.size--
+
+	// Remove the node if it no longer joins two branches
+	switch {
+	case n.children[0] != nil && n.children[1] != nil:
+		return true
+	case n.children[0] != nil:
+		*link = n.children[0]
+		return true
+	case n.children[1] != nil:
+		*link = n.children[1]
+		return true
+	}
+	*link = nil
+
+	// A valueless parent left with a single child is no longer needed either
+	if parentLink != nil {
+		parent := *parentLink
+		if !parent.hasValue {
+			if parent.children[0] != nil {
+				*parentLink = parent.children[0]
+			} else {
+				*parentLink = parent.children[1]
+			}
+		}
+	}
+	return true
+}
+
+// Get returns the value stored for exactly this prefix
+func (t *Trie[V]) Get(prefix netip.Prefix) (V, bool) {
+	var zero V
+	if !prefix.IsValid() {
+		return zero, false
+	}
+	prefix = prefix.Masked()
+	k := keyFromAddr(prefix.Addr())
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	n := *t.root(prefix.Addr())
+	for n != nil && n.prefix.Bits() <= prefix.Bits() && k.commonBits(n.key) >= n.prefix.Bits() {
+		if n.prefix.Bits() == prefix.Bits() {
+			if n.hasValue {
+				return n.value, true
+			}
+			break
+		}
+		n = n.children[k.bit(n.prefix.Bits())]
+	}
+	return zero, false
+}
+
+// Lookup returns the longest prefix containing the address, and its value
+func (t *Trie[V]) Lookup(addr netip.Addr) (netip.Prefix, V, bool) {
+	if !addr.IsValid() {
+		var zero V
+		return netip.Prefix{}, zero, false
+	}
+	return t.LookupPrefix(netip.PrefixFrom(addr, addr.BitLen()))
+}
+
+// LookupPrefix returns the longest stored prefix containing the given prefix, and its value
+func (t *Trie[V]) LookupPrefix(prefix netip.Prefix) (netip.Prefix, V, bool) {
+	for p, v := range t.Covering(prefix) {
+		return p, v, true
+	}
+	var zero V
+	return netip.Prefix{}, zero, false
+}
+
+// Covering returns an iterator over the stored prefixes containing the given
+// prefix, including the prefix itself, from the most to the least specific
+func (t *Trie[V]) Covering(prefix netip.Prefix) iter.Seq2[netip.Prefix, V] {
+	return func(yield func(netip.Prefix, V) bool) {
+		if !prefix.IsValid() {
+			return
+		}
+		prefix = prefix.Masked()
+		k := keyFromAddr(prefix.Addr())
+
+		t.mu.RLock()
+		defer t.mu.RUnlock()
+
+		// Walk down collecting matches, then yield them from the deepest up
+		var matches []*node[V]
+		n := *t.root(prefix.Addr())
+		for n != nil && n.prefix.Bits() <= prefix.Bits() && k.commonBits(n.key) >= n.prefix.Bits() {
+			if n.hasValue {
+				matches = append(matches, n)
+			}
+			if n.prefix.Bits() == prefix.Bits() {
+				break
+			}
+			n = n.children[k.bit(n.prefix.Bits())]
+		}
+
+		for i := len(matches) - 1; i >= 0; i-- {
+			if !yield(matches[i].prefix, matches[i].value) {
+				return
+			}
+		}
+	}
+}
+
+// CoveredBy returns an iterator over the stored prefixes contained in the
+// given prefix, including the prefix itself, in the order of All
+func (t *Trie[V]) CoveredBy(prefix netip.Prefix) iter.Seq2[netip.Prefix, V] {
+	return func(yield func(netip.Prefix, V) bool) {
+		if !prefix.IsValid() {
+			return
+		}
+		prefix = prefix.Masked()
+		k := keyFromAddr(prefix.Addr())
+
+		t.mu.RLock()
+		defer t.mu.RUnlock()
+
+		// Find the first node at or below the prefix, then walk its subtree
+		n := *t.root(prefix.Addr())
+		for n != nil {
+			if n.prefix.Bits() >= prefix.Bits() {
+				if k.commonBits(n.key) >= prefix.Bits() {
+					walk(n, yield)
+				}
+				return
+			}
+			if k.commonBits(n.key) < n.prefix.Bits() {
+				return
+			}
+			n = n.children[k.bit(n.prefix.Bits())]
+		}
+	}
+}
+
+// All returns an iterator over all stored prefixes: IPv4 before IPv6, and
+// within a family by address, with shorter prefixes before longer ones
+func (t *Trie[V]) All() iter.Seq2[netip.Prefix, V] {
+	return func(yield func(netip.Prefix, V) bool) {
+		t.mu.RLock()
+		defer t.mu.RUnlock()
+
+		if walk(t.ipv4, yield) {
+			walk(t.ipv6, yield)
+		}
+	}
+}
+
+// walk yields the values of a subtree in pre-order and reports whether to continue
+func walk[V any](n *node[V], yield func(netip.Prefix, V) bool) bool {
+	if n == nil {
+		return true
+	}
+	if n.hasValue && !yield(n.prefix, n.value) {
+		return false
+	}
+	return walk(n.children[0], yield) && walk(n.children[1], yield)
+}
+
+// root returns the link to the root node for the address's family
+func (t *Trie[V]) root(addr netip.Addr) **node[V] {
+	if addr.Is4() {
+		return &t.ipv4
+	}
+	return &t.ipv6
+}
@@ -0,0 +1,290 @@
+package prefixtrie
+
+import (
+	"math/rand/v2"
+	"net/netip"
+	"slices"
+	"sync"
+	"testing"
+)
+
+// prefixes parses a list of prefixes
+func prefixes(ss ...string) []netip.Prefix {
+	var result []netip.Prefix
+	for _, s := range ss {
+		result = append(result, netip.MustParsePrefix(s))
+	}
+	return result
+}
+
+// collect returns the prefixes of an iterator in order
+func collect(seq func(yield func(netip.Prefix, string) bool)) []netip.Prefix {
+	var result []netip.Prefix
+	for prefix := range seq {
+		result = append(result, prefix)
+	}
+	return result
+}
+
+// newTrie returns a trie holding each prefix with its string as the value
+func newTrie(ss ...string) *Trie[string] {
+	t := New[string]()
+	for _, prefix := range prefixes(ss...) {
+		t.Insert(prefix, prefix.String())
+	}
+	return t
+}
+
+func TestInsert(t *testing.T) {
+	trie := newTrie("10.0.0.0/8", "10.1.0.0/16", "2001:db8::/32")
+	if trie.Len() != 3 {
+		t.Fatalf("Len() = %d, want 3", trie.Len())
+	}
+
+	// Host bits are masked, and inserting again replaces the value
+	trie.Insert(netip.MustParsePrefix("10.1.2.3/16"), "replaced")
+	if value, ok := trie.Get(netip.MustParsePrefix("10.1.0.0/16")); !ok || value != "replaced" {
+		t.Errorf("Get(10.1.0.0/16) = %q, %v, want replaced", value, ok)
+	}
+	if trie.Len() != 3 {
+		t.Errorf("Len() after replacing = %d, want 3", trie.Len())
+	}
+
+	// Invalid prefixes are ignored
+	trie.Insert(netip.Prefix{}, "invalid")
+	if trie.Len() != 3 {
+		t.Errorf("Len() after an invalid prefix = %d, want 3", trie.Len())
+	}
+
+	// A prefix between two stored ones is not stored
+	if _, ok := trie.Get(netip.MustParsePrefix("10.0.0.0/12")); ok {
+		t.Error("Get(10.0.0.0/12) found a prefix that was never inserted")
+	}
+
+	// The families are kept apart, so 0.0.0.0/0 does not cover IPv6
+	trie.Insert(netip.MustParsePrefix("0.0.0.0/0"), "default")
+	if _, _, ok := trie.Lookup(netip.MustParseAddr("2001:db9::1")); ok {
+		t.Error("Lookup(2001:db9::1) matched an IPv4 prefix")
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	counts := New[int]()
+	for _, prefix := range prefixes("10.0.0.0/8", "10.0.0.0/8", "10.0.0.0/24", "10.0.0.0/8") {
+		counts.Update(prefix, func(count int, ok bool) int { return count + 1 })
+	}
+	if count, _ := counts.Get(netip.MustParsePrefix("10.0.0.0/8")); count != 3 {
+		t.Errorf("count of 10.0.0.0/8 = %d, want 3", count)
+	}
+	if counts.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", counts.Len())
+	}
+}
+
+func TestDelete(t *testing.T) {
+	trie := newTrie("10.0.0.0/8", "10.0.0.0/16", "10.128.0.0/16", "10.0.0.0/24", "2001:db8::/32")
+
+	tests := []struct {
+		prefix string
+		want   bool
+		left   []string
+	}{
+		// Never inserted, or only a branch point
+		{"10.0.0.0/12", false, []string{"10.0.0.0/8", "10.0.0.0/16", "10.0.0.0/24", "10.128.0.0/16", "2001:db8::/32"}},
+		{"192.168.0.0/16", false, []string{"10.0.0.0/8", "10.0.0.0/16", "10.0.0.0/24", "10.128.0.0/16", "2001:db8::/32"}},
+		// A node with two children stays as a branch point
+		{"10.0.0.0/8", true, []string{"10.0.0.0/16", "10.0.0.0/24", "10.128.0.0/16", "2001:db8::/32"}},
+		{"10.0.0.0/8", false, []string{"10.0.0.0/16", "10.0.0.0/24", "10.128.0.0/16", "2001:db8::/32"}},
+		// A node with one child is replaced by it
+		{"10.0.0.0/16", true, []string{"10.0.0.0/24", "10.128.0.0/16", "2001:db8::/32"}},
+		// A leaf whose parent is a branch point removes the parent too
+		{"10.128.0.0/16", true, []string{"10.0.0.0/24", "2001:db8::/32"}},
+		{"10.0.0.0/24", true, []string{"2001:db8::/32"}},
+		{"2001:db8::/32", true, nil},
+	}
+
+	for _, tt := range tests {
+		if got := trie.Delete(netip.MustParsePrefix(tt.prefix)); got != tt.want {
+			t.Errorf("Delete(%s) = %v, want %v", tt.prefix, got, tt.want)
+		}
+		if got := collect(trie.All()); !slices.Equal(got, prefixes(tt.left...)) {
+			t.Errorf("after Delete(%s): All() = %v, want %v", tt.prefix, got, tt.left)
+		}
+		if trie.Len() != len(tt.left) {
+			t.Errorf("after Delete(%s): Len() = %d, want %d", tt.prefix, trie.Len(), len(tt.left))
+		}
+	}
+}
+
+func TestLookup(t *testing.T) {
+	trie := newTrie("0.0.0.0/0", "10.0.0.0/8", "10.1.0.0/16", "10.1.2.0/24", "10.1.2.3/32", "2001:db8::/32", "2001:db8:1::/48")
+
+	tests := []struct {
+		addr string
+		want string // "" for no match
+	}{
+		{"10.1.2.3", "10.1.2.3/32"},
+		{"10.1.2.4", "10.1.2.0/24"},
+		{"10.1.3.1", "10.1.0.0/16"},
+		{"10.2.0.1", "10.0.0.0/8"},
+		{"192.168.0.1", "0.0.0.0/0"},
+		{"2001:db8:1::1", "2001:db8:1::/48"},
+		{"2001:db8:2::1", "2001:db8::/32"},
+		{"2001:db9::1", ""},
+	}
+
+	for _, tt := range tests {
+		prefix, value, ok := trie.Lookup(netip.MustParseAddr(tt.addr))
+		if tt.want == "" {
+			if ok {
+				t.Errorf("Lookup(%s) = %s, want no match", tt.addr, prefix)
+			}
+			continue
+		}
+		if !ok || prefix.String() != tt.want || value != tt.want {
+			t.Errorf("Lookup(%s) = %s, %q, %v, want %s", tt.addr, prefix, value, ok, tt.want)
+		}
+	}
+
+	prefix, _, ok := trie.LookupPrefix(netip.MustParsePrefix("10.1.2.0/23"))
+	if !ok || prefix.String() != "10.1.0.0/16" {
+		t.Errorf("LookupPrefix(10.1.2.0/23) = %s, %v, want 10.1.0.0/16", prefix, ok)
+	}
+}
+
+func TestCovering(t *testing.T) {
+	trie := newTrie("0.0.0.0/0", "10.0.0.0/8", "10.1.0.0/16", "10.2.0.0/16", "10.1.2.0/24", "2001:db8::/32")
+
+	tests := []struct {
+		prefix string
+		want   []string
+	}{
+		{"10.1.2.0/24", []string{"10.1.2.0/24", "10.1.0.0/16", "10.0.0.0/8", "0.0.0.0/0"}},
+		{"10.1.2.128/25", []string{"10.1.2.0/24", "10.1.0.0/16", "10.0.0.0/8", "0.0.0.0/0"}},
+		{"10.3.0.0/16", []string{"10.0.0.0/8", "0.0.0.0/0"}},
+		{"10.0.0.0/7", []string{"0.0.0.0/0"}},
+		{"2001:db8:1::/48", []string{"2001:db8::/32"}},
+		{"2001:db9::/32", nil},
+	}
+
+	for _, tt := range tests {
+		if got := collect(trie.Covering(netip.MustParsePrefix(tt.prefix))); !slices.Equal(got, prefixes(tt.want...)) {
+			t.Errorf("Covering(%s) = %v, want %v", tt.prefix, got, tt.want)
+		}
+	}
+
+	// Stopping early must not leave the trie locked
+	for range trie.Covering(netip.MustParsePrefix("10.1.2.0/24")) {
+		break
+	}
+	trie.Insert(netip.MustParsePrefix("10.1.2.0/25"), "")
+}
+
+func TestCoveredBy(t *testing.T) {
+	trie := newTrie("0.0.0.0/0", "10.0.0.0/8", "10.1.0.0/16", "10.2.0.0/16", "10.1.2.0/24", "192.168.0.0/16", "2001:db8::/32")
+
+	tests := []struct {
+		prefix string
+		want   []string
+	}{
+		{"10.0.0.0/8", []string{"10.0.0.0/8", "10.1.0.0/16", "10.1.2.0/24", "10.2.0.0/16"}},
+		{"10.0.0.0/15", []string{"10.1.0.0/16", "10.1.2.0/24"}},
+		{"10.1.2.0/25", nil},
+		{"::/0", []string{"2001:db8::/32"}},
+	}
+
+	for _, tt := range tests {
+		if got := collect(trie.CoveredBy(netip.MustParsePrefix(tt.prefix))); !slices.Equal(got, prefixes(tt.want...)) {
+			t.Errorf("CoveredBy(%s) = %v, want %v", tt.prefix, got, tt.want)
+		}
+	}
+}
+
+func TestAll(t *testing.T) {
+	trie := newTrie("2001:db8::/32", "10.1.0.0/16", "192.168.0.0/16", "10.0.0.0/8", "::/0", "10.0.0.0/16")
+	want := prefixes("10.0.0.0/8", "10.0.0.0/16", "10.1.0.0/16", "192.168.0.0/16", "::/0", "2001:db8::/32")
+	if got := collect(trie.All()); !slices.Equal(got, want) {
+		t.Errorf("All() = %v, want %v", got, want)
+	}
+}
+
+// TestRandom checks lookups against a linear search after random inserts
+// and deletes
+func TestRandom(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	trie := New[string]()
+	stored := map[netip.Prefix]bool{}
+
+	randomPrefix := func() netip.Prefix {
+		// Few distinct addresses, so prefixes nest and collide often
+		addr := netip.AddrFrom4([4]byte{10, byte(rng.IntN(4)), byte(rng.IntN(4) * 64), 0})
+		prefix, _ := addr.Prefix(8 + rng.IntN(17))
+		return prefix
+	}
+
+	for i := 0; i < 2000; i++ {
+		prefix := randomPrefix()
+		if rng.IntN(3) == 0 {
+			if got := trie.Delete(prefix); got != stored[prefix] {
+				t.Fatalf("Delete(%s) = %v, want %v", prefix, got, stored[prefix])
+			}
+			delete(stored, prefix)
+		} else {
+			trie.Insert(prefix, prefix.String())
+			stored[prefix] = true
+		}
+		if trie.Len() != len(stored) {
+			t.Fatalf("Len() = %d, want %d", trie.Len(), len(stored))
+		}
+
+		addr := randomPrefix().Addr()
+		var want netip.Prefix
+		for prefix := range stored {
+			if prefix.Contains(addr) && prefix.Bits() > want.Bits() {
+				want = prefix
+			}
+		}
+		got, _, ok := trie.Lookup(addr)
+		if ok != want.IsValid() || (ok && got != want) {
+			t.Fatalf("Lookup(%s) = %s, %v, want %s", addr, got, ok, want)
+		}
+	}
+}
+
+// TestConcurrent runs readers and writers at once; run it with -race
+func TestConcurrent(t *testing.T) {
+	trie := New[int]()
+	var wg sync.WaitGroup
+
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 256; i++ {
+				prefix := netip.PrefixFrom(netip.AddrFrom4([4]byte{10, byte(w), byte(i), 0}), 24)
+				trie.Insert(prefix, i)
+				if i%3 == 0 {
+					trie.Delete(prefix)
+				}
+			}
+		}()
+	}
+	for r := 0; r < 4; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 256; i++ {
+				trie.Lookup(netip.AddrFrom4([4]byte{10, byte(r), byte(i), 1}))
+				for range trie.Covering(netip.MustParsePrefix("10.0.0.0/24")) {
+				}
+				trie.Len()
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Each writer keeps the prefixes it did not delete
+	if want := 4 * (256 - 86); trie.Len() != want {
+		t.Errorf("Len() = %d, want %d", trie.Len(), want)
+	}
+}
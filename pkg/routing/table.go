@@ -0,0 +1,261 @@
+// Package routing loads routing tables and performs longest-prefix-match lookups
+package routing
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"strings"
+
+	"github.com/neontowel/ipcalc-go/pkg/prefixtrie"
+)
+
+// Route is a single routing table entry
+type Route struct {
+	Prefix netip.Prefix
+	Label  string // Next hop or label as given in the table
+	Line   int    // Line number in the source file, 0 if unknown
+}
+
+// String returns the route as "prefix label"
+func (r Route) String() string {
+	if r.Label == "" {
+		return r.Prefix.String()
+	}
+	return r.Prefix.String() + " " + r.Label
+}
+
+// Table is a routing table indexed by a prefix trie.
+// A prefix may carry several routes, for example equal-cost next hops.
+type Table struct {
+	routes prefixtrie.Trie[[]Route]
+	count  int
+}
+
+// Result is the outcome of looking up one address in a Table
+type Result struct {
+	Address netip.Addr
+	// Best holds the routes of the longest matching prefix
+	Best []Route
+	// Covering holds the routes of all less specific matching prefixes, most specific first
+	Covering []Route
+	// Fallback holds the routes that would win if the best match were withdrawn
+	Fallback []Route
+}
+
+// NewTable returns an empty routing table
+func NewTable() *Table {
+	return &Table{}
+}
+
+// Insert adds a route to the table
+func (t *Table) Insert(route Route) {
+	route.Prefix = route.Prefix.Masked()
+	t.routes.Update(route.Prefix, func(routes []Route, _ bool) []Route {
+		return append(routes, route)
+	})
+	t.count++
+}
+
+// Len returns the number of routes in the table
+func (t *Table) Len() int {
+	return t.count
+}
+
+// Lookup finds the routes matching an address
+func (t *Table) Lookup(addr netip.Addr) Result {
+	addr = addr.Unmap()
+
+	result := Result{Address: addr}
+	var matches [][]Route
+	for _, routes := range t.routes.Covering(netip.PrefixFrom(addr, addr.BitLen())) {
+		matches = append(matches, routes)
+	}
+	if len(matches) == 0 {
+		return result
+	}
+
+	result.Best = matches[0]
+	if len(matches) > 1 {
+		result.Fallback = matches[1]
+	}
+	for _, routes := range matches[1:] {
+		result.Covering = append(result.Covering, routes...)
+	}
+	return result
+}
+
+// LoadFile loads a routing table from a file
+func LoadFile(path string) (*Table, error) {
+	file, err := os.Open(path) // #nosec G304 -- the path is given by the user on purpose
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return Load(file)
+}
+
+// Load reads a routing table with one route per line. Lines hold a prefix
+// followed by a next hop or label, or are in Linux "ip route" format.
+// Blank lines and lines starting with '#' are ignored. A default route
+// that names no address, such as "default dev eth0", takes the address
+// family of the nearest route before it, or of the first route after it,
+// so that "ip -6 route" output loads as IPv6.
+func Load(r io.Reader) (*Table, error) {
+	table := NewTable()
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+
+	var family netip.Prefix // default route of the last route's family
+	var pending []Route     // default routes waiting for a route with a family
+	for scanner.Scan() {
+		lineNumber++
+		route, ok, err := parseLine(scanner.Text())
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+		if !ok {
+			continue
+		}
+		route.Line = lineNumber
+
+		if !route.Prefix.IsValid() {
+			if !family.IsValid() {
+				pending = append(pending, route)
+				continue
+			}
+			route.Prefix = family
+		}
+		family = defaultRoute(route.Prefix.Addr().Is6())
+
+		for _, waiting := range pending {
+			waiting.Prefix = family
+			table.Insert(waiting)
+		}
+		pending = nil
+		table.Insert(route)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	// With no other routes, read them as "ip route" does by default
+	for _, route := range pending {
+		route.Prefix = defaultRoute(false)
+		table.Insert(route)
+	}
+
+	return table, nil
+}
+
+// routeTypes are the route type keywords that may precede the prefix in "ip route" output
+var routeTypes = map[string]bool{
+	"unicast":     true,
+	"local":       true,
+	"broadcast":   true,
+	"multicast":   true,
+	"blackhole":   true,
+	"unreachable": true,
+	"prohibit":    true,
+	"throw":       true,
+	"nat":         true,
+	"anycast":     true,
+}
+
+// ParseLine parses one routing table line. It reports false for blank and
+// comment lines. A default route that names no address is read as the IPv4
+// default route, as "ip route" lists IPv4 routes unless given -6.
+func ParseLine(line string) (Route, bool, error) {
+	route, ok, err := parseLine(line)
+	if ok && !route.Prefix.IsValid() {
+		route.Prefix = defaultRoute(false)
+	}
+	return route, ok, err
+}
+
+// parseLine parses one routing table line like ParseLine, but leaves the
+// prefix of a default route that names no address invalid
+func parseLine(line string) (Route, bool, error) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+		return Route{}, false, nil
+	}
+
+	var routeType string
+	if routeTypes[fields[0]] && len(fields) > 1 {
+		routeType = fields[0]
+		fields = fields[1:]
+	}
+
+	prefix, err := parsePrefix(fields[0], fields[1:])
+	if err != nil {
+		return Route{}, false, err
+	}
+
+	label := parseLabel(fields[1:])
+	if routeType != "" {
+		label = strings.TrimSpace(routeType + " " + label)
+	}
+
+	return Route{Prefix: prefix, Label: label}, true, nil
+}
+
+// parsePrefix parses a route prefix. "default" takes the family of the
+// address the rest of the line mentions, and is invalid when it mentions
+// none. A bare address is a host route.
+func parsePrefix(prefixStr string, rest []string) (netip.Prefix, error) {
+	if prefixStr == "default" {
+		for _, field := range rest {
+			if addr, err := netip.ParseAddr(field); err == nil {
+				return defaultRoute(addr.Is6()), nil
+			}
+		}
+		return netip.Prefix{}, nil
+	}
+
+	if strings.Contains(prefixStr, "/") {
+		prefix, err := netip.ParsePrefix(prefixStr)
+		if err != nil {
+			return netip.Prefix{}, fmt.Errorf("invalid prefix: %s", prefixStr)
+		}
+		if prefix.Addr().Is4In6() && prefix.Bits() >= 96 {
+			return netip.PrefixFrom(prefix.Addr().Unmap(), prefix.Bits()-96), nil
+		}
+		return prefix, nil
+	}
+
+	addr, err := netip.ParseAddr(prefixStr)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("invalid prefix: %s", prefixStr)
+	}
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// defaultRoute returns the default route of an address family
+func defaultRoute(ipv6 bool) netip.Prefix {
+	if ipv6 {
+		return netip.PrefixFrom(netip.IPv6Unspecified(), 0)
+	}
+	return netip.PrefixFrom(netip.IPv4Unspecified(), 0)
+}
+
+// parseLabel extracts the next hop and device from "ip route" attributes,
+// or returns the remaining fields unchanged for plain "prefix label" lines
+func parseLabel(fields []string) string {
+	var parts []string
+	for i := 0; i+1 < len(fields); i++ {
+		switch fields[i] {
+		case "via", "dev":
+			parts = append(parts, fields[i], fields[i+1])
+			i++
+		}
+	}
+	if len(parts) > 0 {
+		return strings.Join(parts, " ")
+	}
+	return strings.Join(fields, " ")
+}
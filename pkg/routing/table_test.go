@@ -0,0 +1,180 @@
+package routing
+
+import (
+	"fmt"
+	"net/netip"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseLine(t *testing.T) {
+	tests := []struct {
+		line   string
+		prefix string // "" when the line holds no route
+		label  string
+		err    bool
+	}{
+		{line: ""},
+		{line: "   "},
+		{line: "# static routes"},
+		{line: "10.0.0.0/8 core", prefix: "10.0.0.0/8", label: "core"},
+		{line: "10.0.0.0/8 core router 1", prefix: "10.0.0.0/8", label: "core router 1"},
+		{line: "10.1.2.3/16", prefix: "10.1.2.3/16"},
+		{line: "10.0.0.1 loopback", prefix: "10.0.0.1/32", label: "loopback"},
+		{line: "2001:db8::1 loopback", prefix: "2001:db8::1/128", label: "loopback"},
+		{line: "::ffff:10.0.0.0/104 mapped", prefix: "10.0.0.0/8", label: "mapped"},
+		{line: "10.1.0.0/16 via 192.168.1.2 dev eth0 proto static metric 100", prefix: "10.1.0.0/16", label: "via 192.168.1.2 dev eth0"},
+		{line: "192.168.1.0/24 dev eth0 proto kernel scope link src 192.168.1.10", prefix: "192.168.1.0/24", label: "dev eth0"},
+		{line: "default via 192.168.1.1 dev eth0", prefix: "0.0.0.0/0", label: "via 192.168.1.1 dev eth0"},
+		{line: "default via fe80::1 dev eth0 proto ra metric 1024 pref medium", prefix: "::/0", label: "via fe80::1 dev eth0"},
+		// Without an address the family is unknown, and ParseLine reads IPv4
+		{line: "default dev eth0 metric 1024", prefix: "0.0.0.0/0", label: "dev eth0"},
+		{line: "blackhole 10.9.0.0/16", prefix: "10.9.0.0/16", label: "blackhole"},
+		{line: "unreachable 2001:db8::/32 dev lo metric 1024", prefix: "2001:db8::/32", label: "unreachable dev lo"},
+		{line: "10.0.0.0/33 core", err: true},
+		{line: "core 10.0.0.0/8", err: true},
+	}
+
+	for _, tt := range tests {
+		route, ok, err := ParseLine(tt.line)
+		if tt.err {
+			if err == nil {
+				t.Errorf("ParseLine(%q) = %v, want an error", tt.line, route)
+			}
+			continue
+		}
+		if err != nil || ok != (tt.prefix != "") {
+			t.Errorf("ParseLine(%q) = %v, %v, %v, want %q", tt.line, route, ok, err, tt.prefix)
+			continue
+		}
+		if ok && (route.Prefix.String() != tt.prefix || route.Label != tt.label) {
+			t.Errorf("ParseLine(%q) = %s %q, want %s %q", tt.line, route.Prefix, route.Label, tt.prefix, tt.label)
+		}
+	}
+}
+
+func TestLoadDefaultFamily(t *testing.T) {
+	tests := []struct {
+		name  string
+		table string
+		want  []string // prefix and line of every route, in table order
+	}{
+		{
+			name: "ip -6 route",
+			table: "2001:db8::/64 dev eth0 proto kernel metric 256 pref medium\n" +
+				"fe80::/64 dev eth0 proto kernel metric 256 pref medium\n" +
+				"default dev eth0 metric 1024\n",
+			want: []string{"::/0 3", "2001:db8::/64 1", "fe80::/64 2"},
+		},
+		{
+			name:  "default before the routes that show its family",
+			table: "default dev eth0 metric 1024\n# comment\n2001:db8::/64 dev eth0\n",
+			want:  []string{"::/0 1", "2001:db8::/64 3"},
+		},
+		{
+			name:  "ip route",
+			table: "default dev ppp0 scope link\n10.0.0.0/8 dev ppp0\n",
+			want:  []string{"0.0.0.0/0 1", "10.0.0.0/8 2"},
+		},
+		{
+			name: "ip route followed by ip -6 route",
+			table: "10.0.0.0/8 dev eth0\ndefault dev eth0\n" +
+				"2001:db8::/64 dev eth1\ndefault dev eth1\n",
+			want: []string{"0.0.0.0/0 2", "10.0.0.0/8 1", "::/0 4", "2001:db8::/64 3"},
+		},
+		{
+			name:  "only a default route",
+			table: "default dev eth0\n",
+			want:  []string{"0.0.0.0/0 1"},
+		},
+	}
+
+	for _, tt := range tests {
+		table, err := Load(strings.NewReader(tt.table))
+		if err != nil {
+			t.Errorf("%s: Load() failed: %v", tt.name, err)
+			continue
+		}
+
+		var got []string
+		for _, routes := range table.routes.All() {
+			for _, route := range routes {
+				got = append(got, fmt.Sprintf("%s %d", route.Prefix, route.Line))
+			}
+		}
+		if !slices.Equal(got, tt.want) || table.Len() != len(tt.want) {
+			t.Errorf("%s: Load() = %v, Len() %d, want %v", tt.name, got, table.Len(), tt.want)
+		}
+	}
+}
+
+func TestLoadError(t *testing.T) {
+	_, err := Load(strings.NewReader("10.0.0.0/8 core\n10.0.0.0/33 bad\n"))
+	if err == nil || err.Error() != "line 2: invalid prefix: 10.0.0.0/33" {
+		t.Errorf("Load() error = %v, want line 2: invalid prefix: 10.0.0.0/33", err)
+	}
+}
+
+func TestLookup(t *testing.T) {
+	table, err := Load(strings.NewReader(`
+default via 192.168.1.1 dev eth0
+10.0.0.0/8 core
+10.1.0.0/16 via 192.168.1.2 dev eth0
+10.1.0.0/16 via 192.168.1.3 dev eth0
+10.1.2.0/24 dev eth1
+2001:db8::/32 transit
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		addr     string
+		best     []string
+		fallback []string
+		covering []string
+	}{
+		{
+			addr:     "10.1.2.3",
+			best:     []string{"10.1.2.0/24 dev eth1"},
+			fallback: []string{"10.1.0.0/16 via 192.168.1.2 dev eth0", "10.1.0.0/16 via 192.168.1.3 dev eth0"},
+			covering: []string{"10.1.0.0/16 via 192.168.1.2 dev eth0", "10.1.0.0/16 via 192.168.1.3 dev eth0", "10.0.0.0/8 core", "0.0.0.0/0 via 192.168.1.1 dev eth0"},
+		},
+		{
+			addr:     "10.2.0.1",
+			best:     []string{"10.0.0.0/8 core"},
+			fallback: []string{"0.0.0.0/0 via 192.168.1.1 dev eth0"},
+			covering: []string{"0.0.0.0/0 via 192.168.1.1 dev eth0"},
+		},
+		{
+			addr: "192.0.2.1",
+			best: []string{"0.0.0.0/0 via 192.168.1.1 dev eth0"},
+		},
+		{
+			addr: "::ffff:10.2.0.1",
+			best: []string{"10.0.0.0/8 core"}, fallback: []string{"0.0.0.0/0 via 192.168.1.1 dev eth0"},
+			covering: []string{"0.0.0.0/0 via 192.168.1.1 dev eth0"},
+		},
+		{addr: "2001:db8::1", best: []string{"2001:db8::/32 transit"}},
+		{addr: "2001:db9::1"},
+	}
+
+	for _, tt := range tests {
+		result := table.Lookup(netip.MustParseAddr(tt.addr))
+		best, fallback, covering := routeStrings(result.Best), routeStrings(result.Fallback), routeStrings(result.Covering)
+		if !slices.Equal(best, tt.best) || !slices.Equal(fallback, tt.fallback) || !slices.Equal(covering, tt.covering) {
+			t.Errorf("Lookup(%s) = %v, fallback %v, covering %v, want %v, %v, %v",
+				tt.addr, best, fallback, covering, tt.best, tt.fallback, tt.covering)
+		}
+	}
+}
+
+// routeStrings returns the String of each route
+func routeStrings(routes []Route) []string {
+	var result []string
+	for _, route := range routes {
+		result = append(result, route.String())
+	}
+	return result
+}